@@ -0,0 +1,311 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// ccittCode is a code of the CCITT fax coding, read most significant bit
+// first
+type ccittCode struct {
+	len  int
+	bits uint32
+}
+
+// Codes of the two-dimensional coding modes. The vertical modes map to the
+// offset of a1 from b1.
+const (
+	ccittPass       = 10
+	ccittHorizontal = 11
+)
+
+var ccittModes = map[ccittCode]int{}
+
+// Run length codes of the white and black runs: the terminating codes of the
+// runs of 0 to 63 pixels, followed by the makeup codes of the multiples of 64.
+// The makeup codes of 1792 pixels and more are common to both colors.
+var ccittWhiteRuns, ccittBlackRuns = map[ccittCode]int{}, map[ccittCode]int{}
+
+func init() {
+	for code, mode := range map[string]int{
+		"0001": ccittPass, "001": ccittHorizontal, "1": 0,
+		"011": 1, "000011": 2, "0000011": 3, "010": -1, "000010": -2, "0000010": -3,
+	} {
+		ccittModes[ccittParseCode(code)] = mode
+	}
+	white := []string{
+		"00110101", "000111", "0111", "1000", "1011", "1100", "1110", "1111",
+		"10011", "10100", "00111", "01000", "001000", "000011", "110100", "110101",
+		"101010", "101011", "0100111", "0001100", "0001000", "0010111", "0000011", "0000100",
+		"0101000", "0101011", "0010011", "0100100", "0011000", "00000010", "00000011", "00011010",
+		"00011011", "00010010", "00010011", "00010100", "00010101", "00010110", "00010111", "00101000",
+		"00101001", "00101010", "00101011", "00101100", "00101101", "00000100", "00000101", "00001010",
+		"00001011", "01010010", "01010011", "01010100", "01010101", "00100100", "00100101", "01011000",
+		"01011001", "01011010", "01011011", "01001010", "01001011", "00110010", "00110011", "00110100",
+		// Makeup codes of 64 to 1728
+		"11011", "10010", "010111", "0110111", "00110110", "00110111", "01100100", "01100101",
+		"01101000", "01100111", "011001100", "011001101", "011010010", "011010011", "011010100", "011010101",
+		"011010110", "011010111", "011011000", "011011001", "011011010", "011011011", "010011000", "010011001",
+		"010011010", "011000", "010011011",
+	}
+	black := []string{
+		"0000110111", "010", "11", "10", "011", "0011", "0010", "00011",
+		"000101", "000100", "0000100", "0000101", "0000111", "00000100", "00000111", "000011000",
+		"0000010111", "0000011000", "0000001000", "00001100111", "00001101000", "00001101100", "00000110111", "00000101000",
+		"00000010111", "00000011000", "000011001010", "000011001011", "000011001100", "000011001101", "000001101000", "000001101001",
+		"000001101010", "000001101011", "000011010010", "000011010011", "000011010100", "000011010101", "000011010110", "000011010111",
+		"000001101100", "000001101101", "000011011010", "000011011011", "000001010100", "000001010101", "000001010110", "000001010111",
+		"000001100100", "000001100101", "000001010010", "000001010011", "000000100100", "000000110111", "000000111000", "000000100111",
+		"000000101000", "000001011000", "000001011001", "000000101011", "000000101100", "000001011010", "000001100110", "000001100111",
+		// Makeup codes of 64 to 1728
+		"0000001111", "000011001000", "000011001001", "000001011011", "000000110011", "000000110100", "000000110101", "0000001101100",
+		"0000001101101", "0000001001010", "0000001001011", "0000001001100", "0000001001101", "0000001110010", "0000001110011", "0000001110100",
+		"0000001110101", "0000001110110", "0000001110111", "0000001010010", "0000001010011", "0000001010100", "0000001010101", "0000001011010",
+		"0000001011011", "0000001100100", "0000001100101",
+	}
+	common := []string{
+		"00000001000", "00000001100", "00000001101", "000000010010", "000000010011", "000000010100", "000000010101",
+		"000000010110", "000000010111", "000000011100", "000000011101", "000000011110", "000000011111",
+	}
+	for j := range white {
+		n := j
+		if j >= 64 {
+			n = (j - 63) * 64
+		}
+		ccittWhiteRuns[ccittParseCode(white[j])] = n
+		ccittBlackRuns[ccittParseCode(black[j])] = n
+	}
+	for j, code := range common {
+		ccittWhiteRuns[ccittParseCode(code)] = 1792 + 64*j
+		ccittBlackRuns[ccittParseCode(code)] = 1792 + 64*j
+	}
+}
+
+// ccittParseCode returns the code written with the binary digits of str
+func ccittParseCode(str string) (c ccittCode) {
+	for _, d := range str {
+		c.bits = c.bits<<1 | uint32(d-'0')
+		c.len++
+	}
+	return
+}
+
+// ccittReader reads the bits of CCITT fax data
+type ccittReader struct {
+	data []byte
+	pos  int // position of the next bit
+}
+
+// bit returns the next bit
+func (r *ccittReader) bit() (uint32, error) {
+	if r.pos >= 8*len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos>>3] >> (7 - uint(r.pos&7)) & 1
+	r.pos++
+	return uint32(b), nil
+}
+
+// code reads the next code of table, whose codes are at most 13 bits long
+func (r *ccittReader) code(table map[ccittCode]int) (int, error) {
+	var c ccittCode
+	for c.len < 13 {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		c.bits = c.bits<<1 | b
+		c.len++
+		if v, ok := table[c]; ok {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid CCITT code at bit %d", r.pos-c.len)
+}
+
+// run reads the length of a run of pixels: makeup codes, if any, followed by
+// a terminating code
+func (r *ccittReader) run(black bool) (n int, err error) {
+	table := ccittWhiteRuns
+	if black {
+		table = ccittBlackRuns
+	}
+	for {
+		var v int
+		if v, err = r.code(table); err != nil {
+			return
+		}
+		n += v
+		if v < 64 {
+			return
+		}
+	}
+}
+
+// eol skips the end of line code that comes next, if any, with the zero bits
+// that fill the line before it
+func (r *ccittReader) eol() {
+	pos, zeros := r.pos, 0
+	for {
+		b, err := r.bit()
+		if err != nil {
+			break
+		}
+		if b == 1 {
+			if zeros >= 11 {
+				return
+			}
+			break
+		}
+		zeros++
+	}
+	r.pos = pos
+}
+
+// decodeCCITT decodes the CCITT fax data of a bilevel image w pixels wide
+// and h rows high. k is the K parameter of the CCITTFaxDecode filter:
+// positive for Group 3 two-dimensional coding, in which each line is coded
+// in one or two dimensions, and negative for Group 4 coding. The pixels are
+// returned with one byte each, 1 for black and 0 for white.
+func decodeCCITT(data []byte, w, h, k int) ([]byte, error) {
+	pix := make([]byte, w*h)
+	// The line above the first one is white
+	ref := make([]byte, w)
+	r := &ccittReader{data: data}
+	for y := 0; y < h; y++ {
+		row := pix[y*w : (y+1)*w]
+		twoDim := k < 0
+		if k > 0 {
+			r.eol()
+			tag, err := r.bit()
+			if err != nil {
+				return nil, err
+			}
+			twoDim = tag == 0
+		}
+		var err error
+		if twoDim {
+			err = ccittRow2D(r, row, ref)
+		} else {
+			err = ccittRow1D(r, row)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CCITT line %d: %v", y+1, err)
+		}
+		ref = row
+	}
+	return pix, nil
+}
+
+// ccittFill sets the pixels of row from start to end to black, 1, or white,
+// 0
+func ccittFill(row []byte, start, end int, black byte) error {
+	if start > end || end > len(row) {
+		return fmt.Errorf("run from %d to %d out of the line", start, end)
+	}
+	for j := start; j < end; j++ {
+		row[j] = black
+	}
+	return nil
+}
+
+// ccittRow1D decodes a line coded as alternate white and black runs
+func ccittRow1D(r *ccittReader, row []byte) error {
+	var black byte
+	for a0 := 0; a0 < len(row); black ^= 1 {
+		n, err := r.run(black == 1)
+		if err == nil {
+			err = ccittFill(row, a0, a0+n, black)
+		}
+		if err != nil {
+			return err
+		}
+		a0 += n
+	}
+	return nil
+}
+
+// ccittRow2D decodes a line coded with reference to the line above, ref
+func ccittRow2D(r *ccittReader, row, ref []byte) error {
+	// a0 is the changing element before which the line is decoded, -1 at
+	// the start of the line, and color is its color
+	a0, color := -1, byte(0)
+	for a0 < len(row) {
+		mode, err := r.code(ccittModes)
+		if err != nil {
+			return err
+		}
+		start := a0
+		if start < 0 {
+			start = 0
+		}
+		switch mode {
+		case ccittPass:
+			b2 := ccittChange(ref, a0, color, true)
+			if err = ccittFill(row, start, b2, color); err != nil {
+				return err
+			}
+			a0 = b2
+		case ccittHorizontal:
+			for j := byte(0); j < 2; j++ {
+				n, err := r.run(color^j == 1)
+				if err == nil {
+					err = ccittFill(row, start, start+n, color^j)
+				}
+				if err != nil {
+					return err
+				}
+				start += n
+			}
+			a0 = start
+		default:
+			a1 := ccittChange(ref, a0, color, false) + mode
+			if err = ccittFill(row, start, a1, color); err != nil {
+				return err
+			}
+			a0 = a1
+			color ^= 1
+		}
+	}
+	return nil
+}
+
+// ccittChange returns b1, the first changing element of the reference line
+// ref to the right of a0 that changes to the color opposite to color, or b2,
+// the next changing element, if second is set. The end of the line counts as
+// a changing element.
+func ccittChange(ref []byte, a0 int, color byte, second bool) int {
+	j := a0
+	if j < 0 {
+		j = 0
+	} else {
+		for j < len(ref) && ref[j] != color {
+			j++
+		}
+	}
+	for j < len(ref) && ref[j] == color {
+		j++
+	}
+	if second {
+		for j < len(ref) && ref[j] != color {
+			j++
+		}
+	}
+	return j
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// Names of the table themes returned by NamedTableTheme()
+const (
+	ThemeStriped   = "striped"   // Dark header, alternating light grey rows, no rules
+	ThemeGrid      = "grid"      // Every cell boxed, light grey header
+	ThemeMinimal   = "minimal"   // Rules below the header and the last row only
+	ThemeFinancial = "financial" // Rule below the header, last row as a total between a rule and a double rule
+)
+
+// BorderSide defines one side of the border of a cell
+type BorderSide struct {
+	Width  float64   // Line width in user units, no line if zero
+	Color  *Color    // Line color, black if nil
+	Dash   []float64 // Dashes and gaps as in SetDashPattern(), solid line if empty
+	Double bool      // Draw two parallel lines, one line width apart, as below a total
+}
+
+// CellPadding is the room left between the sides of a cell and its text, in
+// user units
+type CellPadding struct {
+	Left, Top, Right, Bottom float64
+}
+
+// CellStyle defines the appearance of a cell written by CellFormatStyle() or
+// by a table. The zero value is a cell without border, background or padding.
+type CellStyle struct {
+	Left, Top, Right, Bottom BorderSide  // Sides of the border
+	Padding                  CellPadding // Room around the text
+	Radius                   float64     // Radius of the rounded corners in user units, square corners if zero
+	Fill                     *Color      // Background, none if nil
+	TextColor                *Color      // Text color, current text color if nil
+	FontStyle                string      // Font style ("B", "I"...), current style if empty
+}
+
+// SetBorder sets the four sides of the border of s to side and returns s.
+func (s CellStyle) SetBorder(side BorderSide) CellStyle {
+	s.Left, s.Top, s.Right, s.Bottom = side, side, side, side
+	return s
+}
+
+// TableTheme is a set of cell styles for the rows of a table, used by
+// Table() once set with SetTableTheme() and by the TableBuilder of a
+// TableSpec with a Theme.
+type TableTheme struct {
+	Header CellStyle  // Style of the header rows
+	Body   CellStyle  // Style of the body rows
+	Stripe *CellStyle // Style of every other body row, from the second one, Body if nil
+	Total  *CellStyle // Style of the last row, taken as a total, Body or Stripe if nil
+}
+
+// RowStyle returns the style of row r of a table of n rows whose first header
+// rows make up the header.
+func (th *TableTheme) RowStyle(r, n, header int) CellStyle {
+	switch {
+	case r < header:
+		return th.Header
+	case r == n-1 && th.Total != nil:
+		return *th.Total
+	case (r-header)%2 == 1 && th.Stripe != nil:
+		return *th.Stripe
+	}
+	return th.Body
+}
+
+// NamedTableTheme returns one of the predefined themes ThemeStriped,
+// ThemeGrid, ThemeMinimal and ThemeFinancial, sized for the unit of
+// measure and the cell margin of the document. The returned theme can be
+// modified freely.
+func (f *Fpdf) NamedTableTheme(name string) (theme *TableTheme, err error) {
+	thin := 0.567 / f.k // 0.2 mm, the default line width
+	pad := CellPadding{f.cMargin, f.cMargin / 2, f.cMargin, f.cMargin / 2}
+	rule := BorderSide{Width: thin}
+	theme = &TableTheme{}
+	theme.Header.Padding = pad
+	theme.Header.FontStyle = "B"
+	theme.Body.Padding = pad
+	// Copies of the package colors, which the theme must not share
+	dark, light, white := *DARK_GREY, *LIGHT_GREY, *WHITE
+	switch name {
+	case ThemeStriped:
+		theme.Header.Fill = &dark
+		theme.Header.TextColor = &white
+		stripe := theme.Body
+		stripe.Fill = &light
+		theme.Stripe = &stripe
+	case ThemeGrid:
+		theme.Header = theme.Header.SetBorder(rule)
+		theme.Header.Fill = &light
+		theme.Body = theme.Body.SetBorder(rule)
+	case ThemeMinimal:
+		theme.Header.Bottom = BorderSide{Width: 2 * thin}
+		total := theme.Body
+		total.Bottom = rule
+		theme.Total = &total
+	case ThemeFinancial:
+		theme.Header.Bottom = rule
+		total := theme.Body
+		total.FontStyle = "B"
+		total.Top = rule
+		total.Bottom = BorderSide{Width: thin, Double: true}
+		theme.Total = &total
+	default:
+		return nil, fmt.Errorf("unknown table theme %q", name)
+	}
+	return
+}
+
+// SetTableTheme sets the theme used by Table(), TableX(), TableXY() and
+// TableXCenter() to draw their cells. The header flag of these functions
+// selects the Header style for the first row and their evenOdd flag enables
+// the Stripe style. A nil theme restores their default look.
+func (f *Fpdf) SetTableTheme(theme *TableTheme) {
+	f.tableTheme = theme
+}
+
+// same reports whether sides b and o are drawn alike
+func (b BorderSide) same(o BorderSide) bool {
+	if b.Width != o.Width || b.Double != o.Double || len(b.Dash) != len(o.Dash) {
+		return false
+	}
+	if (b.Color == nil) != (o.Color == nil) || b.Color != nil && *b.Color != *o.Color {
+		return false
+	}
+	for j := range b.Dash {
+		if b.Dash[j] != o.Dash[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// setBorderSide selects the line width, color and dash pattern of side b
+func (f *Fpdf) setBorderSide(b BorderSide) {
+	if f.lineWidth != b.Width {
+		f.SetLineWidth(b.Width)
+	}
+	if b.Color != nil {
+		b.Color.ToDrawColor(f)
+	} else {
+		f.SetDrawColor(0, 0, 0)
+	}
+	if len(b.Dash) > 0 || len(f.dashArray) > 0 {
+		f.SetDashPattern(b.Dash, 0)
+	}
+}
+
+// drawCellBox draws the background and the border of a cell of style s at x
+// and y, w wide and h high. Rounded corners apply to the background and to a
+// border whose four sides are alike; other borders are drawn square.
+func (f *Fpdf) drawCellBox(x, y, w, h float64, s *CellStyle) {
+	ps := f.pageState()
+	dashArray, dashPhase := f.dashArray, f.dashPhase
+	r := math.Max(0, math.Min(s.Radius, math.Min(w, h)/2))
+	uniform := s.Left.same(s.Top) && s.Left.same(s.Right) && s.Left.same(s.Bottom)
+	drawn := false
+	if s.Fill != nil {
+		s.Fill.ToFillColor(f)
+		f.cellBoxPath(x, y, w, h, r, "f")
+	}
+	if uniform && s.Left.Width > 0 {
+		f.setBorderSide(s.Left)
+		drawn = true
+		f.cellBoxPath(x, y, w, h, r, "S")
+		if s.Left.Double {
+			d := 2 * s.Left.Width
+			f.cellBoxPath(x+d, y+d, w-2*d, h-2*d, math.Max(0, r-d), "S")
+		}
+	} else if !uniform {
+		for _, side := range []struct {
+			b              BorderSide
+			x1, y1, x2, y2 float64
+			dx, dy         float64 // direction of the inner line of a double side
+		}{
+			{s.Left, x, y, x, y + h, 1, 0},
+			{s.Top, x, y, x + w, y, 0, 1},
+			{s.Right, x + w, y, x + w, y + h, -1, 0},
+			{s.Bottom, x, y + h, x + w, y + h, 0, -1},
+		} {
+			if side.b.Width <= 0 {
+				continue
+			}
+			f.setBorderSide(side.b)
+			drawn = true
+			f.Line(side.x1, side.y1, side.x2, side.y2)
+			if side.b.Double {
+				d := 2 * side.b.Width
+				f.Line(side.x1+d*side.dx, side.y1+d*side.dy, side.x2+d*side.dx, side.y2+d*side.dy)
+			}
+		}
+	}
+	if drawn {
+		if f.lineWidth != ps.lineWidth {
+			f.SetLineWidth(ps.lineWidth)
+		}
+		if f.color.draw.str != ps.draw.str {
+			f.color.draw = ps.draw
+			f.out(ps.draw.str)
+		}
+		if len(dashArray) > 0 || len(f.dashArray) > 0 {
+			f.dashArray, f.dashPhase = dashArray, dashPhase
+			f.outputDashPattern()
+		}
+	}
+	if f.color.fill.str != ps.fill.str {
+		f.color.fill = ps.fill
+		f.out(ps.fill.str)
+	}
+	f.colorFlag = ps.colorFlag
+}
+
+// cellBoxPath paints with opStr a rectangle whose corners are rounded with
+// radius r
+func (f *Fpdf) cellBoxPath(x, y, w, h, r float64, opStr string) {
+	if r == 0 {
+		f.outf("%.2f %.2f %.2f %.2f re %s", x*f.k, (f.h-y)*f.k, w*f.k, -h*f.k, opStr)
+		return
+	}
+	// The path begins with a q operator
+	f.roundedRectPath(x, y, w, h, r, r, r, r)
+	f.out(opStr + " Q")
+}
+
+// CellFormatStyle prints a cell like CellFormat() does, but draws it with
+// style rather than with a border string and the current colors: each side
+// of the border has its own width, color and dash pattern, the corners can
+// be rounded and the text is aligned within the box left by the padding,
+// the cell margin being ignored.
+//
+// The styles of a TableTheme, such as the ones returned by
+// NamedTableTheme(), can be applied this way to cells laid out by hand.
+func (f *Fpdf) CellFormatStyle(w, h float64, txtStr string, style CellStyle, ln int,
+	alignStr string, link int, linkStr string) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	if f.currentFont.Name == "" {
+		f.err = fmt.Errorf("font has not been set; unable to render text")
+		return f.err
+	}
+	if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.acceptPageBreak() {
+		x := f.x
+		if err = f.AddPageFormat(f.curOrientation, f.curPageSize); err != nil {
+			return
+		}
+		f.x = x
+	}
+	if w == 0 {
+		w = f.w - f.rMargin - f.x
+	}
+	x, y := f.x, f.y
+	f.drawCellBox(x, y, w, h, &style)
+	if txtStr != "" {
+		ps := f.pageState()
+		cMargin := f.cMargin
+		if style.FontStyle != "" {
+			err = f.SetFont(ps.familyStr, style.FontStyle, ps.fontSizePt)
+		}
+		if style.TextColor != nil {
+			style.TextColor.ToTextColor(f)
+		}
+		p := style.Padding
+		f.cMargin = 0
+		f.x, f.y = x+p.Left, y+p.Top
+		if err == nil {
+			err = f.CellFormat(w-p.Left-p.Right, h-p.Top-p.Bottom, txtStr, "", 0, alignStr, false, link, linkStr)
+		}
+		f.cMargin = cMargin
+		f.color.text = ps.text
+		f.colorFlag = ps.colorFlag
+		if err == nil && style.FontStyle != "" {
+			err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+		}
+		if err != nil {
+			f.SetError(err)
+			return
+		}
+	}
+	f.lasth = h
+	f.x, f.y = x, y
+	if ln > 0 {
+		f.y += h
+		if ln == 1 {
+			f.x = f.lMargin
+		}
+	} else {
+		f.x += w
+	}
+	return
+}
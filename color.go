@@ -118,6 +118,12 @@ func (c *Color) ToTextColor(fp *Fpdf) {
 	fp.SetTextColor(c.r, c.g, c.b)
 }
 
+// ToDrawColor() set Draw color with the color
+// of the current color instance.
+func (c *Color) ToDrawColor(fp *Fpdf) {
+	fp.SetDrawColor(c.r, c.g, c.b)
+}
+
 // FromFillColor() set current color instance
 // with the current Fill color.
 func (c *Color) FromFillColor(fp *Fpdf) *Color {
@@ -131,3 +137,10 @@ func (c *Color) FromTextColor(fp *Fpdf) *Color {
 	c.r, c.g, c.b = fp.GetTextColor()
 	return c
 }
+
+// FromDrawColor() set current color instance
+// with the current Draw color.
+func (c *Color) FromDrawColor(fp *Fpdf) *Color {
+	c.r, c.g, c.b = fp.GetDrawColor()
+	return c
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// utf8FontAlias looks for a UTF-8 font added before from a file with the
+// same content as utf8Bytes. If one is found, it is made available under
+// fontKey as well and true is returned. Otherwise fontKey is recorded as the
+// font of that content.
+func (f *Fpdf) utf8FontAlias(fontKey string, utf8Bytes []byte) bool {
+	id := fmt.Sprintf("%x", sha1.Sum(utf8Bytes))
+	if key, ok := f.fontHashes[id]; ok {
+		if def, ok := f.fonts[key]; ok {
+			f.fonts[fontKey] = def
+			return true
+		}
+	}
+	f.fontHashes[id] = fontKey
+	return false
+}
+
+// fontFileID identifies the content of the font file to be embedded
+func fontFileID(font []byte, info fontFileType, compressed bool) string {
+	return fmt.Sprintf("%x %d %d %t", sha1.Sum(font), info.length1, info.length2, compressed)
+}
+
+// importedObjClasses sorts the objects imported by gofpdi into classes of
+// objects with the same content, the objects they refer to included. It
+// returns the 0-based class of each object hash and, for each class, the
+// hash of the object written for it.
+func (f *Fpdf) importedObjClasses() (class map[string]int, reps []string) {
+	keys := make([]string, 0, len(f.importedObjs))
+	for key := range f.importedObjs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// The objects are first compared with their references blanked out
+	blank := strings.Repeat(" ", 40)
+	refs := make(map[string][]string, len(keys))
+	sigs := make(map[string]string, len(keys))
+	for _, key := range keys {
+		data := append([]byte(nil), f.importedObjs[key]...)
+		posList := make([]int, 0, len(f.importedObjPos[key]))
+		for pos := range f.importedObjPos[key] {
+			posList = append(posList, pos)
+		}
+		sort.Ints(posList)
+		for _, pos := range posList {
+			copy(data[pos:pos+40], blank)
+			refs[key] = append(refs[key], f.importedObjPos[key][pos])
+		}
+		sigs[key] = fmt.Sprintf("%x", sha1.Sum(data))
+	}
+
+	// Then the classes are split on the classes of the objects referred to,
+	// until no class is split anymore
+	count := -1
+	for {
+		ids := make(map[string]int, len(keys))
+		class = make(map[string]int, len(keys))
+		reps = reps[:0]
+		for _, key := range keys {
+			id, ok := ids[sigs[key]]
+			if !ok {
+				id = len(ids)
+				ids[sigs[key]] = id
+				reps = append(reps, key)
+			}
+			class[key] = id
+		}
+		if len(ids) == count {
+			return
+		}
+		count = len(ids)
+		for _, key := range keys {
+			var sig strings.Builder
+			sig.WriteString(strconv.Itoa(class[key]))
+			for _, h := range refs[key] {
+				c, ok := class[h]
+				if !ok {
+					c = -1
+				}
+				sig.WriteString(" " + strconv.Itoa(c))
+			}
+			sigs[key] = sig.String()
+		}
+	}
+}
@@ -37,9 +37,14 @@ type blendModeType struct {
 }
 
 type gradientType struct {
-	tp                int // 2: linear, 3: radial
+	tp                int // 2: linear, 3: radial, 6: Coons patch mesh
 	clr1Str, clr2Str  string
 	x1, y1, x2, y2, r float64
+	r0                float64 // radius of the start circle of a radial gradient
+	fnStr             string  // function of the colors, if not blending clr1Str to clr2Str
+	extendStr         string  // extension beyond the start and the end
+	gray              bool    // DeviceGray color space instead of DeviceRGB
+	meshStr           string  // patch data of a mesh, the bounds of which are x1, y1, x2, y2
 	objNum            int
 }
 
@@ -122,6 +127,7 @@ const (
 	colorModeRGB colorMode = iota
 	colorModeSpot
 	colorModeCMYK
+	colorModePattern
 )
 
 type colorType struct {
@@ -166,7 +172,7 @@ func (p PointType) XY() (float64, float64) {
 // methods.
 type ImageInfoType struct {
 	data  []byte  // Raw image data
-	smask []byte  // Soft Mask, an 8bit per-pixel transparency mask
+	smask []byte  // Soft Mask, a per-pixel transparency mask as deep as the image
 	n     int     // Image object number
 	w     float64 // Width
 	h     float64 // Height
@@ -177,19 +183,36 @@ type ImageInfoType struct {
 	dp    string  // DecodeParms
 	trns  []int   // Transparency mask
 	scale float64 // Document scale factor
-	dpi   float64 // Dots-per-inch found from image file (png only)
+	dpi   float64 // Dots-per-inch found from image file (png and tiff only)
+	icc   []byte  // ICC profile of the color space, if any
+	dcd   string  // Decode array, if any
+	ornt  int     // EXIF orientation of the pixels (jpeg only), upright if 0 or 1
+	mask  string  // SHA-1 checksum of the image used as soft mask, if any
 	i     string  // SHA-1 checksum of the above values.
+
+	downsample *DownsampleOptions // Downsampling of the image, that of the document if nil
+	placedDpi  float64            // Lowest resolution the image is placed at, in dots per inch
 }
 
+// generateImageID generates an image Id from the values that make up the
+// image object, so that images with the same content share the same Id
+// whatever their name, source or resolution
 func generateImageID(info *ImageInfoType) (string, error) {
-	b, err := info.GobEncode()
-	return fmt.Sprintf("%x", sha1.Sum(b)), err
+	fields := []interface{}{info.data, info.smask, info.w, info.h, info.cs,
+		info.pal, info.bpc, info.f, info.dp, info.trns, info.icc, info.dcd, info.ornt, info.mask}
+	w := new(bytes.Buffer)
+	encoder := gob.NewEncoder(w)
+	var err error
+	for j := 0; j < len(fields) && err == nil; j++ {
+		err = encoder.Encode(fields[j])
+	}
+	return fmt.Sprintf("%x", sha1.Sum(w.Bytes())), err
 }
 
 // GobEncode encodes the receiving image to a byte slice.
 func (info *ImageInfoType) GobEncode() (buf []byte, err error) {
 	fields := []interface{}{info.data, info.smask, info.n, info.w, info.h, info.cs,
-		info.pal, info.bpc, info.f, info.dp, info.trns, info.scale, info.dpi}
+		info.pal, info.bpc, info.f, info.dp, info.trns, info.scale, info.dpi, info.icc, info.dcd, info.ornt, info.mask}
 	w := new(bytes.Buffer)
 	encoder := gob.NewEncoder(w)
 	for j := 0; j < len(fields) && err == nil; j++ {
@@ -205,7 +228,8 @@ func (info *ImageInfoType) GobEncode() (buf []byte, err error) {
 // the receiving image.
 func (info *ImageInfoType) GobDecode(buf []byte) (err error) {
 	fields := []interface{}{&info.data, &info.smask, &info.n, &info.w, &info.h,
-		&info.cs, &info.pal, &info.bpc, &info.f, &info.dp, &info.trns, &info.scale, &info.dpi}
+		&info.cs, &info.pal, &info.bpc, &info.f, &info.dp, &info.trns, &info.scale, &info.dpi,
+		&info.icc, &info.dcd, &info.ornt, &info.mask}
 	r := bytes.NewBuffer(buf)
 	decoder := gob.NewDecoder(r)
 	for j := 0; j < len(fields) && err == nil; j++ {
@@ -501,6 +525,7 @@ type Fpdf struct {
 	isCurrentUTF8    bool                       // is current font used in utf-8 mode
 	isRTL            bool                       // is is right to left mode enabled
 	page             int                        // current page number
+	openPage         int                        // page being written, whose footer is still to be drawn
 	n                int                        // current object number
 	offsets          []int                      // array of object offsets
 	templates        map[string]Template        // templates used in this document
@@ -538,6 +563,7 @@ type Fpdf struct {
 	coreFonts        map[string]bool            // array of core font names
 	fonts            map[string]fontDefType     // array of used fonts
 	fontFiles        map[string]fontFileType    // array of font files
+	fontHashes       map[string]string          // keys of UTF-8 fonts by SHA-1 checksum of their file
 	diffs            []string                   // array of encoding differences
 	fontFamily       string                     // current font family
 	fontStyle        string                     // current font style
@@ -555,6 +581,22 @@ type Fpdf struct {
 	pageAttachments  [][]annotationAttach       // 1-based array of annotation for file attachments (per page)
 	outlines         []outlineType              // array of outlines
 	outlineRoot      int                        // root of outlines
+	toc              []tocEntryType             // entries of the table of contents
+	footnote         footnoteStateType          // notes waiting to be written
+	index            []indexEntryType           // entries of the alphabetical index
+	tableTheme       *TableTheme                // theme of the cells drawn by Table()
+	anchors          []anchorType               // anchors referred to by PageRef()
+	anchorIDs        map[string]int             // number of each anchor by name
+	pageRefs         map[string]int             // page reference placeholders and the number of their anchor
+	pageRefLabels    map[string]string          // labels expected for the anchors, from a previous layout pass
+	pageCountHint    int                        // number of pages expected, from a previous layout pass
+	sections         []*sectionType             // sections started by BeginSection()
+	nextSection      *sectionType               // section starting with the next page
+	runningHeader    *RunningSpec               // header drawn as each page is finished
+	runningFooter    *RunningSpec               // footer drawn as each page is finished
+	runningMarks     []runningMarkType          // chapters and sections shown by the running headers and footers
+	watermarks       []watermarkType            // stamps drawn on every page by AddWatermark()
+	downsample       *DownsampleOptions         // downsampling of the images set by SetImageDownsampling()
 	autoPageBreak    bool                       // automatic page breaking
 	acceptPageBreak  func() bool                // returns true to accept page break
 	pageBreakTrigger float64                    // threshold used to trigger page breaks
@@ -569,6 +611,7 @@ type Fpdf struct {
 	xmp              []byte                     // XMP metadata
 	producer         string                     // producer
 	title            string                     // title
+	titleText        string                     // title as set, for the {title} field of running headers
 	subject          string                     // subject
 	author           string                     // author
 	keywords         string                     // keywords
@@ -576,6 +619,8 @@ type Fpdf struct {
 	creationDate     time.Time                  // override for document CreationDate value
 	modDate          time.Time                  // override for document ModDate value
 	aliasNbPagesStr  string                     // alias for total number of pages
+	aliasPageNoStr   string                     // alias for the number of the current page
+	aliasSectNbStr   string                     // alias for the number of pages of the current section
 	pdfVersion       string                     // PDF version number
 	fontDirStr       string                     // location of font definition files
 	capStyle         int                        // line cap style: butt 0, round 1, square 2
@@ -587,6 +632,8 @@ type Fpdf struct {
 	blendMode        string                     // current blend mode
 	alpha            float64                    // current transpacency
 	gradientList     []gradientType             // slice[idx] of gradient records
+	softMasks        []softMaskType             // images clipping the drawing with ClipImage(), 1-based
+	patterns         []patternType              // tiling patterns, 1-based
 	clipNest         int                        // Number of active clipping contexts
 	transformNest    int                        // Number of active transformation contexts
 	err              error                      // Set if error occurs during life cycle of instance
@@ -29,6 +29,7 @@ const ALIGN_JUSTIFY = "justify"
 
 // Title() insert a title in the current page. Lvl parameter adjust font size (0 : Master title).
 // Fill color can be precise (nil otherwise)
+// The title becomes the {chapter} (lvl 0) or {section} (lvl 1) of the running headers.
 func (fp *Fpdf) Title(title string, lvl uint8, fontColor, fillColor *Color) (err error) {
 	coef_font := (2.0 - float64(lvl)*0.25) // coef for font
 
@@ -68,6 +69,8 @@ func (fp *Fpdf) Title(title string, lvl uint8, fontColor, fillColor *Color) (err
 	if err != nil {
 		return
 	}
+	// Chapter or section of the running headers and footers
+	fp.SetRunningMark(title, int(lvl))
 
 	// After spacing
 	err = fp.CellFormat(ww, fp.fontSize*2, "", "0", 1, "LM", false, 0, "")
@@ -98,8 +101,8 @@ func (fp *Fpdf) MultiCellOnFixedHeight(width, height float64, text, border, alig
 }
 
 // Table builds a table with the given content with the given width.
+// The cells are drawn with the theme set by SetTableTheme(), if any.
 func (fp *Fpdf) Table(width float64, table [][]string, align []string, header, evenOdd bool) (err error) {
-
 	x_orig := fp.GetX()
 	height := fp.fontSize * 1.2
 
@@ -107,7 +110,25 @@ func (fp *Fpdf) Table(width float64, table [][]string, align []string, header, e
 	orig_font := NewFontFromCurrent(fp)
 	new_font := orig_font.Copy()
 
+	// Styles of the rows taken from the current theme
+	var theme TableTheme
+	themed := fp.tableTheme != nil
+	if themed {
+		theme = *fp.tableTheme
+		if !evenOdd {
+			theme.Stripe = nil
+		}
+	}
+	header_rows := 0
+	if header {
+		header_rows = 1
+	}
+
 	for n_row, row := range table {
+		var style CellStyle
+		if themed {
+			style = theme.RowStyle(n_row, len(table), header_rows)
+		}
 		nb_col := float64(len(row))
 		for n, cell := range row {
 			ln := 0
@@ -124,6 +145,14 @@ func (fp *Fpdf) Table(width float64, table [][]string, align []string, header, e
 			if len(row) == len(align) {
 				_align = align[n]
 			}
+			if themed {
+				row_height := height + style.Padding.Top + style.Padding.Bottom
+				err = fp.CellFormatStyle(width/nb_col, row_height, cell, style, ln, _align, 0, "")
+				if err != nil {
+					return
+				}
+				continue
+			}
 			if evenOdd && n_row%2 == 0 {
 				LIGHT_GREY.ToFillColor(fp)
 			} else {
@@ -319,7 +348,7 @@ func (fp *Fpdf) ParagXRight(width, height float64, textStr string, alignStr stri
 }
 
 // SetHeader sets the header simplify with given left,
-// center and right text.
+// center and right text. See SetRunningHeader() for rich headers.
 func (fp *Fpdf) SetHeader(left, center, right string) {
 	// Update top margin for header
 	fp.SetTopMargin(fp.tMargin * 2.0)
@@ -352,7 +381,7 @@ func (fp *Fpdf) SetHeader(left, center, right string) {
 }
 
 // SetFooter sets the footer simplify with given left,
-// center and right text.
+// center and right text. See SetRunningFooter() for rich footers.
 func (fp *Fpdf) SetFooter(left, center, right string) {
 	// Update bottom margin for footer
 	if fp.bMargin < 20.0 {
@@ -435,14 +464,13 @@ func (fp *Fpdf) BulletedList(height float64, list []string, lvl int) {
 
 // BulletedListXY insert a bullet list at the specified
 // position with the specified list of string.
+// WriteList() writes lists with nested and wrapped items.
 func (fp *Fpdf) BulletedListXY(x, y, height float64, list []string, lvl int) {
 	if height < fp.fontSize {
 		height = fp.fontSize * 1.2
 	}
 	radius := 0.5
 	for n, str := range list {
-		fp.Line(x, y, x+5, y)
-		fp.Line(x, y, x, y+5)
 		fp.Bullet(x+5*float64(lvl+1), y+float64(n)*height-radius-fp.fontSize*0.1, radius, lvl)
 		fp.Text(x+5*float64(lvl+1)+4*radius, y+float64(n)*height, str)
 	}
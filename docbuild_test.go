@@ -106,3 +106,27 @@ func TestDocBuild(t *testing.T) {
 		t.Fatalf("%v", err)
 	}
 }
+
+func TestNamedTableThemeColors(t *testing.T) {
+	pdf, err := New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Failed to create PDF: %v", err)
+	}
+	theme, err := pdf.NamedTableTheme(ThemeStriped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*theme.Header.Fill = *NewRGBColor(255, 0, 0)
+	*theme.Header.TextColor = *NewRGBColor(0, 255, 0)
+	*theme.Stripe.Fill = *NewRGBColor(0, 0, 255)
+	if DARK_GREY.R() == 255 || WHITE.G() != 255 || WHITE.R() != 255 || LIGHT_GREY.B() == 255 {
+		t.Fatalf("changing a theme changed the package colors")
+	}
+	other, err := pdf.NamedTableTheme(ThemeStriped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Header.Fill.R() == 255 {
+		t.Fatalf("changing a theme changed the other themes")
+	}
+}
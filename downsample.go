@@ -0,0 +1,616 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+)
+
+// ResampleFilter is the filter used to downsample images, see
+// DownsampleOptions.
+type ResampleFilter int
+
+const (
+	// ResampleLanczos weights the source pixels with a three-lobed Lanczos
+	// window, which keeps the edges sharpest
+	ResampleLanczos ResampleFilter = iota
+	// ResampleBicubic weights the source pixels with a Catmull-Rom cubic
+	ResampleBicubic
+	// ResampleBilinear weights the source pixels linearly
+	ResampleBilinear
+	// ResampleBox averages the source pixels covered by each pixel, which is
+	// the fastest
+	ResampleBox
+)
+
+// DownsampleOptions sets how the images are downsampled and re-encoded when
+// the document is output, to keep its size in proportion to the size the
+// images are printed at. See SetImageDownsampling().
+//
+// MaxDpi is the highest resolution, in dots per inch, that an image keeps
+// at the largest size it is placed at. Images placed at a higher resolution
+// are resampled with Filter. They are not downsampled if MaxDpi is zero.
+//
+// JPEGQuality is the quality, from 1 to 100, of the images re-encoded with
+// the DCTDecode filter. JPEG images are always re-encoded that way, at
+// jpeg.DefaultQuality if JPEGQuality is zero. Other images are re-encoded
+// losslessly unless JPEGQuality is set; CMYK images are always re-encoded
+// losslessly.
+//
+// Gray converts the color images to grayscale.
+//
+// Only the images that are downsampled or converted are re-encoded. JPEG
+// 2000 images, bilevel CCITT images and images with a color key mask are
+// kept as they are, and so are the images that are not placed with Image()
+// or ImageOptions().
+type DownsampleOptions struct {
+	MaxDpi      float64
+	Filter      ResampleFilter
+	JPEGQuality int
+	Gray        bool
+}
+
+// SetImageDownsampling sets how the images of the document are downsampled
+// when it is output, once the size they are placed at is known. A nil value,
+// the default, keeps the images as they are. The Downsample field of
+// ImageOptions sets other options for an image.
+//
+// The resolution of an image is that of its largest placement with Image()
+// or ImageOptions(), whatever the transformations in effect.
+func (f *Fpdf) SetImageDownsampling(options *DownsampleOptions) {
+	f.downsample = options
+}
+
+// downsampleImages downsamples and re-encodes the images of keyList that
+// their options ask for. Images with the same content are written once, so
+// the one written keeps the resolution of their largest placement.
+func (f *Fpdf) downsampleImages(keyList []string) {
+	placed := make(map[string]float64)
+	options := make(map[string]*DownsampleOptions)
+	for _, key := range keyList {
+		info := f.images[key]
+		if dpi := info.placedDpi; dpi > 0 && (placed[info.i] == 0 || dpi < placed[info.i]) {
+			placed[info.i] = dpi
+		}
+		if info.downsample != nil && options[info.i] == nil {
+			options[info.i] = info.downsample
+		}
+	}
+	done := make(map[string]bool)
+	for _, key := range keyList {
+		info := f.images[key]
+		if done[info.i] {
+			continue
+		}
+		done[info.i] = true
+		opt := options[info.i]
+		if opt == nil {
+			opt = f.downsample
+		}
+		if opt == nil || placed[info.i] == 0 {
+			continue
+		}
+		if err := f.downsampleImage(info, placed[info.i], opt); err != nil {
+			f.err = fmt.Errorf("downsampling image %s: %v", key, err)
+			return
+		}
+	}
+}
+
+// downsampleImage resamples info, placed at dpi, and converts it to gray as
+// set by opt
+func (f *Fpdf) downsampleImage(info *ImageInfoType, dpi float64, opt *DownsampleOptions) error {
+	w, h := int(info.w), int(info.h)
+	if opt.MaxDpi > 0 && dpi > opt.MaxDpi {
+		scale := opt.MaxDpi / dpi
+		w = int(math.Max(1, math.Round(info.w*scale)))
+		h = int(math.Max(1, math.Round(info.h*scale)))
+	}
+	resample := w < int(info.w) || h < int(info.h)
+	gray := opt.Gray && info.cs != "DeviceGray"
+	if !resample && !gray {
+		return nil
+	}
+	px, err := info.pixels()
+	if err != nil || px == nil {
+		return err
+	}
+	if info.ornt >= 5 {
+		// The pixels keep the orientation they are stored in, which the
+		// placements of the image already account for
+		w, h = h, w
+	}
+	if gray {
+		px = px.gray()
+	}
+	if resample {
+		px = px.resample(w, h, opt.Filter)
+	}
+	options := GoImageOptions{
+		JPEG:    info.f == "DCTDecode" || opt.JPEGQuality > 0,
+		Quality: opt.JPEGQuality,
+	}
+	data, alpha := px.split()
+	info.w, info.h = float64(px.w), float64(px.h)
+	if info.ornt >= 5 {
+		info.w, info.h = info.h, info.w
+	}
+	info.bpc = 8
+	info.pal = nil
+	info.dp = ""
+	info.smask = nil
+	info.trns = nil
+	info.dcd = ""
+	switch px.colors {
+	case 1:
+		info.cs = "DeviceGray"
+	case 3:
+		info.cs = "DeviceRGB"
+	case 4:
+		info.cs = "DeviceCMYK"
+		options.JPEG = false
+	}
+	info.setICC(info.icc)
+	return f.encodeImage(info, data, alpha, px.colors, options)
+}
+
+// imagePixels holds the 8-bit samples of an image, with the alpha sample,
+// if any, after the color components of each pixel. CMYK samples are amounts
+// of ink.
+type imagePixels struct {
+	w, h   int
+	colors int
+	alpha  bool
+	pix    []byte
+}
+
+// channels returns the number of samples of a pixel
+func (px *imagePixels) channels() int {
+	if px.alpha {
+		return px.colors + 1
+	}
+	return px.colors
+}
+
+// split returns the color samples and the alpha samples, nil if the image
+// has no alpha channel
+func (px *imagePixels) split() (data, alpha []byte) {
+	if !px.alpha {
+		return px.pix, nil
+	}
+	n := px.w * px.h
+	data = make([]byte, 0, n*px.colors)
+	alpha = make([]byte, 0, n)
+	for pos := 0; pos < len(px.pix); pos += px.colors + 1 {
+		data = append(data, px.pix[pos:pos+px.colors]...)
+		alpha = append(alpha, px.pix[pos+px.colors])
+	}
+	return
+}
+
+// gray returns the image converted to grayscale
+func (px *imagePixels) gray() *imagePixels {
+	if px.colors == 1 {
+		return px
+	}
+	nch := px.channels()
+	out := &imagePixels{w: px.w, h: px.h, colors: 1, alpha: px.alpha}
+	out.pix = make([]byte, 0, len(px.pix)/nch*out.channels())
+	for pos := 0; pos < len(px.pix); pos += nch {
+		s := px.pix[pos:]
+		var r, g, b float64
+		if px.colors == 4 {
+			k := 255 - float64(s[3])
+			r = (255 - float64(s[0])) * k / 255
+			g = (255 - float64(s[1])) * k / 255
+			b = (255 - float64(s[2])) * k / 255
+		} else {
+			r, g, b = float64(s[0]), float64(s[1]), float64(s[2])
+		}
+		out.pix = append(out.pix, uint8(math.Round(0.299*r+0.587*g+0.114*b)))
+		if px.alpha {
+			out.pix = append(out.pix, s[px.colors])
+		}
+	}
+	return out
+}
+
+// orient returns the image stored with the EXIF orientation shown upright
+func (px *imagePixels) orient(orientation int) *imagePixels {
+	nch := px.channels()
+	out := &imagePixels{w: px.w, h: px.h, colors: px.colors, alpha: px.alpha}
+	if orientation >= 5 {
+		out.w, out.h = px.h, px.w
+	}
+	out.pix = make([]byte, len(px.pix))
+	for y := 0; y < px.h; y++ {
+		for x := 0; x < px.w; x++ {
+			dx, dy := x, y
+			switch orientation {
+			case 2:
+				dx = px.w - 1 - x
+			case 3:
+				dx, dy = px.w-1-x, px.h-1-y
+			case 4:
+				dy = px.h - 1 - y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = px.h-1-y, x
+			case 7:
+				dx, dy = px.h-1-y, px.w-1-x
+			case 8:
+				dx, dy = y, px.w-1-x
+			}
+			src := (y*px.w + x) * nch
+			copy(out.pix[(dy*out.w+dx)*nch:], px.pix[src:src+nch])
+		}
+	}
+	return out
+}
+
+// resampleKernel returns the weighting function of filter and its radius
+func resampleKernel(filter ResampleFilter) (func(x float64) float64, float64) {
+	switch filter {
+	case ResampleBicubic:
+		return func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1.5*x*x*x - 2.5*x*x + 1
+			}
+			if x < 2 {
+				return -0.5*x*x*x + 2.5*x*x - 4*x + 2
+			}
+			return 0
+		}, 2
+	case ResampleBilinear:
+		return func(x float64) float64 {
+			return math.Max(0, 1-math.Abs(x))
+		}, 1
+	case ResampleBox:
+		return func(x float64) float64 {
+			if math.Abs(x) <= 0.5 {
+				return 1
+			}
+			return 0
+		}, 0.5
+	}
+	return func(x float64) float64 {
+		if x == 0 {
+			return 1
+		}
+		if math.Abs(x) >= 3 {
+			return 0
+		}
+		x *= math.Pi
+		return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+	}, 3
+}
+
+// resampleTap is the weight of a source pixel in a resampled pixel
+type resampleTap struct {
+	pos    int
+	weight float32
+}
+
+// resampleTaps returns the source pixels, among src, that make up each of
+// dst pixels, with dst not larger than src
+func resampleTaps(src, dst int, kernel func(x float64) float64, radius float64) [][]resampleTap {
+	scale := float64(dst) / float64(src)
+	support := radius / scale
+	taps := make([][]resampleTap, dst)
+	for i := range taps {
+		center := (float64(i)+0.5)/scale - 0.5
+		lo, hi := int(math.Ceil(center-support)), int(math.Floor(center+support))
+		var sum float64
+		weights := make([]float64, 0, hi-lo+1)
+		for j := lo; j <= hi; j++ {
+			wt := kernel((float64(j) - center) * scale)
+			weights = append(weights, wt)
+			sum += wt
+		}
+		for j := lo; j <= hi; j++ {
+			wt := weights[j-lo]
+			if wt == 0 {
+				continue
+			}
+			// Pixels beyond the edges repeat those of the edges
+			pos := j
+			if pos < 0 {
+				pos = 0
+			} else if pos >= src {
+				pos = src - 1
+			}
+			if sum != 0 {
+				wt /= sum
+			}
+			taps[i] = append(taps[i], resampleTap{pos, float32(wt)})
+		}
+	}
+	return taps
+}
+
+// resample returns the image resampled to w by h pixels with filter, the
+// colors weighted by their alpha
+func (px *imagePixels) resample(w, h int, filter ResampleFilter) *imagePixels {
+	kernel, radius := resampleKernel(filter)
+	nch := px.channels()
+	xTaps := resampleTaps(px.w, w, kernel, radius)
+	yTaps := resampleTaps(px.h, h, kernel, radius)
+	// Horizontal pass over the rows of the source, premultiplied
+	row := make([]float32, px.w*nch)
+	tmp := make([]float32, px.h*w*nch)
+	for y := 0; y < px.h; y++ {
+		src := px.pix[y*px.w*nch : (y+1)*px.w*nch]
+		for j, v := range src {
+			row[j] = float32(v)
+		}
+		if px.alpha {
+			for pos := 0; pos < len(row); pos += nch {
+				a := row[pos+px.colors] / 255
+				for c := 0; c < px.colors; c++ {
+					row[pos+c] *= a
+				}
+			}
+		}
+		dst := tmp[y*w*nch : (y+1)*w*nch]
+		for x, taps := range xTaps {
+			for c := 0; c < nch; c++ {
+				var sum float32
+				for _, t := range taps {
+					sum += t.weight * row[t.pos*nch+c]
+				}
+				dst[x*nch+c] = sum
+			}
+		}
+	}
+	// Vertical pass over the columns of the result
+	out := &imagePixels{w: w, h: h, colors: px.colors, alpha: px.alpha}
+	out.pix = make([]byte, w*h*nch)
+	acc := make([]float32, w*nch)
+	for y, taps := range yTaps {
+		for j := range acc {
+			acc[j] = 0
+		}
+		for _, t := range taps {
+			src := tmp[t.pos*w*nch : (t.pos+1)*w*nch]
+			for j, v := range src {
+				acc[j] += t.weight * v
+			}
+		}
+		if px.alpha {
+			for pos := 0; pos < len(acc); pos += nch {
+				a := acc[pos+px.colors]
+				for c := 0; c < px.colors; c++ {
+					if a > 0 {
+						acc[pos+c] *= 255 / a
+					} else {
+						acc[pos+c] = 0
+					}
+				}
+			}
+		}
+		dst := out.pix[y*w*nch : (y+1)*w*nch]
+		for j, v := range acc {
+			dst[j] = uint8(math.Max(0, math.Min(255, math.Round(float64(v)))))
+		}
+	}
+	return out
+}
+
+// storedSize returns the size of the pixels of info as they are stored, which
+// is transposed for the EXIF orientations that turn the image a quarter
+func (info *ImageInfoType) storedSize() (w, h int) {
+	w, h = int(info.w), int(info.h)
+	if info.ornt >= 5 {
+		w, h = h, w
+	}
+	return
+}
+
+// pixels returns the samples of the image info, or nil if it is kept as it
+// is: encoded with filters other than DCTDecode and FlateDecode, or masked
+// with a color key of 16 bits. The color key of other images becomes an
+// alpha channel.
+func (info *ImageInfoType) pixels() (*imagePixels, error) {
+	if len(info.trns) > 0 && info.bpc > 8 {
+		return nil, nil
+	}
+	switch info.f {
+	case "DCTDecode":
+		if info.cs == "DeviceCMYK" && len(info.dcd) == 0 {
+			// Only the CMYK images of Adobe applications can be decoded
+			return nil, nil
+		}
+		img, err := jpeg.Decode(bytes.NewReader(info.data))
+		if err != nil {
+			return nil, err
+		}
+		return goImagePixels(img), nil
+	case "FlateDecode":
+	default:
+		return nil, nil
+	}
+	w, h := info.storedSize()
+	px := &imagePixels{w: w, h: h, colors: 1}
+	switch info.cs {
+	case "DeviceGray", "Indexed":
+	case "DeviceRGB":
+		px.colors = 3
+	case "DeviceCMYK":
+		px.colors = 4
+	default:
+		return nil, nil
+	}
+	samples, err := flateSamples(info.data, w, h, px.colors, info.bpc, info.dp != "")
+	if err != nil {
+		return nil, err
+	}
+	var alpha []byte
+	if len(info.trns) > 0 {
+		alpha = make([]byte, w*h)
+		for j := range alpha {
+			alpha[j] = 255
+			key := true
+			for c, v := range samples[j*px.colors : (j+1)*px.colors] {
+				key = key && c < len(info.trns) && int(v) == info.trns[c]
+			}
+			if key {
+				alpha[j] = 0
+			}
+		}
+	}
+	switch {
+	case info.cs == "Indexed":
+		px.colors = 3
+		nc := len(info.pal) / 3
+		rgb := make([]byte, 3*len(samples))
+		for j, v := range samples {
+			if int(v) < nc {
+				copy(rgb[3*j:], info.pal[3*int(v):3*int(v)+3])
+			}
+		}
+		samples = rgb
+	case info.bpc < 8:
+		// Scale the gray levels to 8 bits
+		maxVal := 1<<uint(info.bpc) - 1
+		for j, v := range samples {
+			samples[j] = uint8(int(v) * 255 / maxVal)
+		}
+	case len(info.dcd) > 0:
+		// Decode [1 0 ...] inverts the samples
+		for j, v := range samples {
+			samples[j] = ^v
+		}
+	}
+	if len(info.smask) > 0 {
+		if alpha, err = flateSamples(info.smask, w, h, 1, info.bpc, true); err != nil {
+			return nil, err
+		}
+	}
+	if alpha == nil {
+		px.pix = samples
+		return px, nil
+	}
+	px.alpha = true
+	px.pix = make([]byte, 0, w*h*(px.colors+1))
+	for j, a := range alpha {
+		px.pix = append(px.pix, samples[j*px.colors:(j+1)*px.colors]...)
+		px.pix = append(px.pix, a)
+	}
+	return px, nil
+}
+
+// flateSamples returns the samples of the FlateDecode data of an image of w
+// by h pixels of colors components of bpc bits, one byte each: the high
+// byte of 16-bit samples and the value of samples of less than a byte. The
+// rows are preceded by their PNG predictor if predicted is true.
+func flateSamples(data []byte, w, h, colors, bpc int, predicted bool) ([]byte, error) {
+	raw, err := sliceUncompress(data)
+	if err != nil {
+		return nil, err
+	}
+	rowLen := (w*colors*bpc + 7) / 8
+	stride := rowLen
+	if predicted {
+		stride++
+	}
+	if len(raw) < h*stride {
+		return nil, fmt.Errorf("image data is truncated")
+	}
+	raw = raw[:h*stride]
+	if predicted {
+		bpp := colors * bpc / 8
+		if bpp < 1 {
+			bpp = 1
+		}
+		if err = pngUnfilter(raw, rowLen, bpp); err != nil {
+			return nil, err
+		}
+	}
+	n := w * colors
+	samples := make([]byte, 0, n*h)
+	for y := 0; y < h; y++ {
+		row := raw[y*stride : (y+1)*stride]
+		if predicted {
+			row = row[1:]
+		}
+		switch bpc {
+		case 8:
+			samples = append(samples, row[:n]...)
+		case 16:
+			for j := 0; j < n; j++ {
+				samples = append(samples, row[2*j])
+			}
+		default:
+			mask := byte(1)<<uint(bpc) - 1
+			for j := 0; j < n; j++ {
+				bit := j * bpc
+				samples = append(samples, row[bit/8]>>uint(8-bpc-bit%8)&mask)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// goImagePixels returns the samples of a decoded JPEG image
+func goImagePixels(img image.Image) *imagePixels {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	px := &imagePixels{w: w, h: h}
+	switch img := img.(type) {
+	case *image.Gray:
+		px.colors = 1
+		px.pix = make([]byte, 0, w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			px.pix = append(px.pix, img.Pix[pos:pos+w]...)
+		}
+	case *image.CMYK:
+		px.colors = 4
+		px.pix = make([]byte, 0, 4*w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			px.pix = append(px.pix, img.Pix[pos:pos+4*w]...)
+		}
+	case *image.YCbCr:
+		px.colors = 3
+		px.pix = make([]byte, 0, 3*w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				ci := img.COffset(x, y)
+				r, g, bl := color.YCbCrToRGB(img.Y[img.YOffset(x, y)], img.Cb[ci], img.Cr[ci])
+				px.pix = append(px.pix, r, g, bl)
+			}
+		}
+	default:
+		px.colors = 3
+		px.pix = make([]byte, 0, 3*w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				px.pix = append(px.pix, c.R, c.G, c.B)
+			}
+		}
+	}
+	return px
+}
@@ -0,0 +1,315 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"strconv"
+)
+
+// FootnoteStyle defines the appearance of the notes created by Footnote().
+// Zero values are replaced by defaults computed from the font in use when the
+// first note is created.
+type FootnoteStyle struct {
+	FontFamily   string  // Font family of the notes, current family if empty
+	FontSize     float64 // Font size of the notes in points, 0.8 times the current size if zero
+	LineHeight   float64 // Height of a note line in user units, 1.2 times the font size if zero
+	MarkFontSize float64 // Font size of the markers in the text in points, 0.6 times the current size if zero
+	MarkOffset   float64 // Rise of the markers above the baseline in points, 0.4 times the current size if zero
+	RuleLength   float64 // Length of the rule above the notes in user units, a third of the working width if zero
+	Endnotes     bool    // Collect the notes for WriteEndnotes() instead of writing them at the foot of the pages
+}
+
+// footnotePartType is the part of a note that is written on a given page
+type footnotePartType struct {
+	num   int           // number of the note, zero for a part carried over from the previous page
+	link  int           // internal link set to the position of the note
+	lines []string      // wrapped text
+	style FootnoteStyle // style in effect when the note was created
+}
+
+// footnoteStateType holds the notes not yet written
+type footnoteStateType struct {
+	style    FootnoteStyle
+	resolved bool               // style defaults computed
+	count    int                // number of the last note
+	reserved map[int]float64    // height reserved at the foot of the pages, by page number
+	current  []footnotePartType // parts written at the foot of the current page
+	carry    []footnotePartType // parts that did not fit on the current page
+	ends     []footnotePartType // endnotes waiting for WriteEndnotes()
+}
+
+// SetFootnoteStyle sets the appearance of the notes created from now on by
+// Footnote() and FootnoteMark(). In particular, style.Endnotes selects the
+// endnote mode.
+func (f *Fpdf) SetFootnoteStyle(style FootnoteStyle) {
+	f.footnote.style = style
+	f.footnote.resolved = false
+}
+
+// footnoteStyle returns the current footnote style with its defaults
+func (f *Fpdf) footnoteStyle() FootnoteStyle {
+	fn := &f.footnote
+	if !fn.resolved {
+		s := &fn.style
+		if s.FontFamily == "" {
+			s.FontFamily = f.fontFamily
+		}
+		if s.FontSize == 0 {
+			s.FontSize = 0.8 * f.fontSizePt
+		}
+		if s.LineHeight == 0 {
+			s.LineHeight = 1.2 * s.FontSize / f.k
+		}
+		if s.MarkFontSize == 0 {
+			s.MarkFontSize = 0.6 * f.fontSizePt
+		}
+		if s.MarkOffset == 0 {
+			s.MarkOffset = 0.4 * f.fontSizePt
+		}
+		if s.RuleLength == 0 {
+			s.RuleLength = (f.w - f.lMargin - f.rMargin) / 3
+		}
+		fn.resolved = true
+	}
+	return fn.style
+}
+
+// Footnote writes a superscripted note number at the current position, in the
+// flowing mode used by Write(), and records txtStr as the text of the note. The
+// number is a link to the note.
+//
+// The note is written at the foot of the current page, above the bottom margin,
+// below a short rule. The room it takes is reserved right away by moving up the
+// page break trigger, so that the text that follows flows to the next page
+// sooner. A note too long for the remaining room is continued at the foot of
+// the next page. In endnote mode, set with SetFootnoteStyle(), the notes are
+// instead collected and written by WriteEndnotes().
+//
+// Notes are numbered from 1 throughout the document, or from the last call to
+// WriteEndnotes() in endnote mode.
+func (f *Fpdf) Footnote(txtStr string) {
+	if f.err != nil || f.page == 0 {
+		return
+	}
+	style := f.footnoteStyle()
+	ht := f.lasth
+	if ht == 0 {
+		ht = f.fontSize
+	}
+	link := f.AddLink()
+	num := f.addFootnote(txtStr, link, f.y+ht)
+	f.SubWrite(ht, strconv.Itoa(num), style.MarkFontSize, style.MarkOffset, link, "")
+}
+
+// FootnoteMark records txtStr as the text of a note like Footnote() does but,
+// instead of writing the note number, it returns it so that it can be
+// embedded in the text passed to MultiCell() or CellFormat(). Since the
+// position of the reference is not known, the note is attached to the current
+// page and is not the target of a link.
+func (f *Fpdf) FootnoteMark(txtStr string) (markStr string) {
+	if f.err != nil || f.page == 0 {
+		return
+	}
+	f.footnoteStyle()
+	return strconv.Itoa(f.addFootnote(txtStr, 0, f.y))
+}
+
+// addFootnote numbers and records a note. contentBottom is the lowest
+// position of the text that refers to it.
+func (f *Fpdf) addFootnote(txtStr string, link int, contentBottom float64) (num int) {
+	fn := &f.footnote
+	fn.count++
+	num = fn.count
+	part := footnotePartType{num: num, link: link, style: fn.style}
+	part.lines = f.footnoteLines(txtStr, part.style)
+	if fn.style.Endnotes {
+		fn.ends = append(fn.ends, part)
+	} else if len(fn.carry) > 0 {
+		// Keep the notes in order behind the ones already carried over
+		fn.carry = append(fn.carry, part)
+	} else {
+		f.reserveFootnote(part, contentBottom)
+	}
+	return
+}
+
+// footnoteLines returns the text of a note wrapped to the working width
+func (f *Fpdf) footnoteLines(txtStr string, style FootnoteStyle) (lines []string) {
+	ps := f.pageState()
+	if f.SetFont(style.FontFamily, "", style.FontSize) != nil {
+		return
+	}
+	lines = f.splitCellText(txtStr, f.w-f.lMargin-f.rMargin-f.footnoteIndent())
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	if ps.familyStr != "" {
+		f.SetError(f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt))
+	}
+	return
+}
+
+// footnoteIndent returns the room left for the note numbers with the note
+// font selected
+func (f *Fpdf) footnoteIndent() float64 {
+	return f.GetStringWidth("000")
+}
+
+// reserveFootnote reserves room at the foot of the current page for as many
+// lines of part as fit below contentBottom and carries the other ones over
+// to the next page.
+func (f *Fpdf) reserveFootnote(part footnotePartType, contentBottom float64) {
+	fn := &f.footnote
+	lh := part.style.LineHeight
+	room := f.pageBreakTrigger - contentBottom
+	sep := 0.0
+	if len(fn.current) == 0 {
+		sep = lh
+	}
+	n := int((room - sep) / lh)
+	if n > len(part.lines) {
+		n = len(part.lines)
+	}
+	if n <= 0 {
+		fn.carry = append(fn.carry, part)
+		return
+	}
+	fn.current = append(fn.current, footnotePartType{part.num, part.link, part.lines[:n], part.style})
+	if fn.reserved == nil {
+		fn.reserved = make(map[int]float64)
+	}
+	fn.reserved[f.page] += sep + float64(n)*lh
+	f.setPageBreakTrigger()
+	if n < len(part.lines) {
+		fn.carry = append(fn.carry, footnotePartType{lines: part.lines[n:], style: part.style})
+	}
+}
+
+// putfootnotes writes the notes of the current page at its foot. It is called
+// when the page is terminated.
+func (f *Fpdf) putfootnotes() {
+	fn := &f.footnote
+	if len(fn.current) > 0 && f.err == nil {
+		style := fn.current[0].style
+		ps := f.pageState()
+		x, y, lasth := f.x, f.y, f.lasth
+		f.inFooter = true
+		top := f.h - f.bMargin - fn.reserved[f.page]
+		f.Line(f.lMargin, top+style.LineHeight/2, f.lMargin+style.RuleLength, top+style.LineHeight/2)
+		f.y = top + style.LineHeight
+		var err error
+		for _, part := range fn.current {
+			style = part.style
+			if err == nil {
+				err = f.SetFont(style.FontFamily, "", style.FontSize)
+			}
+			indent := f.footnoteIndent()
+			for j, line := range part.lines {
+				if err != nil {
+					break
+				}
+				if j == 0 && part.num > 0 {
+					if part.link > 0 {
+						f.SetLink(part.link, f.y, f.page)
+					}
+					f.SetX(f.lMargin)
+					err = f.CellFormat(indent, style.LineHeight, strconv.Itoa(part.num), "", 0, "L", false, 0, "")
+				}
+				if err == nil {
+					f.SetX(f.lMargin + indent)
+					err = f.CellFormat(f.w-f.rMargin-f.x, style.LineHeight, line, "", 2, "L", false, 0, "")
+				}
+			}
+		}
+		if err == nil && ps.familyStr != "" {
+			err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+		}
+		f.SetError(err)
+		f.inFooter = false
+		f.x, f.y, f.lasth = x, y, lasth
+	}
+	fn.current = nil
+	delete(fn.reserved, f.page)
+	f.setPageBreakTrigger()
+}
+
+// startfootnotes reserves room on the page just begun for the notes carried
+// over from the previous page.
+func (f *Fpdf) startfootnotes() {
+	fn := &f.footnote
+	carry := fn.carry
+	fn.carry = nil
+	for _, part := range carry {
+		if len(fn.carry) > 0 {
+			fn.carry = append(fn.carry, part)
+		} else {
+			f.reserveFootnote(part, f.y)
+		}
+	}
+}
+
+// WriteEndnotes writes the notes collected in endnote mode at the current
+// position, in the order of their numbers, breaking pages as needed. The
+// numbering of the notes restarts from 1 afterwards, which makes it possible
+// to write the notes at the end of each section of a document.
+func (f *Fpdf) WriteEndnotes() (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	fn := &f.footnote
+	if len(fn.ends) == 0 {
+		return
+	}
+	ps := f.pageState()
+	for _, part := range fn.ends {
+		style := part.style
+		if err = f.SetFont(style.FontFamily, "", style.FontSize); err != nil {
+			return
+		}
+		indent := f.footnoteIndent()
+		for j, line := range part.lines {
+			if j == 0 {
+				if f.y+style.LineHeight > f.pageBreakTrigger && f.acceptPageBreak() {
+					if err = f.AddPageFormat(f.curOrientation, f.curPageSize); err != nil {
+						return
+					}
+					if err = f.SetFont(style.FontFamily, "", style.FontSize); err != nil {
+						return
+					}
+				}
+				if part.link > 0 {
+					f.SetLink(part.link, f.y, f.page)
+				}
+				f.SetX(f.lMargin)
+				if err = f.CellFormat(indent, style.LineHeight, strconv.Itoa(part.num), "", 0, "L", false, 0, ""); err != nil {
+					return
+				}
+			} else {
+				f.SetX(f.lMargin + indent)
+			}
+			if err = f.CellFormat(f.w-f.rMargin-f.x, style.LineHeight, line, "", 1, "L", false, 0, ""); err != nil {
+				return
+			}
+		}
+	}
+	fn.ends = nil
+	fn.count = 0
+	if ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	return
+}
@@ -85,6 +85,7 @@ func fpdfNew(orientationStr, unitStr, sizeStr, fontDirStr string, size SizeType)
 	f.state = 0
 	f.fonts = make(map[string]fontDefType)
 	f.fontFiles = make(map[string]fontFileType)
+	f.fontHashes = make(map[string]string)
 	f.diffs = make([]string, 0, 8)
 	f.templates = make(map[string]Template)
 	f.templateObjects = make(map[string]int)
@@ -100,6 +101,8 @@ func fpdfNew(orientationStr, unitStr, sizeStr, fontDirStr string, size SizeType)
 	f.pageAttachments = make([][]annotationAttach, 0, 8)
 	f.pageAttachments = append(f.pageAttachments, []annotationAttach{}) //
 	f.aliasMap = make(map[string]string)
+	f.anchorIDs = make(map[string]int)
+	f.pageRefs = make(map[string]int)
 	f.inHeader = false
 	f.inFooter = false
 	f.lasth = 0
@@ -215,6 +218,8 @@ func fpdfNew(orientationStr, unitStr, sizeStr, fontDirStr string, size SizeType)
 	f.spotColorMap = make(map[string]spotColorType)
 	f.blendList = make([]blendModeType, 0, 8)
 	f.blendList = append(f.blendList, blendModeType{}) // blendList[0] is unused (1-based)
+	f.softMasks = make([]softMaskType, 1)              // softMasks[0] is unused (1-based)
+	f.patterns = make([]patternType, 1)                // patterns[0] is unused (1-based)
 	f.blendMap = make(map[string]int)
 	f.blendMode = "Normal"
 	f.alpha = 1
@@ -436,10 +441,15 @@ func (f *Fpdf) SetPageBox(t string, x, y, wd, ht float64) (err error) {
 }
 
 // SetPage sets the current page to that of a valid page in the PDF document.
-// pageNum is one-based. The SetPage() example demonstrates this method.
+// pageNum is one-based. The content drawn afterwards goes to this page, with
+// its own size and orientation, until the next call to SetPage() or
+// AddPage(). The SetPage() example demonstrates this method.
 func (f *Fpdf) SetPage(pageNum int) (err error) {
 	if (pageNum > 0) && (pageNum < len(f.pages)) {
-		f.page = pageNum
+		f.selectPage(pageNum)
+		if f.state == 1 {
+			f.state = 2
+		}
 	} else {
 		err = fmt.Errorf("Page num <0 or exceed number of pages")
 	}
@@ -488,6 +498,7 @@ func (f *Fpdf) SetHeaderFuncMode(fnc func(), homeMode bool) {
 // each page such as a watermark. When this is done, remember to reset the X
 // and Y values so the normal content begins where expected. Including a
 // watermark on each page is demonstrated in the example for TransformRotate.
+// AddWatermark() stamps the pages without a header function.
 //
 // This method is demonstrated in the example for AddPage().
 func (f *Fpdf) SetHeaderFunc(fnc func()) {
@@ -550,7 +561,7 @@ func (f *Fpdf) GetAutoPageBreak() (auto bool, margin float64) {
 func (f *Fpdf) SetAutoPageBreak(auto bool, margin float64) {
 	f.autoPageBreak = auto
 	f.bMargin = margin
-	f.pageBreakTrigger = f.h - margin
+	f.setPageBreakTrigger()
 	f.updateWorkingSize()
 }
 
@@ -626,6 +637,7 @@ func (f *Fpdf) SetProducer(producerStr string, isUTF8 bool) {
 // SetTitle defines the title of the document. isUTF8 indicates if the string
 // is encoded in ISO-8859-1 (false) or UTF-8 (true).
 func (f *Fpdf) SetTitle(titleStr string, isUTF8 bool) {
+	f.titleText = titleStr
 	if isUTF8 {
 		titleStr = utf8toutf16(titleStr)
 	}
@@ -686,6 +698,17 @@ func (f *Fpdf) AliasNbPages(aliasStr string) {
 	f.aliasNbPagesStr = aliasStr
 }
 
+// AliasPageNo defines an alias for the number of the page it is written on.
+// Unlike the value returned by PageNo(), it is substituted as the document is
+// closed, so the number remains right if pages are inserted afterwards, for
+// instance by InsertTOC(). An empty string is replaced with the string "{pn}".
+func (f *Fpdf) AliasPageNo(aliasStr string) {
+	if aliasStr == "" {
+		aliasStr = "{pn}"
+	}
+	f.aliasPageNoStr = aliasStr
+}
+
 // RTL enables right-to-left mode
 func (f *Fpdf) RTL() {
 	f.isRTL = true
@@ -718,27 +741,53 @@ func (f *Fpdf) Close() (err error) {
 	if f.state == 3 {
 		return
 	}
+	if err = f.closepages(); err != nil {
+		return
+	}
+	// Close document
+	f.enddoc()
+
+	return
+}
+
+// closepages finishes the page being written, after the pages needed by the
+// notes that did not fit on it. A page is added to an empty document.
+func (f *Fpdf) closepages() (err error) {
 	if f.page == 0 {
 		err = f.AddPage()
 		if err != nil {
 			return
 		}
 	}
-	// Page footer
-	f.inFooter = true
-	if f.footerFnc != nil {
-		f.footerFnc()
-	} else if f.footerFncLpi != nil {
-		f.footerFncLpi(true)
+	if f.openPage == 0 {
+		// Pages appended by AppendDocument() are already finished
+		f.state = 1
+		return
 	}
-	f.inFooter = false
+	// Notes that did not fit on the last page
+	for len(f.footnote.carry) > 0 {
+		if err = f.AddPage(); err != nil {
+			return
+		}
+		if len(f.footnote.current) == 0 {
+			break
+		}
+	}
+	f.finishpage(true)
+	return
+}
 
+// finishpage writes the notes and the footer of the page being written and
+// closes it.
+func (f *Fpdf) finishpage(lastPage bool) {
+	if f.page != f.openPage {
+		f.selectPage(f.openPage)
+	}
+	f.putfootnotes()
+	// Page footer
+	f.putfooter(lastPage)
 	// Close page
 	f.endpage()
-	// Close document
-	f.enddoc()
-
-	return
 }
 
 // PageSize returns the width and height of the specified page in the units
@@ -765,42 +814,80 @@ func (f *Fpdf) PageSize(pageNum int) (wd, ht float64, unitStr string) {
 //
 // The PageSize() example demonstrates this method.
 func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) (err error) {
-	if f.page != len(f.pages)-1 {
-		f.page = len(f.pages) - 1
-	}
 	if f.state == 0 {
 		f.open()
 	}
-	familyStr := f.fontFamily
-	style := f.fontStyle
-	if f.underline {
-		style += "U"
+	ps := f.pageState()
+
+	if f.openPage > 0 {
+		f.finishpage(false) // not last page.
 	}
-	if f.strikeout {
-		style += "S"
+	f.page = len(f.pages) - 1
+	if f.nextSection != nil {
+		f.startsection()
 	}
-	fontsize := f.fontSizePt
-	lw := f.lineWidth
-	dc := f.color.draw
-	fc := f.color.fill
-	tc := f.color.text
-	cf := f.colorFlag
+	// Start new page
+	f.beginpage(orientationStr, size)
 
-	if f.page > 0 {
-		f.inFooter = true
-		// Page footer avoid double call on footer.
-		if f.footerFnc != nil {
-			f.footerFnc()
+	err = f.startpage(ps)
+	if err == nil {
+		f.startfootnotes()
+	}
+	return
+}
 
-		} else if f.footerFncLpi != nil {
-			f.footerFncLpi(false) // not last page.
-		}
-		f.inFooter = false
-		// Close page
-		f.endpage()
+// pageStateType holds the graphic state that is carried over from one page to
+// the next one.
+type pageStateType struct {
+	familyStr  string
+	styleStr   string
+	fontSizePt float64
+	lineWidth  float64
+	draw       colorType
+	fill       colorType
+	text       colorType
+	colorFlag  bool
+}
+
+// pageState returns the graphic state to restore on a new page
+func (f *Fpdf) pageState() (ps pageStateType) {
+	ps.familyStr = f.fontFamily
+	ps.styleStr = f.fontStyle
+	if f.underline {
+		ps.styleStr += "U"
 	}
-	// Start new page
-	f.beginpage(orientationStr, size)
+	if f.strikeout {
+		ps.styleStr += "S"
+	}
+	ps.fontSizePt = f.fontSizePt
+	ps.lineWidth = f.lineWidth
+	ps.draw = f.color.draw
+	ps.fill = f.color.fill
+	ps.text = f.color.text
+	ps.colorFlag = f.colorFlag
+	return
+}
+
+// putfooter draws the running header and footer, calls the application
+// footer function, if any, and draws the watermarks on the current page.
+func (f *Fpdf) putfooter(lastPage bool) {
+	f.inFooter = true
+	f.putRunningBands()
+	if f.footerFnc != nil {
+		f.footerFnc()
+	} else if f.footerFncLpi != nil {
+		f.footerFncLpi(lastPage)
+	}
+	f.inFooter = false
+	f.stampPage(f.page, f.watermarks...)
+}
+
+// startpage writes the graphic state ps at the beginning of the current page,
+// calls the application header function and restores ps afterwards.
+func (f *Fpdf) startpage(ps pageStateType) (err error) {
+	lw := ps.lineWidth
+	dc := ps.draw
+	fc := ps.fill
 
 	// 	Set line cap style to current value
 	// f.out("2 J")
@@ -818,8 +905,8 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) (err error) {
 		f.outputDashPattern()
 	}
 	// 	Set font
-	if familyStr != "" {
-		err = f.SetFont(familyStr, style, fontsize)
+	if ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
 		if err != nil {
 			return
 		}
@@ -834,8 +921,8 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) (err error) {
 	if fc.str != "0 g" {
 		f.out(fc.str)
 	}
-	f.color.text = tc
-	f.colorFlag = cf
+	f.color.text = ps.text
+	f.colorFlag = ps.colorFlag
 
 	// 	Page header
 	if f.headerFnc != nil {
@@ -854,8 +941,8 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) (err error) {
 	}
 
 	// Restore font
-	if familyStr != "" {
-		err = f.SetFont(familyStr, style, fontsize)
+	if ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
 		if err != nil {
 			return
 		}
@@ -869,8 +956,8 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) (err error) {
 		f.color.fill = fc
 		f.out(fc.str)
 	}
-	f.color.text = tc
-	f.colorFlag = cf
+	f.color.text = ps.text
+	f.colorFlag = ps.colorFlag
 
 	return
 }
@@ -1002,6 +1089,9 @@ func (f *Fpdf) GetStringSymbolWidth(s string) int {
 		unicode := []rune(s)
 		for _, char := range unicode {
 			intChar := int(char)
+			if isPageRefMark(char) {
+				continue
+			}
 			if len(f.currentFont.Cw) >= intChar && f.currentFont.Cw[intChar] > 0 {
 				if f.currentFont.Cw[intChar] != 65535 {
 					w += f.currentFont.Cw[intChar]
@@ -1017,6 +1107,9 @@ func (f *Fpdf) GetStringSymbolWidth(s string) int {
 			if ch == 0 {
 				break
 			}
+			if isPageRefMark(rune(ch)) {
+				continue
+			}
 			if int(ch) < len(f.currentFont.Cw) {
 				w += f.currentFont.Cw[ch]
 			} else {
@@ -1435,8 +1528,8 @@ func (f *Fpdf) gradient(tp, r1, g1, b1, r2, g2, b2 int, x1, y1, x2, y2, r float6
 	pos := len(f.gradientList)
 	clr1 := rgbColorValue(r1, g1, b1, "", "")
 	clr2 := rgbColorValue(r2, g2, b2, "", "")
-	f.gradientList = append(f.gradientList, gradientType{tp, clr1.str, clr2.str,
-		x1, y1, x2, y2, r, 0})
+	f.gradientList = append(f.gradientList, gradientType{tp: tp, clr1Str: clr1.str, clr2Str: clr2.str,
+		x1: x1, y1: y1, x2: x2, y2: y2, r: r, extendStr: "true true"})
 	f.outf("/Sh%d sh", pos)
 }
 
@@ -1649,13 +1742,13 @@ func (f *Fpdf) ClipPolygon(points []PointType, outline bool) {
 }
 
 // ClipEnd ends a clipping operation that was started with a call to
-// ClipRect(), ClipRoundedRect(), ClipText(), ClipEllipse(), ClipCircle() or
-// ClipPolygon(). Clipping operations can be nested. The document cannot be
+// ClipRect(), ClipRoundedRect(), ClipText(), ClipEllipse(), ClipCircle(),
+// ClipPolygon() or ClipImage(). Clipping operations can be nested. The document cannot be
 // successfully output while a clipping operation is active.
 //
 // The ClipText() example demonstrates this method.
 func (f *Fpdf) ClipEnd() (err error) {
-	if f.err == nil {
+	if f.err != nil {
 		return f.err
 	}
 	if f.clipNest > 0 {
@@ -1747,6 +1840,9 @@ func (f *Fpdf) addFont(familyStr, styleStr, fileStr string, isUTF8 bool) (err er
 			f.SetError(err)
 			return
 		}
+		if f.utf8FontAlias(fontKey, utf8Bytes) {
+			return
+		}
 		reader := fileReader{readerPosition: 0, array: utf8Bytes}
 		utf8File := newUTF8Font(&reader)
 		err = utf8File.parseFile()
@@ -1886,6 +1982,10 @@ func (f *Fpdf) addFontFromBytes(familyStr, styleStr string, jsonFileBytes, zFile
 		// 	styleStr = "BI"
 		// }
 
+		if f.utf8FontAlias(fontkey, utf8Bytes) {
+			return
+		}
+
 		Type := "UTF8"
 		reader := fileReader{readerPosition: 0, array: utf8Bytes}
 
@@ -2255,6 +2355,7 @@ func (f *Fpdf) Bookmark(txtStr string, level int, y float64) {
 	if y == -1 {
 		y = f.y
 	}
+	f.SetRunningMark(txtStr, level)
 	if f.isCurrentUTF8 {
 		txtStr = utf8toutf16(txtStr)
 	}
@@ -2536,6 +2637,9 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 		if link > 0 || len(linkStr) > 0 {
 			f.newLink(f.x+dx, f.y+dy+.5*h-.5*f.fontSize, f.GetStringWidth(txtStr), f.fontSize, link, linkStr)
 		}
+		if len(f.pageRefs) > 0 && strings.ContainsRune(txtStr, pageRefStart) {
+			f.pageRefLinks(txtStr, f.x+dx, f.y+dy+.5*h-.5*f.fontSize, f.fontSize)
+		}
 	}
 	str := s.String()
 	if len(str) > 0 {
@@ -2606,7 +2710,9 @@ func (f *Fpdf) SplitLines(txt []byte, w float64) [][]byte {
 	l := 0
 	for i < nb {
 		c := s[i]
-		l += cw[c]
+		if !isPageRefMark(rune(c)) {
+			l += cw[c]
+		}
 		if c == ' ' || c == '\t' || c == '\n' {
 			sep = i
 		}
@@ -2774,7 +2880,9 @@ func (f *Fpdf) MultiCell(w, h float64, txtStr, borderStr, alignStr string, fill
 			f.err = fmt.Errorf("character outside the supported range: %s", string(c))
 			return f.err
 		}
-		if cw[int(c)] == 0 { //Marker width 0 used for missing symbols
+		if isPageRefMark(c) {
+			// Placeholders of page references have no width
+		} else if cw[int(c)] == 0 { //Marker width 0 used for missing symbols
 			l += f.currentFont.Desc.MissingWidth
 		} else if cw[int(c)] != 65535 { //Marker width 65535 used for zero width symbols
 			l += cw[int(c)]
@@ -2905,7 +3013,9 @@ func (f *Fpdf) write(h float64, txtStr string, link int, linkStr string) {
 		if c == ' ' {
 			sep = i
 		}
-		l += float64(cw[int(c)])
+		if !isPageRefMark(c) {
+			l += float64(cw[int(c)])
+		}
 		if l > wmax {
 			// Automatic line break
 			if sep == -1 {
@@ -3068,6 +3178,14 @@ func (f *Fpdf) ImageTypeFromMime(mimeStr string) (tp string) {
 		tp = "jpg"
 	case "image/gif":
 		tp = "gif"
+	case "image/tiff":
+		tp = "tiff"
+	case "image/webp":
+		tp = "webp"
+	case "image/jp2":
+		tp = "jp2"
+	case "image/jpx":
+		tp = "jpx"
 	default:
 		f.SetErrorf("unsupported image type: %s", mimeStr)
 	}
@@ -3075,6 +3193,40 @@ func (f *Fpdf) ImageTypeFromMime(mimeStr string) (tp string) {
 }
 
 func (f *Fpdf) imageOut(info *ImageInfoType, x, y, w, h float64, allowNegativeX, flow bool, link int, linkStr string) (err error) {
+	w, h = f.imageExtent(info, w, h)
+	// Flowing mode
+	if flow {
+		if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.acceptPageBreak() {
+			// Automatic page break
+			x2 := f.x
+			f.AddPageFormat(f.curOrientation, f.curPageSize)
+			if f.err != nil {
+				return f.err
+			}
+			f.x = x2
+		}
+		y = f.y
+		f.y += h
+	}
+	if !allowNegativeX {
+		if x < 0 {
+			x = f.x
+		}
+	}
+	// dbg("h %.2f", h)
+	// q 85.04 0 0 NaN 28.35 NaN cm /I2 Do Q
+	f.out(f.imageDo(info, x, y, w, h))
+	if link > 0 || len(linkStr) > 0 {
+		f.newLink(x, y, w, h, link, linkStr)
+	}
+
+	return
+}
+
+// imageExtent returns the size in user units of the image info placed with
+// the width w and the height h of Image() and notes it as a placement of the
+// image
+func (f *Fpdf) imageExtent(info *ImageInfoType, w, h float64) (float64, float64) {
 	// Automatic width and height calculation if needed
 	if w == 0 && h == 0 {
 		// Put image at 96 dpi
@@ -3103,33 +3255,27 @@ func (f *Fpdf) imageOut(info *ImageInfoType, x, y, w, h float64, allowNegativeX,
 	if h == 0 {
 		h = w * info.h / info.w
 	}
-	// Flowing mode
-	if flow {
-		if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.acceptPageBreak() {
-			// Automatic page break
-			x2 := f.x
-			f.AddPageFormat(f.curOrientation, f.curPageSize)
-			if f.err != nil {
-				return f.err
-			}
-			f.x = x2
-		}
-		y = f.y
-		f.y += h
-	}
-	if !allowNegativeX {
-		if x < 0 {
-			x = f.x
+	// The largest placement sets the resolution kept by downsampling
+	if w != 0 && h != 0 {
+		dpi := math.Min(info.w*72/math.Abs(w*f.k), info.h*72/math.Abs(h*f.k))
+		if info.placedDpi == 0 || dpi < info.placedDpi {
+			info.placedDpi = dpi
 		}
 	}
-	// dbg("h %.2f", h)
-	// q 85.04 0 0 NaN 28.35 NaN cm /I2 Do Q
-	f.outf("q %.5f 0 0 %.5f %.5f %.5f cm /I%s Do Q", w*f.k, h*f.k, x*f.k, (f.h-(y+h))*f.k, info.i)
-	if link > 0 || len(linkStr) > 0 {
-		f.newLink(x, y, w, h, link, linkStr)
-	}
+	return w, h
+}
 
-	return
+// imageDo returns the operators that draw the image info with its upper left
+// corner at (x, y) and the size w by h
+func (f *Fpdf) imageDo(info *ImageInfoType, x, y, w, h float64) string {
+	if info.ornt > 1 {
+		// Show upright the pixels stored rotated or mirrored
+		m := imageOrientations[info.ornt]
+		wk, hk := w*f.k, h*f.k
+		return sprintf("q %.5f %.5f %.5f %.5f %.5f %.5f cm /I%s Do Q", m[0]*wk, m[1]*hk, m[2]*wk, m[3]*hk,
+			m[4]*wk+x*f.k, m[5]*hk+(f.h-(y+h))*f.k, info.i)
+	}
+	return sprintf("q %.5f 0 0 %.5f %.5f %.5f cm /I%s Do Q", w*f.k, h*f.k, x*f.k, (f.h-(y+h))*f.k, info.i)
 }
 
 // Image puts a JPEG, PNG or GIF image in the current page.
@@ -3157,10 +3303,20 @@ func (f *Fpdf) Image(imageNameStr string, x, y, w, h float64, flow bool, tp stri
 // If w and h are any other negative value, their absolute values
 // indicate their dpi extents.
 //
-// Supported JPEG formats are 24 bit, 32 bit and gray scale. Supported PNG
-// formats are 24 bit, indexed color, and 8 bit indexed gray scale. If a GIF
-// image is animated, only the first frame is rendered. Transparency is
-// supported. It is possible to put a link on the image.
+// Supported JPEG formats are 24 bit, 32 bit and gray scale. JPEG images are
+// shown upright according to their EXIF orientation, and the CMYK ones are
+// inverted only if they have the Adobe marker of inverted samples. Supported
+// PNG formats are 24 bit, indexed color, and 8 bit indexed gray scale. The
+// ICC profiles of JPEG and PNG images are kept as ICCBased color spaces. If a GIF
+// image is animated, only the first frame is rendered. TIFF images may be
+// gray, RGB, CMYK or indexed, stored in strips or tiles, uncompressed or
+// compressed with LZW, Deflate or PackBits. Bilevel CCITT Group 3 and Group 4
+// TIFF images are embedded as they are, except the two-dimensional ones stored
+// in several strips or tiles, which are decoded. WebP images may be lossy or lossless,
+// with an alpha channel; animated ones are not supported. JPEG 2000 images,
+// either JP2 files or bare codestreams, are embedded as they are and need a
+// PDF 1.5 reader. Transparency is supported. It is possible to put a link on
+// the image.
 //
 // imageNameStr may be the name of an image as registered with a call to either
 // RegisterImageReader() or RegisterImage(). In the first case, the image is
@@ -3209,8 +3365,9 @@ func (f *Fpdf) RegisterImageReader(imgName, tp string, r io.Reader) (info *Image
 // parsing an image.
 //
 // ImageType's possible values are (case insensitive):
-// "JPG", "JPEG", "PNG" and "GIF". If empty, the type is inferred from
-// the file extension.
+// "JPG", "JPEG", "PNG", "GIF", "TIF", "TIFF", "WEBP", and "JP2", "J2K", "J2C",
+// "JPX" or "JPF" for JPEG 2000. If empty, the type is inferred from the file
+// extension.
 //
 // ReadDpi defines whether to attempt to automatically read the image
 // dpi information from the image file. Normally, this should be set
@@ -3220,10 +3377,38 @@ func (f *Fpdf) RegisterImageReader(imgName, tp string, r io.Reader) (info *Image
 //
 // AllowNegativePosition can be set to true in order to prevent the default
 // coercion of negative x values to the current x position.
+//
+// Page selects the one-based page of a multi-page TIFF image, the first one
+// if zero; see ImageTIFFPages(). Pages other than the first one are
+// registered under the name of the image followed by "#" and the page
+// number, such as "scan.tiff#2".
+//
+// MaskImage is the name of an image, registered or in a file, whose gray
+// levels set the opacity of the image, from transparent at black to opaque
+// at white, in place of its own alpha channel. The mask is stretched over
+// the image. Color masks are converted to gray.
+//
+// Downsample sets how the image is downsampled when the document is output,
+// instead of the options of SetImageDownsampling(). It is only used when the
+// image is registered.
 type ImageOptions struct {
 	ImageType             string
 	ReadDpi               bool
 	AllowNegativePosition bool
+	Page                  int
+	Downsample            *DownsampleOptions
+	MaskImage             string
+}
+
+// imageKey returns the name the image imgName is registered under
+func (options ImageOptions) imageKey(imgName string) string {
+	if options.Page > 1 {
+		imgName = sprintf("%s#%d", imgName, options.Page)
+	}
+	if options.MaskImage != "" {
+		imgName = sprintf("%s#mask:%s", imgName, options.MaskImage)
+	}
+	return imgName
 }
 
 // RegisterImageOptionsReader registers an image, reading it from Reader r, adding it
@@ -3238,10 +3423,15 @@ func (f *Fpdf) RegisterImageOptionsReader(imgName string, options ImageOptions,
 	if err != nil {
 		return
 	}
-	info, ok := f.images[imgName]
+	info, ok := f.images[options.imageKey(imgName)]
 	if ok {
 		return
 	}
+	if options.MaskImage != "" {
+		return f.registerMaskedImage(imgName, options, func(options ImageOptions) (*ImageInfoType, error) {
+			return f.RegisterImageOptionsReader(imgName, options, r)
+		})
+	}
 
 	// First use of this image, get info
 	if options.ImageType == "" {
@@ -3260,17 +3450,24 @@ func (f *Fpdf) RegisterImageOptionsReader(imgName string, options ImageOptions,
 		info = f.parsepng(r, options.ReadDpi)
 	case "gif":
 		info = f.parsegif(r)
+	case "tif", "tiff":
+		info = f.parsetiff(r, options.Page, options.ReadDpi)
+	case "webp":
+		info = f.parsewebp(r)
+	case "jp2", "j2k", "j2c", "jpx", "jpf":
+		info = f.parsejpx(r)
 	default:
 		err = fmt.Errorf("unsupported image type: %s", options.ImageType)
 		f.err = err
 		return
 	}
+	info.downsample = options.Downsample
 
 	if info.i, err = generateImageID(info); err != nil {
 		f.err = err
 		return
 	}
-	f.images[imgName] = info
+	f.images[options.imageKey(imgName)] = info
 
 	return
 }
@@ -3296,10 +3493,15 @@ func (f *Fpdf) RegisterImage(fileStr, tp string) (info *ImageInfoType, err error
 // necessary if you need information about the image before placing it. See
 // Image() for restrictions on the image and the "tp" parameters.
 func (f *Fpdf) RegisterImageOptions(fileStr string, options ImageOptions) (info *ImageInfoType, err error) {
-	info, ok := f.images[fileStr]
+	info, ok := f.images[options.imageKey(fileStr)]
 	if ok {
 		return
 	}
+	if options.MaskImage != "" {
+		return f.registerMaskedImage(fileStr, options, func(options ImageOptions) (*ImageInfoType, error) {
+			return f.RegisterImageOptions(fileStr, options)
+		})
+	}
 
 	file, err := os.Open(fileStr)
 	if err != nil {
@@ -3345,57 +3547,29 @@ func (f *Fpdf) ImportObjPos(objPos map[string]map[int]string) {
 	}
 }
 
-// putImportedTemplates writes the imported template objects to the PDF
+// putImportedTemplates writes the imported template objects to the PDF.
+// Objects with the same content, whatever the importer they come from, are
+// written once.
 func (f *Fpdf) putImportedTemplates() {
 	nOffset := f.n + 1
+	class, reps := f.importedObjClasses()
 
-	// keep track of list of sha1 hashes (to be replaced with integers)
-	objsIDHash := make([]string, len(f.importedObjs))
-
-	// actual object data with new id
-	objsIDData := make([][]byte, len(f.importedObjs))
-
-	// Populate hash slice and data slice
-	i := 0
-	for k, v := range f.importedObjs {
-		objsIDHash[i] = k
-		objsIDData[i] = v
-
-		i++
+	// Save the object id of each hash so that procset dictionary has the
+	// correct object ids
+	for hash, c := range class {
+		f.importedTplIDs[hash] = c + nOffset
 	}
 
-	// Populate a lookup table to get an object id from a hash
-	hashToObjID := make(map[string]int, len(f.importedObjs))
-	for i = 0; i < len(objsIDHash); i++ {
-		hashToObjID[objsIDHash[i]] = i + nOffset
-	}
-
-	// Now, replace hashes inside data with %040d object id
-	for i = 0; i < len(objsIDData); i++ {
-		// get hash
-		hash := objsIDHash[i]
+	for _, hash := range reps {
+		data := append([]byte(nil), f.importedObjs[hash]...)
 
+		// Replace sha1 hashes inside data with object ids padded with spaces
 		for pos, h := range f.importedObjPos[hash] {
-			// Convert object id into a 40 character string padded with spaces
-			objIDPadded := fmt.Sprintf("%40s", fmt.Sprintf("%d", hashToObjID[h]))
-
-			// Convert objIDPadded into []byte
-			objIDBytes := []byte(objIDPadded)
-
-			// Replace sha1 hash with object id padded
-			for j := pos; j < pos+40; j++ {
-				objsIDData[i][j] = objIDBytes[j-pos]
-			}
+			copy(data[pos:pos+40], fmt.Sprintf("%40s", fmt.Sprintf("%d", f.importedTplIDs[h])))
 		}
 
-		// Save objsIDHash so that procset dictionary has the correct object ids
-		f.importedTplIDs[hash] = i + nOffset
-	}
-
-	// Now, put objects
-	for i = 0; i < len(objsIDData); i++ {
 		f.newobj()
-		f.out(string(objsIDData[i]))
+		f.out(string(data))
 	}
 }
 
@@ -3597,14 +3771,21 @@ func (f *Fpdf) _getpagesize(size SizeType) SizeType {
 func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 
 	f.page++
+	f.openPage = f.page
+	f.pages = append(f.pages, bytes.NewBufferString(""))
+	f.pageLinks = append(f.pageLinks, make([]linkType, 0, 0))
+	f.pageAttachments = append(f.pageAttachments, []annotationAttach{})
+	f.initpage(orientationStr, size)
+}
+
+// initpage prepares the current page, which must be empty, for content with
+// the specified orientation and size.
+func (f *Fpdf) initpage(orientationStr string, size SizeType) {
 	// add the default page boxes, if any exist, to the page
 	f.pageBoxes[f.page] = make(map[string]PageBox)
 	for box, pb := range f.defPageBoxes {
 		f.pageBoxes[f.page][box] = pb
 	}
-	f.pages = append(f.pages, bytes.NewBufferString(""))
-	f.pageLinks = append(f.pageLinks, make([]linkType, 0, 0))
-	f.pageAttachments = append(f.pageAttachments, []annotationAttach{})
 	f.state = 2
 	f.x = f.lMargin
 	f.y = f.tMargin
@@ -3626,7 +3807,7 @@ func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 		}
 		f.wPt = f.w * f.k
 		f.hPt = f.h * f.k
-		f.pageBreakTrigger = f.h - f.bMargin
+		f.setPageBreakTrigger()
 		f.curOrientation = orientationStr
 		f.curPageSize = size
 		f.updateWorkingSize()
@@ -3634,12 +3815,12 @@ func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 	if orientationStr != f.defOrientation || size.Wd != f.defPageSize.Wd || size.Ht != f.defPageSize.Ht {
 		f.pageSizes[f.page] = SizeType{f.wPt, f.hPt}
 	}
-	return
 }
 
 func (f *Fpdf) endpage() {
 	f.EndLayer()
 	f.state = 1
+	f.openPage = 0
 }
 
 // Load a font definition file from the given Reader
@@ -3767,6 +3948,19 @@ func (f *Fpdf) parsejpg(r io.Reader) (info *ImageInfoType) {
 		f.err = fmt.Errorf("image JPEG buffer has unsupported color space (%v)", config.ColorModel)
 		return
 	}
+	orientation, icc, adobe := jpegMeta(info.data)
+	if info.cs == "DeviceCMYK" && adobe {
+		// Adobe applications write inverted CMYK samples
+		info.dcd = "1 0 1 0 1 0 1 0"
+	}
+	info.setICC(icc)
+	if orientation > 1 {
+		info.ornt = orientation
+		if orientation >= 5 {
+			// The rows of the pixels are the columns of the image
+			info.w, info.h = info.h, info.w
+		}
+	}
 	return
 }
 
@@ -3942,6 +4136,17 @@ func (f *Fpdf) RegisterAlias(alias, replacement string) {
 	f.aliasMap[alias] = replacement
 }
 
+// replacePagePlaceholders replaces the page references and the aliases in
+// the content of the pages, once they are all in place
+func (f *Fpdf) replacePagePlaceholders() {
+	if len(f.aliasNbPagesStr) > 0 {
+		// Replace number of pages
+		f.RegisterAlias(f.aliasNbPagesStr, sprintf("%d", f.PageCount()))
+	}
+	f.replacePageRefs()
+	f.replaceAliases()
+}
+
 func (f *Fpdf) replaceAliases() {
 	for mode := 0; mode < 2; mode++ {
 		for alias, replacement := range f.aliasMap {
@@ -3949,28 +4154,55 @@ func (f *Fpdf) replaceAliases() {
 				alias = utf8toutf16(alias, false)
 				replacement = utf8toutf16(replacement, false)
 			}
-			for n := 1; n <= f.page; n++ {
-				s := f.pages[n].String()
-				if strings.Contains(s, alias) {
-					s = strings.Replace(s, alias, replacement, -1)
-					f.pages[n].Truncate(0)
-					f.pages[n].WriteString(s)
+			for n := 1; n < len(f.pages); n++ {
+				f.replacePageAlias(n, alias, replacement)
+			}
+		}
+		if len(f.aliasPageNoStr) > 0 {
+			for n := 1; n < len(f.pages); n++ {
+				alias, replacement := f.aliasPageNoStr, f.pageLabel(n)
+				if mode == 1 {
+					alias = utf8toutf16(alias, false)
+					replacement = utf8toutf16(replacement, false)
+				}
+				f.replacePageAlias(n, alias, replacement)
+			}
+		}
+		if len(f.aliasSectNbStr) > 0 {
+			for n := 1; n < len(f.pages); n++ {
+				sec, end := f.sectionOf(n)
+				first := 1
+				if sec != nil {
+					first = sec.firstPage
+				}
+				alias, replacement := f.aliasSectNbStr, strconv.Itoa(end-first)
+				if mode == 1 {
+					alias = utf8toutf16(alias, false)
+					replacement = utf8toutf16(replacement, false)
 				}
+				f.replacePageAlias(n, alias, replacement)
 			}
 		}
 	}
 }
 
+// replacePageAlias replaces all occurrences of alias in the content of the
+// one-based page n
+func (f *Fpdf) replacePageAlias(n int, alias, replacement string) {
+	s := f.pages[n].String()
+	if strings.Contains(s, alias) {
+		s = strings.Replace(s, alias, replacement, -1)
+		f.pages[n].Truncate(0)
+		f.pages[n].WriteString(s)
+	}
+}
+
 func (f *Fpdf) putpages() {
 	var wPt, hPt float64
 	var pageSize SizeType
 	var ok bool
-	nb := f.page
-	if len(f.aliasNbPagesStr) > 0 {
-		// Replace number of pages
-		f.RegisterAlias(f.aliasNbPagesStr, sprintf("%d", nb))
-	}
-	f.replaceAliases()
+	nb := f.PageCount()
+	f.replacePagePlaceholders()
 	if f.defOrientation == "P" {
 		wPt = f.defPageSize.Wd * f.k
 		hPt = f.defPageSize.Ht * f.k
@@ -4075,13 +4307,11 @@ func (f *Fpdf) putfonts() {
 		if f.catalogSort {
 			sort.SliceStable(fileList, func(i, j int) bool { return fileList[i] < fileList[j] })
 		}
+		// Files with the same content are written once
+		fileObjs := make(map[string]int)
 		for _, file = range fileList {
 			info = f.fontFiles[file]
 			if info.fontType != "UTF8" {
-				f.newobj()
-				info.n = f.n
-				f.fontFiles[file] = info
-
 				var font []byte
 
 				if info.embedded {
@@ -4100,6 +4330,16 @@ func (f *Fpdf) putfonts() {
 					buf = append(buf, font[6+info.length1+6:info.length2]...)
 					font = buf
 				}
+				id := fontFileID(font, info, compressed)
+				if n, ok := fileObjs[id]; ok {
+					info.n = n
+					f.fontFiles[file] = info
+					continue
+				}
+				f.newobj()
+				info.n = f.n
+				f.fontFiles[file] = info
+				fileObjs[id] = f.n
 				f.outf("<</Length %d", len(font))
 				if compressed {
 					f.out("/Filter /FlateDecode")
@@ -4124,11 +4364,19 @@ func (f *Fpdf) putfonts() {
 		if f.catalogSort {
 			sort.SliceStable(keyList, func(i, j int) bool { return keyList[i] < keyList[j] })
 		}
+		// Fonts registered under several names are written once
+		fontObjs := make(map[string]int)
 		for _, key = range keyList {
 			font = f.fonts[key]
+			if n, ok := fontObjs[font.i]; ok {
+				font.N = n
+				f.fonts[key] = font
+				continue
+			}
 			// Font objects
 			font.N = f.n + 1
 			f.fonts[key] = font
+			fontObjs[font.i] = font.N
 			tp := font.Tp
 			name := font.Name
 			switch tp {
@@ -4443,6 +4691,17 @@ func (f *Fpdf) putimages() {
 	// corresponding object ID number.
 	insertedImages := map[string]int{}
 
+	f.downsampleImages(keyList)
+	// The soft mask images come first for the images they mask to refer to
+	masks := make(map[string]bool)
+	for _, key = range keyList {
+		if mask := f.images[key].mask; mask != "" {
+			masks[mask] = true
+		}
+	}
+	sort.SliceStable(keyList, func(i, j int) bool {
+		return masks[f.images[keyList[i]].i] && !masks[f.images[keyList[j]].i]
+	})
 	for _, key = range keyList {
 		image := f.images[key]
 
@@ -4466,15 +4725,34 @@ func (f *Fpdf) putimage(info *ImageInfoType) {
 	info.n = f.n
 	f.out("<</Type /XObject")
 	f.out("/Subtype /Image")
-	f.outf("/Width %d", int(info.w))
-	f.outf("/Height %d", int(info.h))
+	w, h := info.storedSize()
+	f.outf("/Width %d", w)
+	f.outf("/Height %d", h)
+	// The palette follows the soft mask, if any, and the ICC profile follows
+	// the palette
+	palN := f.n + 1
+	if len(info.smask) > 0 {
+		palN++
+	}
+	cs := "/" + info.cs
+	if len(info.icc) > 0 {
+		iccN := palN
+		if info.cs == "Indexed" {
+			iccN++
+		}
+		cs = sprintf("[/ICCBased %d 0 R]", iccN)
+	}
 	if info.cs == "Indexed" {
-		f.outf("/ColorSpace [/Indexed /DeviceRGB %d %d 0 R]", len(info.pal)/3-1, f.n+1)
-	} else {
-		f.outf("/ColorSpace /%s", info.cs)
-		if info.cs == "DeviceCMYK" {
-			f.out("/Decode [1 0 1 0 1 0 1 0]")
+		base := "/DeviceRGB"
+		if len(info.icc) > 0 {
+			base = cs
 		}
+		f.outf("/ColorSpace [/Indexed %s %d %d 0 R]", base, len(info.pal)/3-1, palN)
+	} else {
+		f.outf("/ColorSpace %s", cs)
+	}
+	if len(info.dcd) > 0 {
+		f.outf("/Decode [%s]", info.dcd)
 	}
 	f.outf("/BitsPerComponent %d", info.bpc)
 	if len(info.f) > 0 {
@@ -4490,7 +4768,9 @@ func (f *Fpdf) putimage(info *ImageInfoType) {
 		}
 		f.outf("/Mask [%s]", trns.String())
 	}
-	if info.smask != nil {
+	if info.mask != "" {
+		f.outf("/SMask %d 0 R", f.imageObjNum(info.mask))
+	} else if info.smask != nil {
 		f.outf("/SMask %d 0 R", f.n+1)
 	}
 	f.outf("/Length %d>>", len(info.data))
@@ -4499,12 +4779,12 @@ func (f *Fpdf) putimage(info *ImageInfoType) {
 	// 	Soft mask
 	if len(info.smask) > 0 {
 		smask := &ImageInfoType{
-			w:     info.w,
-			h:     info.h,
+			w:     float64(w),
+			h:     float64(h),
 			cs:    "DeviceGray",
-			bpc:   8,
-			f:     info.f,
-			dp:    sprintf("/Predictor 15 /Colors 1 /BitsPerComponent 8 /Columns %d", int(info.w)),
+			bpc:   info.bpc,
+			f:     "FlateDecode",
+			dp:    sprintf("/Predictor 15 /Colors 1 /BitsPerComponent %d /Columns %d", info.bpc, w),
 			data:  info.smask,
 			scale: f.k,
 		}
@@ -4523,6 +4803,23 @@ func (f *Fpdf) putimage(info *ImageInfoType) {
 		}
 		f.out("endobj")
 	}
+	// ICC profile
+	if len(info.icc) > 0 {
+		alt := info.cs
+		if alt == "Indexed" {
+			alt = "DeviceRGB"
+		}
+		f.newobj()
+		if f.compress {
+			icc := sliceCompress(info.icc)
+			f.outf("<</N %d /Alternate /%s /Filter /FlateDecode /Length %d>>", iccComponents(info.icc), alt, len(icc))
+			f.putstream(icc)
+		} else {
+			f.outf("<</N %d /Alternate /%s /Length %d>>", iccComponents(info.icc), alt, len(info.icc))
+			f.putstream(info.icc)
+		}
+		f.out("endobj")
+	}
 }
 
 func (f *Fpdf) putxobjectdict() {
@@ -4536,9 +4833,14 @@ func (f *Fpdf) putxobjectdict() {
 		if f.catalogSort {
 			sort.SliceStable(keyList, func(i, j int) bool { return f.images[keyList[i]].i < f.images[keyList[j]].i })
 		}
+		// Images with the same content share their name
+		written := make(map[string]bool)
 		for _, key = range keyList {
 			image = f.images[key]
-			f.outf("/I%s %d 0 R", image.i, image.n)
+			if !written[image.i] {
+				f.outf("/I%s %d 0 R", image.i, image.n)
+				written[image.i] = true
+			}
 		}
 	}
 	{
@@ -4576,21 +4878,34 @@ func (f *Fpdf) putresourcedict() {
 		if f.catalogSort {
 			sort.SliceStable(keyList, func(i, j int) bool { return f.fonts[keyList[i]].i < f.fonts[keyList[j]].i })
 		}
+		written := make(map[string]bool)
 		for _, key = range keyList {
 			font = f.fonts[key]
-			f.outf("/F%s %d 0 R", font.i, font.N)
+			if !written[font.i] {
+				f.outf("/F%s %d 0 R", font.i, font.N)
+				written[font.i] = true
+			}
 		}
 	}
 	f.out(">>")
 	f.out("/XObject <<")
 	f.putxobjectdict()
 	f.out(">>")
+	f.putnumberedresourcedict()
+}
+
+// putnumberedresourcedict writes the entries of a resource dictionary for the
+// blend modes, gradients, layers and spot colors of the document
+func (f *Fpdf) putnumberedresourcedict() {
 	count := len(f.blendList)
-	if count > 1 {
+	if count > 1 || len(f.softMasks) > 1 {
 		f.out("/ExtGState <<")
 		for j := 1; j < count; j++ {
 			f.outf("/GS%d %d 0 R", j, f.blendList[j].objNum)
 		}
+		for j := 1; j < len(f.softMasks); j++ {
+			f.outf("/SM%d %d 0 R", j, f.softMasks[j].objNum)
+		}
 		f.out(">>")
 	}
 	count = len(f.gradientList)
@@ -4601,6 +4916,13 @@ func (f *Fpdf) putresourcedict() {
 		}
 		f.out(">>")
 	}
+	if len(f.patterns) > 1 {
+		f.out("/Pattern <<")
+		for j := 1; j < len(f.patterns); j++ {
+			f.outf("/P%d %d 0 R", j, f.patterns[j].objNum)
+		}
+		f.out(">>")
+	}
 	// Layers
 	f.layerPutResourceDict()
 	f.spotColorPutResourceDict()
@@ -4623,20 +4945,29 @@ func (f *Fpdf) putGradients() {
 	for j := 1; j < count; j++ {
 		var f1 int
 		gr := f.gradientList[j]
+		if gr.tp == 6 {
+			f.putMesh(gr)
+			f.gradientList[j].objNum = f.n
+			continue
+		}
 		if gr.tp == 2 || gr.tp == 3 {
 			f.newobj()
-			f.outf("<</FunctionType 2 /Domain [0.0 1.0] /C0 [%s] /C1 [%s] /N 1>>", gr.clr1Str, gr.clr2Str)
+			if gr.fnStr != "" {
+				f.out(gr.fnStr)
+			} else {
+				f.outf("<</FunctionType 2 /Domain [0.0 1.0] /C0 [%s] /C1 [%s] /N 1>>", gr.clr1Str, gr.clr2Str)
+			}
 			f.out("endobj")
 			f1 = f.n
 		}
 		f.newobj()
-		f.outf("<</ShadingType %d /ColorSpace /DeviceRGB", gr.tp)
+		f.outf("<</ShadingType %d /ColorSpace /%s", gr.tp, strIf(gr.gray, "DeviceGray", "DeviceRGB"))
 		if gr.tp == 2 {
-			f.outf("/Coords [%.5f %.5f %.5f %.5f] /Function %d 0 R /Extend [true true]>>",
-				gr.x1, gr.y1, gr.x2, gr.y2, f1)
+			f.outf("/Coords [%.5f %.5f %.5f %.5f] /Function %d 0 R /Extend [%s]>>",
+				gr.x1, gr.y1, gr.x2, gr.y2, f1, gr.extendStr)
 		} else if gr.tp == 3 {
-			f.outf("/Coords [%.5f %.5f 0 %.5f %.5f %.5f] /Function %d 0 R /Extend [true true]>>",
-				gr.x1, gr.y1, gr.x2, gr.y2, gr.r, f1)
+			f.outf("/Coords [%.5f %.5f %.5f %.5f %.5f %.5f] /Function %d 0 R /Extend [%s]>>",
+				gr.x1, gr.y1, gr.r0, gr.x2, gr.y2, gr.r, f1, gr.extendStr)
 		}
 		f.out("endobj")
 		f.gradientList[j].objNum = f.n
@@ -4675,6 +5006,8 @@ func (f *Fpdf) putresources() {
 		return
 	}
 	f.putimages()
+	f.putSoftMasks()
+	f.putPatterns()
 	f.putTemplates()
 	f.putImportedTemplates() // gofpdi
 	// 	Resource dictionary
@@ -4767,6 +5100,8 @@ func (f *Fpdf) putcatalog() {
 		f.outf("/Outlines %d 0 R", f.outlineRoot)
 		f.out("/PageMode /UseOutlines")
 	}
+	// Page numbering of the sections
+	f.putpagelabels()
 	// Layers
 	f.layerPutCatalog()
 	// Name dictionary :
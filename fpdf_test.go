@@ -19,7 +19,12 @@ package gofpdf_test
 import (
 	"bufio"
 	"bytes"
+	"compress/zlib"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
 	"io"
 	"io/ioutil"
 	"math"
@@ -27,6 +32,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -278,6 +284,357 @@ func TestFooterFuncLpi(t *testing.T) {
 	}
 }
 
+// TestFootnoteReservationAfterSetPage makes sure the room reserved for a
+// footnote survives a call to SetPage() and is released on the next page.
+func TestFootnoteReservationAfterSetPage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.Write(5, "Text with a note")
+	pdf.Footnote("The note at the foot of the first page.")
+	pdf.Ln(5)
+	pdf.SetPage(1)
+	_, pageHt := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	noteHt := 2 * 1.2 * 0.8 * 12 / pdf.GetConversionRatio()
+	limits := map[int]float64{1: pageHt - bottom - noteHt, 2: pageHt - bottom}
+	for pdf.PageNo() <= 2 {
+		pageNum := pdf.PageNo()
+		pdf.CellFormat(0, 5, "Line", "", 1, "L", false, 0, "")
+		if pdf.PageNo() == pageNum && pdf.GetY() > limits[pageNum]+1e-6 {
+			t.Fatalf("text written down to %.2f on page %d, limit is %.2f", pdf.GetY(), pageNum, limits[pageNum])
+		}
+	}
+	if err = pdf.Output(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMovePageAcrossSections makes sure the page labels remain a valid number
+// tree, with ascending keys, after a page is moved to another section.
+func TestMovePageAcrossSections(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.AddPage()
+	pdf.BeginSection(gofpdf.SectionOptions{NumberStyle: gofpdf.NumberLowerRoman})
+	pdf.AddPage()
+	pdf.BeginSection(gofpdf.SectionOptions{NumberPrefix: "A-"})
+	pdf.AddPage()
+	pdf.MovePage(5, 1)
+	var buf bytes.Buffer
+	if err = pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	str := buf.String()
+	pos := strings.Index(str, "/Nums [")
+	if pos < 0 {
+		t.Fatalf("no page labels")
+	}
+	str = str[pos+len("/Nums ["):]
+	fields := strings.Fields(str[:strings.Index(str, "]")])
+	prev := -1
+	for j := 0; j+1 < len(fields); j++ {
+		key, err := strconv.Atoi(fields[j])
+		if err != nil || !strings.HasPrefix(fields[j+1], "<<") {
+			continue
+		}
+		if key <= prev {
+			t.Fatalf("page label key %d follows key %d in %v", key, prev, fields)
+		}
+		prev = key
+	}
+	if prev < 0 {
+		t.Fatalf("no page label keys in %v", fields)
+	}
+}
+
+// TestTemplateGradient makes sure the gradients and patterns set in a
+// template are defined by the document that uses it.
+func TestTemplateGradient(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.SetCompression(false)
+	pdf.AddPage()
+	tpl, err := pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		gr := gofpdf.NewLinearGradient(0, 0, 50, 0)
+		gr.AddStop(0, 255, 0, 0)
+		gr.AddStop(1, 0, 0, 255)
+		tpl.SetFillGradient(gr)
+		tpl.Rect(0, 0, 50, 20, "F")
+		tpl.SetFillPattern(tpl.AddHatchPattern(gofpdf.HatchCross, 2, 0.2))
+		tpl.Rect(0, 30, 50, 20, "F")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.AddHatchPattern(gofpdf.HatchDots, 2, 0.5)
+	pdf.UseTemplate(tpl)
+	var buf bytes.Buffer
+	if err = pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	str := buf.String()
+	for _, s := range []string{"/ShadingType 2", "/PatternType 2", "/P1 scn", "/P2 scn", "/P3 "} {
+		if !strings.Contains(str, s) {
+			t.Errorf("%q not found in the document", s)
+		}
+	}
+	_, err = gofpdf.CreateTpl(gofpdf.PointType{}, gofpdf.SizeType{Wd: 10, Ht: 10}, "P", "mm", "", func(tpl *gofpdf.Tpl) {
+		tpl.AddHatchPattern(gofpdf.HatchDots, 2, 0.5)
+	})
+	if err == nil {
+		t.Errorf("pattern in a template without document accepted")
+	}
+}
+
+// TestMaskedRotatedImage makes sure the soft mask of a photo with an EXIF
+// orientation covers its pixels as they are stored, which are turned a quarter
+// for logo-rotated.jpg.
+func TestMaskedRotatedImage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.SetCompression(false)
+	pdf.AddPage()
+	// Mask hiding the left half of the upright image
+	mask := image.NewGray(image.Rect(0, 0, 50, 30))
+	for y := 0; y < 30; y++ {
+		for x := 25; x < 50; x++ {
+			mask.SetGray(x, y, color.Gray{255})
+		}
+	}
+	if _, err = pdf.RegisterGoImage("half", mask, gofpdf.GoImageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	pdf.ImageOptions(example.ImageFile("logo-rotated.jpg"), 10, 10, 60, 0, false,
+		gofpdf.ImageOptions{MaskImage: "half"}, 0, "")
+	var buf bytes.Buffer
+	if err = pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "/Width 30\n/Height 50") {
+		t.Errorf("soft mask not stored in the orientation of the image pixels")
+	}
+}
+
+// TestClipEnd checks that ClipEnd() restores the graphics state saved by a
+// clipping operation
+func TestClipEnd(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.SetCompression(false)
+	pdf.AddPage()
+	pdf.ClipRect(10, 10, 50, 20, false)
+	pdf.Rect(0, 0, 100, 100, "F")
+	if err = pdf.ClipEnd(); err != nil {
+		t.Fatal(err)
+	}
+	pdf.Rect(100, 100, 10, 10, "F")
+	var buf bytes.Buffer
+	if err = pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`W n\n[^Q]*\nQ\n[^\n]* re f\n`).Match(buf.Bytes()) {
+		t.Errorf("clipping not ended with the Q operator")
+	}
+}
+
+// TestMergeStringOperands checks that the resources renumbered by Merge()
+// are left as they are in the text of the pages
+func TestMergeStringOperands(t *testing.T) {
+	newDoc := func(blendMode string) *gofpdf.Fpdf {
+		pdf, err := gofpdf.New("P", "mm", "A4", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pdf.SetCompression(false)
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "", 12)
+		pdf.SetAlpha(0.5, blendMode)
+		pdf.Text(10, 10, "/GS1 gs")
+		return pdf
+	}
+	pdf, src := newDoc("Normal"), newDoc("Multiply")
+	if err := gofpdf.Merge(pdf, src); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "\n/GS2 gs\n") {
+		t.Errorf("blend mode of the merged page not renumbered")
+	}
+	if strings.Count(out, "(/GS1 gs) Tj") != 2 {
+		t.Errorf("text of the merged page changed")
+	}
+}
+
+// TestDeleteOnlyPage checks that the only page of a document, appended from
+// another one and thus finished, cannot be deleted
+func TestDeleteOnlyPage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.AddPage()
+	src.SetFont("Helvetica", "", 12)
+	src.Bookmark("Only page", 0, -1)
+	link := src.AddLink()
+	src.SetLink(link, 0, 1)
+	src.CellFormat(40, 10, "Top", "", 1, "L", false, link, "")
+	if err = gofpdf.Merge(pdf, src); err != nil {
+		t.Fatal(err)
+	}
+	if err = pdf.DeletePage(1); err == nil {
+		t.Fatalf("the only page should not be deletable")
+	}
+	if pdf.PageCount() != 1 {
+		t.Fatalf("expected 1 page, got %d", pdf.PageCount())
+	}
+}
+
+// TestTableRowTallerThanPage checks that a table row taller than a page is
+// split across pages and that a content block taller than a page is an
+// error
+func TestTableRowTallerThanPage(t *testing.T) {
+	newTable := func(imageHeight float64, images int) (*gofpdf.Fpdf, error) {
+		pdf, err := gofpdf.New("P", "mm", "A4", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pdf.SetCompression(false)
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetY(200)
+		var blocks []gofpdf.CellContent
+		for j := 0; j < images; j++ {
+			blocks = append(blocks, &gofpdf.CellImage{Name: example.ImageFile("logo.png"), Height: imageHeight})
+		}
+		tb := pdf.NewTableBuilder(gofpdf.TableSpec{HeaderRows: 1, Columns: []gofpdf.TableColumn{{Width: 60}, {Width: 100}}})
+		tb.AddRow("Images", "Text")
+		tb.AddCells(gofpdf.TableCell{Content: blocks}, gofpdf.TableCell{Text: strings.Repeat(lorem()+"\n", 4)})
+		return pdf, tb.Write()
+	}
+	pdf, err := newTable(70, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdf.PageCount() < 3 {
+		t.Fatalf("expected the row to span 3 pages, got %d", pdf.PageCount())
+	}
+	_, bottom := pdf.GetPageSize()
+	_, _, _, bMargin := pdf.GetMargins()
+	if pdf.GetY() > bottom-bMargin {
+		t.Errorf("table ends below the bottom margin")
+	}
+	var buf bytes.Buffer
+	if err = pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ops := regexp.MustCompile(`q [\d.]+ 0 0 [\d.]+ [\d.]+ (-?[\d.]+) cm /I`).FindAllStringSubmatch(buf.String(), -1)
+	if len(ops) != 5 {
+		t.Fatalf("expected 5 images, got %d", len(ops))
+	}
+	for _, op := range ops {
+		if y, _ := strconv.ParseFloat(op[1], 64); y < bMargin*72/25.4-0.01 {
+			t.Errorf("image drawn below the bottom margin, at %s", op[1])
+		}
+	}
+	if _, err = newTable(300, 1); err == nil || !strings.Contains(err.Error(), "taller than the page") {
+		t.Errorf("expected an error for an image taller than the page, got %v", err)
+	}
+}
+
+// pngChunk returns a PNG chunk of type typ holding data
+func pngChunk(typ string, data []byte) []byte {
+	chunk := make([]byte, len(data)+12)
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], typ)
+	copy(chunk[8:], data)
+	binary.BigEndian.PutUint32(chunk[8+len(data):], crc32.ChecksumIEEE(chunk[4:8+len(data)]))
+	return chunk
+}
+
+// TestPNGMalformed checks that PNG images with chunks too short for their
+// header are rejected with an error
+func TestPNGMalformed(t *testing.T) {
+	header := func(w, h uint32, bpc, ct, interlace byte) []byte {
+		ihdr := make([]byte, 13)
+		binary.BigEndian.PutUint32(ihdr, w)
+		binary.BigEndian.PutUint32(ihdr[4:], h)
+		ihdr[8], ihdr[9], ihdr[12] = bpc, ct, interlace
+		return append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", ihdr)...)
+	}
+	idat := func(raw []byte) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(raw)
+		zw.Close()
+		return pngChunk("IDAT", buf.Bytes())
+	}
+	iend := pngChunk("IEND", nil)
+	for _, tc := range []struct {
+		name string
+		img  []byte
+	}{
+		{"short 16-bit gray tRNS", bytes.Join([][]byte{header(1, 1, 16, 0, 0), pngChunk("tRNS", []byte{0}), idat(make([]byte, 3)), iend}, nil)},
+		{"short 16-bit RGB tRNS", bytes.Join([][]byte{header(1, 1, 16, 2, 0), pngChunk("tRNS", []byte{0, 0, 0}), idat(make([]byte, 7)), iend}, nil)},
+		{"truncated RGBA data", bytes.Join([][]byte{header(4, 4, 8, 6, 0), idat(make([]byte, 3)), iend}, nil)},
+		{"truncated 16-bit gray and alpha data", bytes.Join([][]byte{header(4, 4, 16, 4, 0), idat(make([]byte, 20)), iend}, nil)},
+		{"huge interlaced image", bytes.Join([][]byte{header(1<<30, 1<<30, 8, 0, 1), idat(make([]byte, 16)), iend}, nil)},
+	} {
+		pdf, err := gofpdf.New("P", "mm", "A4", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pdf.RegisterImageOptionsReader("img", gofpdf.ImageOptions{ImageType: "png"}, bytes.NewReader(tc.img))
+		if pdf.Error() == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
+
+// TestPNGBrokenICCProfile checks that a PNG image whose ICC profile cannot be
+// decompressed is loaded without it
+func TestPNGBrokenICCProfile(t *testing.T) {
+	img, err := ioutil.ReadFile(example.ImageFile("logo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Insert an iCCP chunk with a corrupt zlib header after the IHDR chunk
+	chunk := pngChunk("iCCP", []byte("broken\x00\x00\xff\xff\x01\x02"))
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	img = append(img[:ihdrEnd:ihdrEnd], append(chunk, img[ihdrEnd:]...)...)
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.AddPage()
+	pdf.RegisterImageOptionsReader("broken", gofpdf.ImageOptions{ImageType: "png"}, bytes.NewReader(img))
+	pdf.ImageOptions("broken", 10, 10, 30, 0, false, gofpdf.ImageOptions{}, 0, "")
+	if err = pdf.Output(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type fontResourceType struct {
 }
 
@@ -943,6 +1300,10 @@ func TestExampleFpdf_Image(t *testing.T) {
 	pdf.Text(50, 110, "logo-rgb.png")
 	pdf.Image(example.ImageFile("logo.jpg"), 10, 130, 30, 0, false, "", 0, "")
 	pdf.Text(50, 140, "logo.jpg")
+	pdf.Image(example.ImageFile("logo-16bit.png"), 10, 160, 30, 0, false, "", 0, "")
+	pdf.Text(50, 170, "logo-16bit.png")
+	pdf.Image(example.ImageFile("logo-interlaced.png"), 10, 190, 30, 0, false, "", 0, "")
+	pdf.Text(50, 200, "logo-interlaced.png")
 	fileStr := example.Filename("Fpdf_Image")
 
 	err = pdf.OutputFileAndClose(fileStr)
@@ -1484,6 +1845,57 @@ func TestExampleFpdf_Bookmark(t *testing.T) {
 	return
 }
 
+// TestExampleFpdf_InsertTOC demonstrates a table of contents inserted at the
+// beginning of the document once all the chapters have been written.
+func TestExampleFpdf_InsertTOC(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Page numbers written by the footer are resolved when the document is
+	// closed, so they account for the pages of the table of contents
+	pdf.AliasPageNo("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.CellFormat(0, 10, "Page {pn}/{nb}", "", 0, "C", false, 0, "")
+	})
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.CellFormat(0, 10, "Cover page", "", 1, "C", false, 0, "")
+	for chapter := 1; chapter <= 3; chapter++ {
+		pdf.AddPage()
+		title := fmt.Sprintf("Chapter %d", chapter)
+		pdf.Bookmark(title, 0, -1)
+		pdf.AddTOCEntry(title, 0)
+		pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
+		for section := 1; section <= 2; section++ {
+			title = fmt.Sprintf("Section %d.%d, with a title that is long enough to be "+
+				"wrapped on two lines in the table of contents", chapter, section)
+			pdf.Bookmark(title, 1, -1)
+			pdf.AddTOCEntry(title, 1)
+			pdf.MultiCell(0, 6, title, "", "L", false)
+			pdf.Ln(100)
+		}
+	}
+	pageCount := pdf.PageCount()
+	err = pdf.InsertTOC(2, gofpdf.TOCStyle{Title: "Contents", LevelStyles: []string{"B"}})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if pdf.PageCount() != pageCount+1 {
+		t.Fatalf("unexpected page count %d after inserting the table of contents", pdf.PageCount())
+	}
+	if pdf.PageNo() != pageCount+1 {
+		t.Fatalf("unexpected current page %d after inserting the table of contents", pdf.PageNo())
+	}
+	pdf.CellFormat(0, 10, "End of the last chapter", "", 1, "L", false, 0, "")
+	fileStr := example.Filename("Fpdf_InsertTOC")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+}
+
 // ExampleFpdf_TransformBegin demonstrates various transformations. It is adapted from an
 // example script by Moritz Wagner and Andreas Würmser.
 func TestExampleFpdf_TransformBegin(t *testing.T) {
@@ -2703,71 +3115,1361 @@ func TestExampleFpdf_RegisterAlias_utf8(t *testing.T) {
 	// Successfully generated pdf/Fpdf_RegisterAliasUTF8.pdf
 }
 
-// ExampleNewGrid demonstrates the generation of graph grids.
-func TestExampleNewGrid(t *testing.T) {
-	pdf, err := gofpdf.New("P", "mm", "A4", "")
+// ExampleFpdf_PageRef demonstrates forward page references resolved when
+// the document is output. The document is laid out twice so that the
+// references take the room of their final labels.
+func TestExampleFpdf_PageRef(t *testing.T) {
+	build := func(pdf *gofpdf.Fpdf) error {
+		pdf.AddUTF8Font("dejavu", "", example.FontFile("DejaVuSansCondensed.ttf"))
+		pdf.SetFont("dejavu", "", 12)
+		pdf.AddPage()
+		pdf.Write(6, "Les résultats sont présentés page "+pdf.PageRef("results")+
+			" et la conclusion page "+pdf.PageRef("conclusion")+". ")
+		pdf.Write(6, "Une référence perdue renvoie à la page "+pdf.PageRef("missing")+".")
+		pdf.Ln(10)
+		pdf.CellFormat(0, 8, "Conclusion → "+pdf.PageRef("conclusion"), "1", 1, "R", false, 0, "")
+		for j := 0; j < 12; j++ {
+			pdf.AddPage()
+			if j == 4 {
+				pdf.SetAnchor("results")
+				pdf.Write(6, "Résultats.")
+			}
+		}
+		pdf.SetAnchor("conclusion")
+		pdf.MultiCell(0, 6, "Conclusion, see also page "+pdf.PageRef("results")+" for the results.", "", "L", false)
+		return pdf.Error()
+	}
+	pdf, err := gofpdf.RelayoutPageRefs(func() (*gofpdf.Fpdf, error) {
+		return gofpdf.New("P", "mm", "A5", "")
+	}, build)
 	if err != nil {
 		t.Fatalf("Error %v", err)
 	}
-	pdf.SetFont("Arial", "", 12)
-	pdf.AddPage()
 
-	gr := gofpdf.NewGrid(13, 10, 187, 130)
-	gr.TickmarksExtentX(0, 10, 4)
-	gr.TickmarksExtentY(0, 10, 3)
-	gr.Grid(pdf)
+	fileStr := example.Filename("Fpdf_PageRef")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_PageRef.pdf
+}
 
-	gr = gofpdf.NewGrid(13, 154, 187, 128)
-	gr.XLabelRotate = true
-	gr.TickmarksExtentX(0, 1, 12)
-	gr.XDiv = 5
-	gr.TickmarksContainY(0, 1.1)
-	gr.YDiv = 20
-	// Replace X label formatter with month abbreviation
-	gr.XTickStr = func(val float64, precision int) string {
-		return time.Month(math.Mod(val, 12) + 1).String()[0:3]
+// ExampleFpdf_BeginSection demonstrates sections with their own page format,
+// margins, headers, footers and page numbering.
+func TestExampleFpdf_BeginSection(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
 	}
-	gr.Grid(pdf)
-	dot := func(x, y float64) {
-		pdf.Circle(gr.X(x), gr.Y(y), 0.5, "F")
+	pdf.SetFont("Arial", "", 11)
+	footer := func(align string) func() {
+		return func() {
+			pdf.SetY(-12)
+			pdf.SetFont("Arial", "I", 8)
+			pdf.CellFormat(0, 8, "Page {pn} of {snb}", "T", 0, align, false, 0, "")
+		}
 	}
-	pts := []float64{0.39, 0.457, 0.612, 0.84, 0.998, 1.037, 1.015, 0.918, 0.772, 0.659, 0.593, 0.164}
-	for month, val := range pts {
-		dot(float64(month)+0.5, val)
+	header := func(txt string) func() {
+		return func() {
+			pdf.SetFont("Arial", "B", 9)
+			pdf.CellFormat(0, 8, txt, "B", 1, "C", false, 0, "")
+			pdf.Ln(4)
+		}
+	}
+	sections := []struct {
+		title string
+		pages int
+		opts  gofpdf.SectionOptions
+	}{
+		{"Cover", 1, gofpdf.SectionOptions{NumberStyle: gofpdf.NumberNone, NumberPrefix: "Cover"}},
+		{"Front matter", 4, gofpdf.SectionOptions{NumberStyle: gofpdf.NumberLowerRoman,
+			Footer: footer("C")}},
+		{"Body", 5, gofpdf.SectionOptions{LeftMargin: 25, RightMargin: 15,
+			Header: header("Body"), FirstHeader: func() {}, Footer: footer("R"), EvenFooter: footer("L")}},
+		{"Appendix", 3, gofpdf.SectionOptions{OrientationStr: "L", NumberPrefix: "A-", TopMargin: 15, BottomMargin: 20,
+			Header: header("Appendix"), Footer: footer("C")}},
+	}
+	for _, sec := range sections {
+		err = pdf.BeginSection(sec.opts)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		for j := 0; j < sec.pages; j++ {
+			if j > 0 {
+				pdf.AddPage()
+			}
+			pdf.SetFont("Arial", "", 11)
+			pdf.MultiCell(0, 5, fmt.Sprintf("%s, page %d.\n\n%s", sec.title, j+1, lorem()), "", "L", false)
+		}
 	}
-	pdf.SetDrawColor(255, 64, 64)
-	pdf.SetAlpha(0.5, "Normal")
-	pdf.SetLineWidth(1.2)
-	gr.Plot(pdf, 0.5, 11.5, 50, func(x float64) float64 {
-		// http://www.xuru.org/rt/PR.asp
-		return 0.227 * math.Exp(-0.0373*x*x+0.471*x)
-	})
-	pdf.SetAlpha(1.0, "Normal")
-	pdf.SetXY(gr.X(0.5), gr.Y(1.35))
-	pdf.SetFontSize(14)
-	pdf.Write(0, "Solar energy (MWh) per month, 2016")
-	pdf.AddPage()
 
-	gr = gofpdf.NewGrid(13, 10, 187, 274)
-	gr.TickmarksContainX(2.3, 3.4)
-	gr.TickmarksContainY(10.4, 56.8)
-	gr.Grid(pdf)
+	fileStr := example.Filename("Fpdf_BeginSection")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_BeginSection.pdf
+}
 
-	fileStr := example.Filename("Fpdf_Grid")
+// ExampleFpdf_NewTableBuilder demonstrates a table with fitted column widths,
+// wrapped cells, spans, per-cell styles and a header repeated on each page.
+func TestExampleFpdf_NewTableBuilder(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 9)
+	pdf.AddPage()
+	tb := pdf.NewTableBuilder(gofpdf.TableSpec{
+		Columns: []gofpdf.TableColumn{
+			{Width: 12, Align: "CM"},
+			{},
+			{Width: 14, Align: "RM"},
+			{Align: "RM"},
+		},
+		HeaderRows: 2,
+		Padding:    1.5,
+		HeaderFill: gofpdf.LIGHT_BLUE,
+	})
+	tb.AddCells(gofpdf.TableCell{Text: "Invoice 2025-042", ColSpan: 4, Align: "CM"})
+	tb.AddRow("Ref", "Description", "Qty", "Amount")
+	words := strings.Fields(lorem())
+	for j := 0; j < 30; j++ {
+		desc := strings.Join(words[:5+(j*7)%40], " ")
+		if j%10 == 9 {
+			tb.AddCells(gofpdf.TableCell{Text: fmt.Sprintf("%d", j+1)},
+				gofpdf.TableCell{Text: "Subtotal", ColSpan: 2, Align: "RM", FontStyle: "B"},
+				gofpdf.TableCell{Text: fmt.Sprintf("%.2f", float64(j)*101.5), Fill: gofpdf.LIGHT_YELLOW, FontStyle: "B"})
+			continue
+		}
+		if j%10 == 3 {
+			// A reference spanning two lines, kept on the same page
+			tb.AddCells(gofpdf.TableCell{Text: fmt.Sprintf("%d-%d", j+1, j+2), RowSpan: 2, Fill: gofpdf.LIGHT_GREY},
+				gofpdf.TableCell{Text: desc},
+				gofpdf.TableCell{Text: "1"},
+				gofpdf.TableCell{Text: "12.00"})
+			continue
+		}
+		if j%10 == 4 {
+			// The first column is taken by the span from the row above
+			tb.AddRow(desc, "1", "8.50")
+			continue
+		}
+		tb.AddRow(fmt.Sprintf("%d", j+1), desc, fmt.Sprintf("%d", j%4+1), fmt.Sprintf("%.2f", float64(j)*10.25))
+	}
+	tb.AddCells(gofpdf.TableCell{Text: "Total", ColSpan: 3, Align: "RM", FontStyle: "B", Padding: 3},
+		gofpdf.TableCell{Text: "4242.00", FontStyle: "B", Padding: 3, TextColor: gofpdf.RED})
+	err = tb.Write()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Write(5, "Text after the table.")
+	if pdf.PageCount() < 2 {
+		t.Fatalf("table should break across pages, got %d page", pdf.PageCount())
+	}
+
+	fileStr := example.Filename("Fpdf_NewTableBuilder")
 	err = pdf.OutputFileAndClose(fileStr)
 	if err != nil {
 		t.Fatalf("Error %v", err)
 	}
 	// Output:
-	// Successfully generated pdf/Fpdf_Grid.pdf
+	// Successfully generated pdf/Fpdf_NewTableBuilder.pdf
 }
 
-// ExampleFpdf_SetPageBox demonstrates the use of a page box
-func TestExampleFpdf_SetPageBox(t *testing.T) {
-	// pdfinfo (from http://www.xpdfreader.com) reports the following for this example:
-	// ~ pdfinfo -box pdf/Fpdf_PageBox.pdf
-	// Producer:       FPDF 1.7
-	// CreationDate:   Sat Jan  1 00:00:00 2000
+// TestExampleFpdf_NamedTableTheme demonstrates cell styles and table themes.
+func TestExampleFpdf_NamedTableTheme(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 10)
+	pdf.AddPage()
+	table := [][]string{
+		{"Item", "Q1", "Q2", "Q3"},
+		{"Revenue", "1200.00", "1350.00", "1410.00"},
+		{"Costs", "-800.00", "-820.00", "-905.00"},
+		{"Taxes", "-120.00", "-160.00", "-150.00"},
+		{"Net income", "280.00", "370.00", "355.00"},
+	}
+	align := []string{"LM", "RM", "RM", "RM"}
+	for _, name := range []string{gofpdf.ThemeStriped, gofpdf.ThemeGrid, gofpdf.ThemeMinimal, gofpdf.ThemeFinancial} {
+		theme, err := pdf.NamedTableTheme(name)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		pdf.CellFormat(0, 8, "Theme "+name, "", 1, "LM", false, 0, "")
+		pdf.SetTableTheme(theme)
+		pdf.Table(120, table, align, true, true)
+	}
+	pdf.SetTableTheme(nil)
+	if _, err = pdf.NamedTableTheme("baroque"); err == nil {
+		t.Fatalf("unknown theme should be rejected")
+	}
+
+	// Hand made cells
+	dotted := gofpdf.BorderSide{Width: 0.3, Color: gofpdf.BLUE, Dash: []float64{0.8, 0.8}}
+	style := gofpdf.CellStyle{
+		Left:      gofpdf.BorderSide{Width: 1.2, Color: gofpdf.RED},
+		Top:       dotted,
+		Bottom:    dotted,
+		Padding:   gofpdf.CellPadding{Left: 4, Top: 1, Right: 2, Bottom: 1},
+		Fill:      gofpdf.LIGHT_YELLOW,
+		FontStyle: "I",
+	}
+	pdf.CellFormatStyle(90, 12, "Left rule, dotted top and bottom", style, 2, "LM", 0, "")
+	pdf.Ln(4)
+	rounded := gofpdf.CellStyle{Radius: 3, Fill: gofpdf.LIGHT_BLUE, TextColor: gofpdf.DARK_GREY}
+	rounded = rounded.SetBorder(gofpdf.BorderSide{Width: 0.4, Color: gofpdf.BLUE})
+	pdf.CellFormatStyle(60, 12, "Rounded corners", rounded, 0, "CM", 0, "")
+	pdf.SetX(pdf.GetX() + 5)
+	rounded.Left.Double = true
+	rounded = rounded.SetBorder(rounded.Left)
+	pdf.CellFormatStyle(60, 12, "Double border", rounded, 1, "CM", 0, "")
+	pdf.Ln(6)
+
+	// A builder table with the financial theme and a highlighted cell
+	theme, err := pdf.NamedTableTheme(gofpdf.ThemeFinancial)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	tb := pdf.NewTableBuilder(gofpdf.TableSpec{
+		Width:      120,
+		Columns:    []gofpdf.TableColumn{{}, {Align: "RM"}},
+		HeaderRows: 1,
+		Theme:      theme,
+	})
+	tb.AddRow("Account", "Balance")
+	tb.AddRow("Cash", "1,250.00")
+	tb.AddCells(gofpdf.TableCell{Text: "Receivables"},
+		gofpdf.TableCell{Text: "310.00", Style: &rounded})
+	tb.AddRow("Total", "1,560.00")
+	if err = tb.Write(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	fileStr := example.Filename("Fpdf_NamedTableTheme")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_NamedTableTheme.pdf
+}
+
+// TestExampleFpdf_TableFromStructs demonstrates tables made from structs and
+// CSV data, with formatted columns, subtotals and totals.
+func TestExampleFpdf_TableFromStructs(t *testing.T) {
+	type sale struct {
+		Region  string    `pdf:",width=30"`
+		Date    time.Time `pdf:",format=02 Jan 2006"`
+		Product string
+		Amount  float64 `pdf:"Amount (USD),total"`
+		Margin  float64
+		note    string
+	}
+	day := func(d int) time.Time {
+		return time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+	sales := []sale{
+		{"Americas", day(3), "Widgets", 1250.5, 0.21, ""},
+		{"Americas", day(9), "Gadgets", 980, 0.185, ""},
+		{"Europe", day(4), "Widgets", 2210.75, 0.24, ""},
+		{"Europe", day(12), "Gizmos", 430, 0.3, ""},
+		{"Europe", day(20), "Gadgets", 1615.25, 0.19, ""},
+		{"Asia", day(7), "Gizmos", 3120, 0.275, ""},
+	}
+
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 10)
+	pdf.AddPage()
+	theme, err := pdf.NamedTableTheme(gofpdf.ThemeFinancial)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	tb, err := pdf.TableFromStructs(sales, gofpdf.TableDataOptions{
+		Spec: gofpdf.TableSpec{Theme: theme},
+		Formatters: map[string]gofpdf.CellFormatter{
+			"Amount": gofpdf.FormatCurrency("$", 2),
+			"Margin": gofpdf.FormatPercent(1),
+		},
+		GroupBy: "Region",
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if err = tb.Write(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Ln(10)
+
+	data := "sku,label,price,stock,updated\n" +
+		"A-100,Widget,12.5,120,2025-02-01\n" +
+		"A-200,Gadget,7.25,80,2025-02-14\n" +
+		"B-300,\"Gizmo, large\",1049.9,3,2025-03-02\n"
+	tb, err = pdf.TableFromCSV(strings.NewReader(data), gofpdf.TableDataOptions{
+		Columns: []string{"label", "sku", "price", "stock", "updated"},
+		Tags: map[string]string{
+			"label":   "Product",
+			"sku":     "SKU,align=CM",
+			"price":   "Price,format=%.2f",
+			"stock":   "In stock,format=%d",
+			"updated": "Last update,format=Jan 2, 2006",
+		},
+		Totals: []string{"stock"},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if err = tb.Write(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	for _, test := range []struct {
+		formatter gofpdf.CellFormatter
+		value     interface{}
+		text      string
+	}{
+		{gofpdf.FormatCurrency("$", 2), -1234567.891, "-$1,234,567.89"},
+		{gofpdf.FormatCurrency(" €", 0), "980", "980 €"},
+		{gofpdf.FormatNumber(1, " ", ","), 12345.67, "12 345,7"},
+		{gofpdf.FormatPercent(0), 0.5, "50%"},
+		{gofpdf.FormatDate("2006"), "2025-03-02", "2025"},
+		{gofpdf.FormatPercent(1), "n/a", "n/a"},
+	} {
+		if text := test.formatter(test.value); text != test.text {
+			t.Fatalf("formatted %v as %q, expected %q", test.value, text, test.text)
+		}
+	}
+	if _, err = pdf.TableFromStructs(sales, gofpdf.TableDataOptions{GroupBy: "Country"}); err == nil {
+		t.Fatalf("unknown column should be rejected")
+	}
+	pdf.ClearError()
+
+	fileStr := example.Filename("Fpdf_TableFromStructs")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_TableFromStructs.pdf
+}
+
+// TestExampleFpdf_CellContent demonstrates table cells holding images, styled
+// text, lists, drawings and nested tables.
+func TestExampleFpdf_CellContent(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 9)
+	pdf.AddPage()
+	tb := pdf.NewTableBuilder(gofpdf.TableSpec{
+		Columns:    []gofpdf.TableColumn{{Width: 35, Align: "CT"}, {Align: "LT"}, {Width: 60, Align: "LT"}},
+		HeaderRows: 1,
+		Padding:    2,
+		HeaderFill: gofpdf.LIGHT_BLUE,
+	})
+	tb.AddRow("Picture", "Description", "Specifications")
+	images := []string{"logo.png", "logo.jpg", "logo.gif", "golang-gopher.png"}
+	for j := 0; j < 12; j++ {
+		specs := pdf.NewTableBuilder(gofpdf.TableSpec{Columns: []gofpdf.TableColumn{{}, {Align: "RM"}}, Padding: 1})
+		specs.AddRow("Weight", fmt.Sprintf("%d g", 120+15*j))
+		specs.AddRow("Size", fmt.Sprintf("%d x %d mm", 40+j, 25+2*j))
+		specs.AddCells(gofpdf.TableCell{Text: "Stock"},
+			gofpdf.TableCell{Text: fmt.Sprintf("%d", (j*37)%50), Fill: gofpdf.LIGHT_GREEN})
+		level := j % 3
+		tb.AddCells(
+			gofpdf.TableCell{Content: []gofpdf.CellContent{
+				&gofpdf.CellImage{Name: example.ImageFile(images[j%len(images)]), Width: 28, Align: "C"},
+			}},
+			gofpdf.TableCell{Content: []gofpdf.CellContent{
+				&gofpdf.CellText{Runs: []gofpdf.TextRun{
+					{Text: fmt.Sprintf("Article %d", j+1), FontStyle: "B", FontSize: 11},
+					{Text: "\n" + lorem()[:80+40*level]},
+					{Text: " New!", FontStyle: "BI", Color: gofpdf.RED},
+				}},
+				&gofpdf.CellList{Items: []string{"Free shipping", "Two year warranty"}, Level: level},
+				&gofpdf.CellDrawing{Width: 40, Height: 4, Draw: func(f *gofpdf.Fpdf, x, y, w, h float64) error {
+					// Rating bar
+					f.SetFillColor(200, 200, 200)
+					f.Rect(x, y+1, w, h-2, "F")
+					f.SetFillColor(46, 204, 113)
+					f.Rect(x, y+1, w*float64(j%5+1)/5, h-2, "F")
+					return nil
+				}},
+			}},
+			gofpdf.TableCell{Content: []gofpdf.CellContent{specs}},
+		)
+	}
+	if err = tb.Write(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if pdf.PageCount() < 2 {
+		t.Fatalf("table should break across pages, got %d page", pdf.PageCount())
+	}
+
+	fileStr := example.Filename("Fpdf_CellContent")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_CellContent.pdf
+}
+
+// TestExampleFpdf_WriteList demonstrates nested numbered and bulleted lists.
+func TestExampleFpdf_WriteList(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 10)
+	pdf.AddPage()
+	words := strings.Fields(lorem())
+	text := func(j int) string {
+		return strings.Join(words[j%20:j%20+4+(j*13)%30], " ")
+	}
+
+	// Ordered list: 1., a), i.
+	ordered := gofpdf.ListStyle{
+		Levels: []gofpdf.ListLevelStyle{
+			{NumberStyle: gofpdf.NumberDecimal},
+			{NumberStyle: gofpdf.NumberLowerAlpha, Format: "%s)"},
+			{NumberStyle: gofpdf.NumberLowerRoman},
+		},
+		Spacing: 1,
+	}
+	var items []gofpdf.ListItem
+	for j := 0; j < 9; j++ {
+		item := gofpdf.ListItem{Text: text(j)}
+		if j%3 == 1 {
+			for k := 0; k < 3; k++ {
+				sub := gofpdf.ListItem{Text: text(j + k)}
+				if k == 1 {
+					sub.Items = []gofpdf.ListItem{{Text: text(k)}, {Text: text(k + 5)}}
+				}
+				item.Items = append(item.Items, sub)
+			}
+		}
+		items = append(items, item)
+	}
+	if err = pdf.WriteList(items, ordered); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Ln(4)
+	pdf.MultiCell(0, 5, "The list goes on after this paragraph, its numbering continued.", "", "L", false)
+	pdf.Ln(2)
+	ordered.Start = len(items) + 1
+	if err = pdf.WriteList(items[:3], ordered); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Ln(4)
+
+	// Outline numbering 1.1.1
+	outline := gofpdf.ListStyle{Levels: []gofpdf.ListLevelStyle{
+		{NumberStyle: gofpdf.NumberDecimal, Outline: true},
+		{NumberStyle: gofpdf.NumberDecimal, Format: "%s", Outline: true, Indent: 10},
+		{NumberStyle: gofpdf.NumberDecimal, Format: "%s", Outline: true, Indent: 12},
+	}}
+	if err = pdf.WriteList(items, outline); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Ln(4)
+
+	// Default bullets, glyphs and images
+	if err = pdf.WriteList(items[:5], gofpdf.ListStyle{}); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Ln(4)
+	bullets := gofpdf.ListStyle{Levels: []gofpdf.ListLevelStyle{
+		{BulletImage: example.ImageFile("logo.png")},
+		{Bullet: "-"},
+	}}
+	if err = pdf.WriteList(items[:5], bullets); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if pdf.PageCount() < 2 {
+		t.Fatalf("lists should flow across pages, got %d page", pdf.PageCount())
+	}
+
+	// A list in a table cell
+	pdf.Ln(4)
+	tb := pdf.NewTableBuilder(gofpdf.TableSpec{Columns: []gofpdf.TableColumn{{Width: 30}, {}}, Padding: 2})
+	tb.AddCells(gofpdf.TableCell{Text: "Steps"},
+		gofpdf.TableCell{Content: []gofpdf.CellContent{&gofpdf.ListBlock{Items: items[:2], Style: ordered}}})
+	if err = tb.Write(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	fileStr := example.Filename("Fpdf_WriteList")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_WriteList.pdf
+}
+
+// TestExampleFpdf_SetRunningHeader demonstrates running headers and footers
+// with a logo, fields, rules and first page and even page variants.
+func TestExampleFpdf_SetRunningHeader(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetTitle("Field guide", true)
+	pdf.SetCreationDate(time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC))
+	pdf.SetFont("Helvetica", "", 10)
+	grey := gofpdf.NewRGBColor(110, 110, 110)
+	rule := gofpdf.BorderSide{Width: 0.2, Color: grey}
+
+	odd := gofpdf.RunningBand{
+		Left:   gofpdf.RunningSlot{Image: example.ImageFile("logo.png")},
+		Center: gofpdf.RunningSlot{Runs: []gofpdf.TextRun{{Text: "{chapter}", FontStyle: "B"}}},
+		Right:  gofpdf.RunningSlot{Runs: []gofpdf.TextRun{{Text: "{section}", FontStyle: "I"}}},
+		Rule:   rule,
+	}
+	even := odd
+	even.Left, even.Right = odd.Right, odd.Left
+	pdf.SetRunningHeader(&gofpdf.RunningSpec{
+		Band:     odd,
+		Even:     &even,
+		First:    &gofpdf.RunningBand{},
+		FontSize: 8,
+		Color:    grey,
+		Height:   6,
+	})
+	pdf.SetRunningFooter(&gofpdf.RunningSpec{
+		Band: gofpdf.RunningBand{
+			Left: gofpdf.RunningSlot{Runs: []gofpdf.TextRun{{Text: "{title}", FontStyle: "B"}, {Text: " - {date}"}}},
+			Right: gofpdf.RunningSlot{Runs: []gofpdf.TextRun{
+				{Text: "Page "}, {Text: "{page}", FontStyle: "B"}, {Text: " of {nb}"},
+			}},
+			Rule: gofpdf.BorderSide{Width: 0.2, Double: true},
+		},
+		FontSize:   8,
+		DateFormat: "January 2, 2006",
+	})
+
+	pdf.AddPage()
+	pdf.SetY(60)
+	pdf.Title("Field guide", 0, nil, nil)
+	for _, chapter := range []string{"Birds", "Trees"} {
+		pdf.AddPage()
+		pdf.Title(chapter, 0, nil, nil)
+		for j := 1; j <= 5; j++ {
+			pdf.Bookmark(fmt.Sprintf("%s, part %d", chapter, j), 1, -1)
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.CellFormat(0, 8, fmt.Sprintf("Part %d", j), "", 1, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.MultiCell(0, 5, lorem(), "", "J", false)
+			pdf.Ln(4)
+		}
+	}
+
+	fileStr := example.Filename("Fpdf_SetRunningHeader")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_SetRunningHeader.pdf
+}
+
+// TestExampleFpdf_MovePage demonstrates a summary written last and moved to
+// the front, a page inserted afterwards and a page deleted.
+func TestExampleFpdf_MovePage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.AliasPageNo("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.CellFormat(0, 6, "Page {pn} of {nb}", "", 0, "C", false, 0, "")
+	})
+	regions := []string{"North", "East", "Draft", "South", "West"}
+	for _, region := range regions {
+		pdf.AddPage()
+		pdf.SetAnchor(region)
+		pdf.Bookmark(region, 0, -1)
+		pdf.CellFormat(0, 10, region+" region", "", 1, "L", false, 0, "")
+		pdf.MultiCell(0, 5, lorem(), "", "J", false)
+	}
+
+	// The summary is written last, with references to the regions
+	pdf.AddPage()
+	pdf.Bookmark("Summary", 0, -1)
+	pdf.CellFormat(0, 10, "Summary", "", 1, "L", false, 0, "")
+	for _, region := range []string{"North", "East", "South", "West"} {
+		pdf.CellFormat(0, 6, region+" region, page "+pdf.PageRef(region), "", 1, "L", false, 0, "")
+	}
+	if err = pdf.MovePage(pdf.PageCount(), 1); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	// The draft page goes away and a divider is inserted before the regions
+	if err = pdf.DeletePage(4); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if err = pdf.InsertPageBefore(2); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetPage(2)
+	pdf.SetY(60)
+	pdf.SetFont("Helvetica", "B", 24)
+	pdf.CellFormat(0, 20, "Regions", "", 1, "C", false, 0, "")
+	if err = pdf.DeletePage(1); err == nil {
+		t.Fatalf("the page being written should not be deletable")
+	}
+	if pdf.PageCount() != 6 {
+		t.Fatalf("expected 6 pages, got %d", pdf.PageCount())
+	}
+	pdf.ClearError()
+
+	fileStr := example.Filename("Fpdf_MovePage")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_MovePage.pdf
+}
+
+// TestExampleFpdf_Merge demonstrates chapters built concurrently as separate
+// documents and merged behind a cover page.
+func TestExampleFpdf_Merge(t *testing.T) {
+	newPdf := func() (*gofpdf.Fpdf, error) {
+		pdf, err := gofpdf.New("P", "mm", "A5", "")
+		if err == nil {
+			pdf.AddUTF8Font("dejavu", "", example.FontFile("DejaVuSansCondensed.ttf"))
+			pdf.AddSpotColor("Ink", 100, 30, 0, 10)
+			pdf.AliasPageNo("")
+			pdf.SetFooterFunc(func() {
+				pdf.SetY(-12)
+				pdf.SetFont("dejavu", "", 8)
+				pdf.CellFormat(0, 6, "{pn} / {nb}", "", 0, "C", false, 0, "")
+			})
+		}
+		return pdf, err
+	}
+	chapter := func(num int) (*gofpdf.Fpdf, error) {
+		pdf, err := newPdf()
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("Chapter %d", num)
+		pdf.AddPage()
+		pdf.SetAnchor(name)
+		pdf.Bookmark(name, 0, -1)
+		pdf.SetFont("dejavu", "", 16)
+		pdf.CellFormat(0, 12, name+" – ça commence", "", 1, "L", false, 0, "")
+		pdf.SetFillSpotColor("Ink", 100)
+		pdf.Rect(10, 24, 100, 1.5, "F")
+		pdf.Image(example.ImageFile("logo.png"), 120, 12, 15, 0, false, "", 0, "")
+		pdf.LinearGradient(10, 30, 128, 8, 255, 255, 255, 40*num, 120, 200, 0, 0, 1, 0)
+		pdf.SetY(42)
+		pdf.SetFont("dejavu", "", 10)
+		next := fmt.Sprintf("Chapter %d", num%3+1)
+		pdf.MultiCell(0, 5, lorem()+" See "+next+" on page "+pdf.PageRef(next)+".", "", "J", false)
+		if num == 2 {
+			pdf.AddSpotColor("Accent", 0, 80, 90, 0)
+			pdf.SetFillSpotColor("Accent", 100)
+			pdf.Rect(10, 27, 60, 1.5, "F")
+			pdf.AddPageFormat("L", gofpdf.SizeType{Wd: 148, Ht: 210})
+			pdf.SetAlpha(0.5, "Normal")
+			pdf.SetFillColor(200, 60, 60)
+			pdf.Rect(20, 20, 170, 80, "F")
+			pdf.SetAlpha(1, "Normal")
+			pdf.Bookmark("A landscape page", 1, -1)
+		}
+		pdf.AddPage()
+		pdf.MultiCell(0, 5, lorem(), "", "J", false)
+		return pdf, nil
+	}
+
+	// Chapters are built concurrently
+	chapters := make([]*gofpdf.Fpdf, 3)
+	errs := make(chan error, len(chapters))
+	for j := range chapters {
+		go func(j int) {
+			var err error
+			chapters[j], err = chapter(j + 1)
+			errs <- err
+		}(j)
+	}
+	for range chapters {
+		if err := <-errs; err != nil {
+			t.Fatalf("Error %v", err)
+		}
+	}
+
+	pdf, err := newPdf()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddSpotColor("Cover", 0, 0, 0, 60)
+	pdf.AddPage()
+	pdf.SetFillSpotColor("Cover", 100)
+	pdf.Rect(10, 50, 128, 4, "F")
+	pdf.SetFont("dejavu", "", 24)
+	pdf.SetY(60)
+	pdf.CellFormat(0, 20, "Merged chapters", "", 1, "C", false, 0, "")
+	pdf.SetFont("dejavu", "", 12)
+	for j := range chapters {
+		name := fmt.Sprintf("Chapter %d", j+1)
+		pdf.CellFormat(0, 8, name+", page "+pdf.PageRef(name), "", 1, "C", false, 0, "")
+	}
+	if err = gofpdf.Merge(pdf, chapters...); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if pdf.PageCount() != 8 {
+		t.Fatalf("expected 8 pages, got %d", pdf.PageCount())
+	}
+
+	fileStr := example.Filename("Fpdf_Merge")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_Merge.pdf
+}
+
+// TestExampleFpdf_Impose demonstrates the imposition of a document for
+// printing: 2-up and 4-up handouts, a booklet and a poster.
+func TestExampleFpdf_Impose(t *testing.T) {
+	src, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	src.AddUTF8Font("dejavu", "", example.FontFile("DejaVuSansCondensed.ttf"))
+	src.AliasNbPages("")
+	src.SetFooterFunc(func() {
+		src.SetY(-12)
+		src.SetFont("dejavu", "", 8)
+		src.CellFormat(0, 6, fmt.Sprintf("Page %d of {nb}", src.PageNo()), "", 0, "C", false, 0, "")
+	})
+	for j := 1; j <= 6; j++ {
+		src.AddPage()
+		src.SetDrawColor(120, 120, 120)
+		src.Rect(5, 5, 138, 200, "D")
+		src.SetFont("dejavu", "", 20)
+		src.CellFormat(0, 12, fmt.Sprintf("Page %d", j), "", 1, "L", false, 0, "")
+		src.Image(example.ImageFile("logo.png"), 110, 12, 20, 0, false, "", 0, "")
+		src.SetAlpha(0.4, "Normal")
+		src.SetFillColor(60, 120, 200)
+		src.Rect(10, 26, 128, 6, "F")
+		src.SetAlpha(1, "Normal")
+		src.SetY(36)
+		src.SetFont("dejavu", "", 10)
+		src.MultiCell(0, 5, lorem(), "", "J", false)
+	}
+
+	// 2-up on A5 landscape sheets
+	pdf, err := gofpdf.Impose(src, gofpdf.ImpositionLayout{Kind: gofpdf.ImposeNUp,
+		Margin: 5, Gutter: 10, CropMarks: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// 4-up on A4 portrait sheets, the pages turned to fit larger
+	fourUp, err := gofpdf.Impose(src, gofpdf.ImpositionLayout{Kind: gofpdf.ImposeNUp,
+		SheetSize: gofpdf.SizeType{Wd: 210, Ht: 297}, Cols: 2, Rows: 2, AutoRotate: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// 2-up on A4 portrait sheets, the pages turned to fit larger
+	turned, err := gofpdf.Impose(src, gofpdf.ImpositionLayout{Kind: gofpdf.ImposeNUp,
+		SheetSize: gofpdf.SizeType{Wd: 210, Ht: 297}, Cols: 1, Rows: 2, AutoRotate: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Booklet on sheets with room for crop marks
+	booklet, err := gofpdf.Impose(src, gofpdf.ImpositionLayout{Kind: gofpdf.ImposeBooklet,
+		SheetSize: gofpdf.SizeType{Wd: 330, Ht: 240}, Margin: 15, Creep: 1, CropMarks: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// A one page plan enlarged 2.4 times, tiled on A4 sheets
+	plan, err := gofpdf.New("L", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	plan.AddPage()
+	plan.SetLineWidth(1)
+	plan.Circle(105, 74, 60, "D")
+	plan.Line(0, 74, 210, 74)
+	plan.Line(105, 0, 105, 148)
+	poster, err := gofpdf.Impose(plan, gofpdf.ImpositionLayout{Kind: gofpdf.ImposePoster,
+		Scale: 2.4, Margin: 10, Overlap: 10, CropMarks: true, AutoRotate: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if err = gofpdf.Merge(pdf, fourUp, turned, booklet, poster); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// 3 + 2 + 3 sheets, 4 sheet sides and 4 tiles
+	if pdf.PageCount() != 16 {
+		t.Fatalf("expected 16 pages, got %d", pdf.PageCount())
+	}
+
+	fileStr := example.Filename("Fpdf_Impose")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_Impose.pdf
+}
+
+// TestExampleFpdf_AddWatermark demonstrates watermarks stamped on the pages
+// already written and on the pages added afterwards, whatever their size.
+func TestExampleFpdf_AddWatermark(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Helvetica", "", 11)
+	page := func() {
+		pdf.AddPage()
+		pdf.SetFillColor(230, 240, 255)
+		w, _ := pdf.GetPageSize()
+		pdf.Rect(10, 10, w-20, 30, "F")
+		pdf.MultiCell(0, 5, lorem()+"\n\n"+lorem(), "", "J", false)
+	}
+	page()
+	page()
+	pdf.AddWatermark(gofpdf.Watermark{Text: "DRAFT", FontStyle: "B", Angle: 45,
+		Under: true, Layer: "Draft"})
+	pdf.AddWatermark(gofpdf.Watermark{Image: example.ImageFile("logo.png"), Opacity: 1,
+		Scale: 0.15, Position: "TR", Pages: []int{1}})
+	pdf.AddWatermark(gofpdf.Watermark{Text: "Printed copy", Color: gofpdf.NewRGBColor(200, 0, 0),
+		Opacity: 0.8, Scale: 0.3, Position: "B", PrintOnly: true})
+	page()
+	pdf.AddPageFormat("L", gofpdf.SizeType{Wd: 148, Ht: 210})
+	pdf.MultiCell(0, 5, lorem(), "", "J", false)
+
+	fileStr := example.Filename("Fpdf_AddWatermark")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_AddWatermark.pdf
+}
+
+// TestExampleFpdf_ImageTIFF demonstrates the placement of TIFF images,
+// including each page of a multi-page scan.
+func TestExampleFpdf_ImageTIFF(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+	pdf.Image(example.ImageFile("golang-gopher.tiff"), 10, 10, 40, 0, false, "", 0, "")
+	pdf.Text(60, 30, "golang-gopher.tiff")
+	fileStr := example.ImageFile("scans.tiff")
+	n := pdf.ImageTIFFPages(fileStr)
+	if n != 9 {
+		t.Fatalf("Expected 9 pages, got %d", n)
+	}
+	// Pages 8 and 9 are coded in two dimensions in several strips
+	for p := 1; p <= n; p++ {
+		x, y := 10+float64((p-1)/5)*100, 60+float64((p-1)%5)*30
+		pdf.ImageOptions(fileStr, x, y, 40, 0, false, gofpdf.ImageOptions{Page: p}, 0, "")
+		pdf.Text(x+50, y+10, fmt.Sprintf("scans.tiff, page %d", p))
+	}
+	fileStr = example.Filename("Fpdf_ImageTIFF")
+
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_ImageTIFF.pdf
+}
+
+// TestExampleFpdf_RegisterGoImage demonstrates the placement of images built
+// in memory with the image package, without encoding them to a file first.
+func TestExampleFpdf_RegisterGoImage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+
+	// A disc fading out to the right over a transparent background
+	rect := image.Rect(0, 0, 200, 120)
+	rgba := image.NewNRGBA(rect)
+	gray := image.NewGray(rect)
+	cmyk := image.NewCMYK(rect)
+	pal := image.NewPaletted(rect, color.Palette{color.Transparent,
+		color.RGBA{200, 30, 30, 255}, color.RGBA{30, 30, 200, 255}})
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			dx, dy := x-100, y-60
+			in := dx*dx+dy*dy < 55*55
+			v := uint8(255 * x / rect.Dx())
+			gray.SetGray(x, y, color.Gray{Y: v})
+			cmyk.SetCMYK(x, y, color.CMYK{C: v, M: 255 - v, K: uint8(y)})
+			if in {
+				rgba.SetNRGBA(x, y, color.NRGBA{R: v, G: 120, B: 255 - v, A: 255 - v/2})
+				pal.SetColorIndex(x, y, uint8(1+(x/20)%2))
+			}
+		}
+	}
+	images := []struct {
+		name string
+		img  image.Image
+		opt  gofpdf.GoImageOptions
+	}{
+		{"RGBA with alpha", rgba, gofpdf.GoImageOptions{}},
+		{"RGBA with alpha, JPEG quality 50", rgba, gofpdf.GoImageOptions{JPEG: true, Quality: 50}},
+		{"Gray", gray, gofpdf.GoImageOptions{}},
+		{"Gray, JPEG", gray, gofpdf.GoImageOptions{JPEG: true}},
+		{"CMYK", cmyk, gofpdf.GoImageOptions{}},
+		{"Paletted with transparency", pal, gofpdf.GoImageOptions{}},
+	}
+	for j, im := range images {
+		_, err = pdf.RegisterGoImage(im.name, im.img, im.opt)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		x, y := 10+float64(j%2)*95, 15+float64(j/2)*75
+		pdf.Text(x, y-3, im.name)
+		pdf.Image(im.name, x, y, 90, 0, false, "", 0, "")
+	}
+	fileStr := example.Filename("Fpdf_RegisterGoImage")
+
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_RegisterGoImage.pdf
+}
+
+// TestExampleFpdf_ImageWebPJPX demonstrates the placement of WebP images,
+// which are decoded, and of JPEG 2000 images, which are embedded as they are.
+func TestExampleFpdf_ImageWebPJPX(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+	// The lossless WebP image keeps the transparency of its alpha channel
+	pdf.SetFillColor(255, 230, 180)
+	pdf.Rect(10, 10, 60, 50, "F")
+	pdf.Image(example.ImageFile("logo.webp"), 15, 15, 50, 0, false, "", 0, "")
+	pdf.Text(80, 35, "logo.webp")
+	pdf.Image(example.ImageFile("logo.jp2"), 15, 70, 50, 0, false, "", 0, "")
+	pdf.Text(80, 90, "logo.jp2")
+	// A bare codestream, read through a reader with its type given
+	fl, err := os.Open(example.ImageFile("logo-gray.j2k"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer fl.Close()
+	pdf.RegisterImageOptionsReader("gray", gofpdf.ImageOptions{ImageType: pdf.ImageTypeFromMime("image/jp2")}, fl)
+	pdf.Image("gray", 15, 115, 50, 0, false, "", 0, "")
+	pdf.Text(80, 135, "logo-gray.j2k")
+	fileStr := example.Filename("Fpdf_ImageWebPJPX")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_ImageWebPJPX.pdf
+}
+
+// TestExampleFpdf_SetImageDownsampling demonstrates the downsampling of
+// images to the resolution of the size they are printed at.
+func TestExampleFpdf_SetImageDownsampling(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetImageDownsampling(&gofpdf.DownsampleOptions{MaxDpi: 150, JPEGQuality: 80})
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+
+	// A large picture printed 3 cm wide
+	rect := image.Rect(0, 0, 2000, 1200)
+	photo := image.NewRGBA(rect)
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			photo.Set(x, y, color.RGBA{uint8(x * 255 / rect.Dx()), uint8(y * 255 / rect.Dy()), uint8((x ^ y) & 0xff), 255})
+		}
+	}
+	if _, err = pdf.RegisterGoImage("photo", photo, gofpdf.GoImageOptions{}); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.Image("photo", 10, 10, 30, 0, false, "", 0, "")
+	pdf.Text(50, 20, "2000 x 1200 pixels, printed at 150 dpi")
+	// The transparency is resampled along with the colors
+	pdf.Image(example.ImageFile("logo.png"), 10, 40, 15, 0, false, "", 0, "")
+	pdf.Text(50, 50, "logo.png, printed at 150 dpi")
+	// Options of an image replace those of the document
+	pdf.ImageOptions(example.ImageFile("logo.jpg"), 10, 60, 30, 0, false,
+		gofpdf.ImageOptions{Downsample: &gofpdf.DownsampleOptions{MaxDpi: 72, Filter: gofpdf.ResampleBox, Gray: true}}, 0, "")
+	pdf.Text(50, 70, "logo.jpg, printed in gray at 72 dpi")
+	fileStr := example.Filename("Fpdf_SetImageDownsampling")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_SetImageDownsampling.pdf
+}
+
+// TestExampleFpdf_ImageOrientation demonstrates the placement of photos
+// stored sideways, shown upright from their EXIF orientation, and of images
+// with an ICC profile.
+func TestExampleFpdf_ImageOrientation(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+	pdf.Image(example.ImageFile("logo.jpg"), 10, 10, 40, 0, false, "", 0, "")
+	pdf.Text(60, 25, "logo.jpg")
+	// The pixels are stored rotated a quarter turn, with orientation 6
+	fileStr := example.ImageFile("logo-rotated.jpg")
+	info, err := pdf.RegisterImageOptions(fileStr, gofpdf.ImageOptions{})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if info.Width() < info.Height() {
+		t.Fatalf("Expected a landscape image, got %.0f by %.0f", info.Width(), info.Height())
+	}
+	pdf.Image(fileStr, 10, 45, 40, 0, false, "", 0, "")
+	pdf.Text(60, 60, "logo-rotated.jpg, with an sRGB profile")
+	pdf.Image(example.ImageFile("logo-icc.png"), 10, 80, 40, 0, false, "", 0, "")
+	pdf.Text(60, 95, "logo-icc.png, with an sRGB profile")
+	fileStr = example.Filename("Fpdf_ImageOrientation")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_ImageOrientation.pdf
+}
+
+// TestExampleFpdf_ClipImage demonstrates soft masks made from gray images,
+// applied to an image with ImageOptions.MaskImage and to any drawing with
+// ClipImage().
+func TestExampleFpdf_ClipImage(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+
+	// A vignette, white at the center and fading to black at the edges
+	rect := image.Rect(0, 0, 200, 140)
+	vignette := image.NewGray(rect)
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			dx, dy := float64(x-100)/100, float64(y-70)/70
+			v := 1.6 * (1 - math.Sqrt(dx*dx+dy*dy))
+			vignette.SetGray(x, y, color.Gray{uint8(255 * math.Max(0, math.Min(1, v)))})
+		}
+	}
+	if _, err = pdf.RegisterGoImage("vignette", vignette, gofpdf.GoImageOptions{}); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFillColor(255, 230, 180)
+	pdf.Rect(10, 10, 190, 60, "F")
+	pdf.ImageOptions(example.ImageFile("logo.jpg"), 20, 15, 70, 0, false,
+		gofpdf.ImageOptions{MaskImage: "vignette"}, 0, "")
+	pdf.Text(110, 40, "logo.jpg with a vignette mask")
+
+	// Drawing seen through the luminosity of an image
+	err = pdf.ClipImage("vignette", 20, 80, 70, 49, gofpdf.ImageOptions{})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.LinearGradient(20, 80, 70, 49, 20, 60, 200, 250, 120, 40, 0, 0, 1, 0)
+	pdf.ClipEnd()
+	pdf.Text(110, 105, "gradient clipped by the vignette")
+
+	pdf.ClipImage(example.ImageFile("logo-gray.png"), 20, 140, 70, 0, gofpdf.ImageOptions{})
+	for j := 0; j < 7; j++ {
+		pdf.SetFillColor(40*j, 100, 255-30*j)
+		pdf.Rect(20+10*float64(j), 140, 10, 50, "F")
+	}
+	pdf.ClipEnd()
+	pdf.Text(110, 165, "stripes clipped by logo-gray.png")
+	fileStr := example.Filename("Fpdf_ClipImage")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_ClipImage.pdf
+}
+
+// TestExampleFpdf_ContentDeduplication demonstrates that images and fonts
+// registered more than once, under different names or from different
+// sources, are embedded once in the document.
+func TestExampleFpdf_ContentDeduplication(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", example.FontDir())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+
+	// The same TrueType file under two family names
+	pdf.AddUTF8Font("dejavu", "", "DejaVuSansCondensed.ttf")
+	buf, err := ioutil.ReadFile(example.FontFile("DejaVuSansCondensed.ttf"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddUTF8FontFromBytes("body", "", buf)
+	pdf.AddFont("Calligrapher", "", "calligra.json")
+	pdf.AddFont("Script", "", "calligra.json")
+
+	pdf.SetFont("dejavu", "", 14)
+	pdf.Text(20, 20, "Family dejavu: ∑ Ωμέγα")
+	pdf.SetFont("body", "", 14)
+	pdf.Text(20, 30, "Family body: ∑ Ωμέγα")
+	pdf.SetFont("Calligrapher", "", 18)
+	pdf.Text(20, 45, "Family Calligrapher")
+	pdf.SetFont("Script", "", 18)
+	pdf.Text(20, 58, "Family Script")
+
+	// The same image from a file, from a reader and from a template
+	fileName := example.ImageFile("logo.png")
+	pdf.Image(fileName, 20, 70, 40, 0, false, "", 0, "")
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: "png"}, file)
+	file.Close()
+	pdf.ImageOptions("logo", 80, 70, 40, 0, false, gofpdf.ImageOptions{}, 0, "")
+	tpl, err := pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		tpl.Image(fileName, 140, 70, 40, 0, false, "", 0, "")
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.UseTemplate(tpl)
+	pdf.SetFont("dejavu", "", 11)
+	pdf.Text(20, 110, "One font file, one font program and one image are embedded")
+	fileStr := example.Filename("Fpdf_ContentDeduplication")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_ContentDeduplication.pdf
+}
+
+// TestExampleFpdf_AddTilingPattern demonstrates the filling and the stroking of
+// shapes with repeated motifs and hatches.
+func TestExampleFpdf_AddTilingPattern(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+
+	// Hatches for a chart printed in black and white
+	pdf.SetDrawColor(0, 0, 0)
+	hatches := []gofpdf.PatternID{
+		pdf.AddHatchPattern(gofpdf.HatchDiagonal, 2, 0.3),
+		pdf.AddHatchPattern(gofpdf.HatchCross, 2.5, 0.3),
+		pdf.AddHatchPattern(gofpdf.HatchDots, 1.5, 0.6),
+	}
+	pdf.SetLineWidth(0.3)
+	pdf.Line(20, 90, 120, 90)
+	for j, value := range []float64{55, 70, 35} {
+		pdf.SetFillPattern(hatches[j])
+		pdf.Rect(30+float64(j)*30, 90-value, 20, value, "FD")
+	}
+
+	// A motif repeated over a background, with a small gap between cells
+	motif := pdf.AddTilingPattern(8, 8, 10, 10, func(tpl *gofpdf.Tpl) {
+		tpl.SetFillColor(250, 200, 80)
+		tpl.Circle(4, 4, 3, "F")
+		tpl.SetFillColor(200, 60, 40)
+		tpl.Rect(3, 3, 2, 2, "F")
+	})
+	pdf.SetFillPattern(motif)
+	pdf.Circle(165, 55, 30, "F")
+	pdf.Polygon([]gofpdf.PointType{{X: 20, Y: 110}, {X: 70, Y: 100}, {X: 60, Y: 140}, {X: 25, Y: 135}}, "F")
+	pdf.MoveTo(90, 105)
+	pdf.CurveTo(140, 95, 130, 140)
+	pdf.LineTo(95, 135)
+	pdf.ClosePath()
+	pdf.DrawPath("F")
+
+	// Cell backgrounds and strokes
+	pdf.SetFillPattern(hatches[1])
+	pdf.SetXY(20, 150)
+	pdf.CellFormat(80, 12, "Cell with a cross hatch background", "1", 1, "C", true, 0, "")
+	pdf.SetDrawPattern(motif)
+	pdf.SetLineWidth(6)
+	pdf.Line(120, 156, 190, 156)
+	fileStr := example.Filename("Fpdf_AddTilingPattern")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_AddTilingPattern.pdf
+}
+
+// TestExampleFpdf_NewLinearGradient demonstrates gradients with several color
+// stops, transparency and extension options, used to fill any shape, and a
+// mesh gradient.
+func TestExampleFpdf_NewLinearGradient(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 11)
+
+	// A four-stop gradient as the fill color of shapes
+	brand := gofpdf.NewLinearGradient(10, 0, 200, 0).
+		AddStop(0, 20, 40, 120).
+		AddStop(0.35, 40, 160, 200).
+		AddStop(0.7, 250, 200, 60).
+		AddStop(1, 220, 60, 60)
+	pdf.SetFillGradient(brand)
+	pdf.Rect(10, 10, 60, 30, "F")
+	pdf.Circle(105, 25, 15, "F")
+	pdf.MoveTo(140, 40)
+	pdf.CurveTo(150, 0, 200, 10)
+	pdf.LineTo(200, 40)
+	pdf.ClosePath()
+	pdf.DrawPath("F")
+	pdf.SetXY(10, 45)
+	pdf.CellFormat(190, 10, "Cell background with the same gradient", "", 1, "C", true, 0, "")
+
+	// A radial gradient that is not extended, filling an ellipse
+	pdf.ClipEllipse(55, 90, 45, 25, false)
+	pdf.DrawGradient(gofpdf.NewRadialGradient(55, 90, 0, 55, 90, 30).
+		AddStop(0, 255, 255, 255).
+		AddStop(0.5, 255, 180, 0).
+		AddStop(1, 200, 0, 0).
+		SetExtend(true, false))
+	pdf.ClipEnd()
+	pdf.Ellipse(55, 90, 45, 25, 0, "D")
+
+	// Transparent stops over a drawing
+	pdf.SetTextColor(40, 40, 40)
+	pdf.SetFont("Arial", "B", 28)
+	pdf.Text(115, 95, "Fade out")
+	pdf.SetFont("Arial", "", 11)
+	pdf.ClipRoundedRect(110, 70, 90, 40, 5, false)
+	pdf.DrawGradient(gofpdf.NewLinearGradient(110, 0, 200, 0).
+		AddStopAlpha(0, 255, 255, 255, 0).
+		AddStopAlpha(0.4, 255, 255, 255, 0).
+		AddStopAlpha(1, 255, 255, 255, 1))
+	pdf.ClipEnd()
+	pdf.SetTextColor(0, 0, 0)
+
+	// A mesh of two patches sharing a curved edge
+	left := gofpdf.CoonsPatchRect(10, 130, 95, 80, [4]gofpdf.RGBType{
+		{R: 255, G: 80, B: 80}, {R: 255, G: 255, B: 120}, {R: 80, G: 200, B: 255}, {R: 120, G: 60, B: 200}})
+	right := gofpdf.CoonsPatchRect(105, 130, 95, 80, [4]gofpdf.RGBType{
+		{R: 255, G: 255, B: 120}, {R: 80, G: 220, B: 120}, {R: 255, G: 255, B: 255}, {R: 80, G: 200, B: 255}})
+	left.Points[4] = gofpdf.PointType{X: 135, Y: 155}
+	left.Points[5] = gofpdf.PointType{X: 75, Y: 185}
+	right.Points[11] = left.Points[4]
+	right.Points[10] = left.Points[5]
+	pdf.MeshGradient([]gofpdf.CoonsPatch{left, right})
+	pdf.Text(12, 220, "Coons patch mesh with a curved edge between its two patches")
+	fileStr := example.Filename("Fpdf_NewLinearGradient")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_NewLinearGradient.pdf
+}
+
+// ExampleNewGrid demonstrates the generation of graph grids.
+func TestExampleNewGrid(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A4", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+
+	gr := gofpdf.NewGrid(13, 10, 187, 130)
+	gr.TickmarksExtentX(0, 10, 4)
+	gr.TickmarksExtentY(0, 10, 3)
+	gr.Grid(pdf)
+
+	gr = gofpdf.NewGrid(13, 154, 187, 128)
+	gr.XLabelRotate = true
+	gr.TickmarksExtentX(0, 1, 12)
+	gr.XDiv = 5
+	gr.TickmarksContainY(0, 1.1)
+	gr.YDiv = 20
+	// Replace X label formatter with month abbreviation
+	gr.XTickStr = func(val float64, precision int) string {
+		return time.Month(math.Mod(val, 12) + 1).String()[0:3]
+	}
+	gr.Grid(pdf)
+	dot := func(x, y float64) {
+		pdf.Circle(gr.X(x), gr.Y(y), 0.5, "F")
+	}
+	pts := []float64{0.39, 0.457, 0.612, 0.84, 0.998, 1.037, 1.015, 0.918, 0.772, 0.659, 0.593, 0.164}
+	for month, val := range pts {
+		dot(float64(month)+0.5, val)
+	}
+	pdf.SetDrawColor(255, 64, 64)
+	pdf.SetAlpha(0.5, "Normal")
+	pdf.SetLineWidth(1.2)
+	gr.Plot(pdf, 0.5, 11.5, 50, func(x float64) float64 {
+		// http://www.xuru.org/rt/PR.asp
+		return 0.227 * math.Exp(-0.0373*x*x+0.471*x)
+	})
+	pdf.SetAlpha(1.0, "Normal")
+	pdf.SetXY(gr.X(0.5), gr.Y(1.35))
+	pdf.SetFontSize(14)
+	pdf.Write(0, "Solar energy (MWh) per month, 2016")
+	pdf.AddPage()
+
+	gr = gofpdf.NewGrid(13, 10, 187, 274)
+	gr.TickmarksContainX(2.3, 3.4)
+	gr.TickmarksContainY(10.4, 56.8)
+	gr.Grid(pdf)
+
+	fileStr := example.Filename("Fpdf_Grid")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_Grid.pdf
+}
+
+// ExampleFpdf_SetPageBox demonstrates the use of a page box
+func TestExampleFpdf_SetPageBox(t *testing.T) {
+	// pdfinfo (from http://www.xpdfreader.com) reports the following for this example:
+	// ~ pdfinfo -box pdf/Fpdf_PageBox.pdf
+	// Producer:       FPDF 1.7
+	// CreationDate:   Sat Jan  1 00:00:00 2000
 	// ModDate:   	   Sat Jan  1 00:00:00 2000
 	// Tagged:         no
 	// Form:           none
@@ -2863,6 +4565,97 @@ func TestExampleFpdf_SubWrite(t *testing.T) {
 	// Successfully generated pdf/Fpdf_SubWrite.pdf
 }
 
+// ExampleFpdf_Footnote demonstrates notes written at the foot of the pages,
+// including a long note continued on the next page, and endnotes.
+func TestExampleFpdf_Footnote(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 8, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+	pdf.SetFont("Times", "", 11)
+	for j := 0; j < 6; j++ {
+		pdf.Write(5, lorem())
+		pdf.Footnote(fmt.Sprintf("Note on paragraph %d. %s", j+1, loremList()[j%4]))
+		if j == 3 {
+			pdf.Write(5, " Second note.")
+			pdf.Footnote(lorem() + " " + lorem())
+		}
+		pdf.Ln(8)
+	}
+	pdf.MultiCell(0, 5, "This cell refers to note "+pdf.FootnoteMark("A note referred to from MultiCell.")+".", "", "L", false)
+
+	pdf.SetFootnoteStyle(gofpdf.FootnoteStyle{Endnotes: true})
+	pdf.AddPage()
+	pdf.Write(5, "A chapter with endnotes")
+	pdf.Footnote("First endnote.")
+	pdf.Write(5, " collected")
+	pdf.Footnote("Second endnote. " + lorem())
+	pdf.Write(5, " at the end.")
+	pdf.Ln(10)
+	pdf.SetFont("Times", "B", 11)
+	pdf.CellFormat(0, 6, "Notes", "", 1, "L", false, 0, "")
+	err = pdf.WriteEndnotes()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	fileStr := example.Filename("Fpdf_Footnote")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_Footnote.pdf
+}
+
+// ExampleFpdf_RenderIndex demonstrates an alphabetical index with subterms,
+// accented terms and merged page ranges.
+func TestExampleFpdf_RenderIndex(t *testing.T) {
+	pdf, err := gofpdf.New("P", "mm", "A5", "")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	pdf.AddUTF8Font("dejavu", "", example.FontFile("DejaVuSansCondensed.ttf"))
+	pdf.AddUTF8Font("dejavu", "B", example.FontFile("DejaVuSansCondensed-Bold.ttf"))
+	terms := []string{"Zebra", "élan", "Apple", "éclair", "Émile", "banana", "Œuvre", "cherry", "2D graphics", "Ångström"}
+	for j := 1; j <= 12; j++ {
+		pdf.AddPage()
+		pdf.SetFont("dejavu", "", 11)
+		pdf.Write(5, fmt.Sprintf("Page %d. %s", j, lorem()))
+		pdf.IndexEntry(terms[j%len(terms)])
+		pdf.IndexEntry(terms[(j*3)%len(terms)], "usage")
+		if j >= 4 && j <= 7 {
+			pdf.IndexEntry("Range")
+		}
+		if j%2 == 0 {
+			pdf.IndexEntry("font", fmt.Sprintf("size %d", j))
+			pdf.IndexEntry("font")
+		}
+	}
+	pdf.AddPage()
+	pdf.SetFont("dejavu", "B", 14)
+	pdf.CellFormat(0, 10, "Index", "", 1, "L", false, 0, "")
+	pdf.SetFont("dejavu", "", 10)
+	err = pdf.RenderIndex(2)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	fileStr := example.Filename("Fpdf_RenderIndex")
+	err = pdf.OutputFileAndClose(fileStr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	// Output:
+	// Successfully generated pdf/Fpdf_RenderIndex.pdf
+}
+
 // ExampleFpdf_SetPage demomstrates the SetPage() method, allowing content
 // generation to be deferred until all pages have been added.
 func TestExampleFpdf_SetPage(t *testing.T) {
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// GoImageOptions holds the options of RegisterGoImage().
+//
+// JPEG encodes the RGB and gray images with the DCTDecode filter at the
+// given Quality, from 1 to 100 (jpeg.DefaultQuality if zero), instead of the
+// lossless FlateDecode filter. CMYK and paletted images are always encoded
+// losslessly, and so is the soft mask of images with transparency.
+//
+// Dpi is the resolution used to size the image when it is placed without a
+// width or a height, 72 if zero.
+type GoImageOptions struct {
+	JPEG    bool
+	Quality int
+	Dpi     float64
+}
+
+// RegisterGoImage registers the image img of the image package under the
+// name imgName, adding it to the PDF file but not adding it to the page. Use
+// Image() or ImageOptions() with the same name to add the image to the page.
+//
+// The pixels of img are written as they are, without going through an
+// encoded file. *image.Gray and *image.Gray16 images are written in the
+// DeviceGray color space, *image.CMYK ones in DeviceCMYK and
+// *image.Paletted ones as indexed colors. Other images are converted to
+// DeviceRGB. The alpha channel of images that are not opaque, including the
+// transparent colors of a palette, is kept as a soft mask.
+func (f *Fpdf) RegisterGoImage(imgName string, img image.Image, options GoImageOptions) (info *ImageInfoType, err error) {
+	err = f.err
+	if err != nil {
+		return
+	}
+	info, ok := f.images[imgName]
+	if ok {
+		return
+	}
+	if img == nil || img.Bounds().Empty() {
+		err = fmt.Errorf("image %s is empty", imgName)
+		f.err = err
+		return
+	}
+	info = f.newImageInfo()
+	if options.Dpi > 0 {
+		info.dpi = options.Dpi
+	}
+	if err = f.goimage(info, img, options); err != nil {
+		f.err = err
+		return
+	}
+	if info.i, err = generateImageID(info); err != nil {
+		f.err = err
+		return
+	}
+	f.images[imgName] = info
+	return
+}
+
+// goimage fills info with the pixels of img
+func (f *Fpdf) goimage(info *ImageInfoType, img image.Image, options GoImageOptions) error {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	info.w = float64(w)
+	info.h = float64(h)
+	info.bpc = 8
+	var data, alpha []byte
+	colors := 1
+	switch img := img.(type) {
+	case *image.Gray:
+		info.cs = "DeviceGray"
+		data = make([]byte, 0, w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			data = append(data, img.Pix[pos:pos+w]...)
+		}
+	case *image.Gray16:
+		info.cs = "DeviceGray"
+		info.bpc = 16
+		data = make([]byte, 0, 2*w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			data = append(data, img.Pix[pos:pos+2*w]...)
+		}
+		options.JPEG = false
+		if f.pdfVersion < "1.5" {
+			f.pdfVersion = "1.5"
+		}
+	case *image.CMYK:
+		info.cs = "DeviceCMYK"
+		colors = 4
+		data = make([]byte, 0, 4*w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			data = append(data, img.Pix[pos:pos+4*w]...)
+		}
+		options.JPEG = false
+	case *image.Paletted:
+		if len(img.Palette) == 0 {
+			return fmt.Errorf("image palette is empty")
+		}
+		info.cs = "Indexed"
+		info.pal = make([]byte, 0, 3*len(img.Palette))
+		opaque := true
+		for _, c := range img.Palette {
+			nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+			info.pal = append(info.pal, nc.R, nc.G, nc.B)
+			opaque = opaque && nc.A == 255
+		}
+		data = make([]byte, 0, w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pos := img.PixOffset(b.Min.X, y)
+			data = append(data, img.Pix[pos:pos+w]...)
+		}
+		if !opaque {
+			alpha = make([]byte, len(data))
+			for j, v := range data {
+				if int(v) < len(img.Palette) {
+					_, _, _, a := img.Palette[v].RGBA()
+					alpha[j] = uint8(a >> 8)
+				}
+			}
+		}
+		options.JPEG = false
+	default:
+		info.cs = "DeviceRGB"
+		colors = 3
+		data = make([]byte, 0, 3*w*h)
+		alpha = make([]byte, 0, w*h)
+		opaque := true
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				data = append(data, c.R, c.G, c.B)
+				alpha = append(alpha, c.A)
+				opaque = opaque && c.A == 255
+			}
+		}
+		if opaque {
+			alpha = nil
+		}
+	}
+	return f.encodeImage(info, data, alpha, colors, options)
+}
+
+// encodeImage sets the data of info, whose size, color space and depth are
+// set, to the samples of data with colors components, and its soft mask to
+// alpha, if not nil. Only the JPEG and Quality options are used.
+func (f *Fpdf) encodeImage(info *ImageInfoType, data, alpha []byte, colors int, options GoImageOptions) error {
+	w, h := info.storedSize()
+	if options.JPEG {
+		var src image.Image
+		if info.cs == "DeviceGray" {
+			src = &image.Gray{Pix: data, Stride: w, Rect: image.Rect(0, 0, w, h)}
+		} else {
+			rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+			for j := 0; j < w*h; j++ {
+				copy(rgba.Pix[4*j:], data[3*j:3*j+3])
+				rgba.Pix[4*j+3] = 255
+			}
+			src = rgba
+		}
+		quality := options.Quality
+		if quality <= 0 {
+			quality = jpeg.DefaultQuality
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: quality}); err != nil {
+			return err
+		}
+		info.f = "DCTDecode"
+		info.data = buf.Bytes()
+	} else {
+		rowLen := colors * w * info.bpc / 8
+		info.f = "FlateDecode"
+		info.dp = sprintf("/Predictor 15 /Colors %d /BitsPerComponent %d /Columns %d", colors, info.bpc, w)
+		info.data = sliceCompress(predictorRows(data, rowLen))
+	}
+	if alpha != nil {
+		info.smask = sliceCompress(predictorRows(alpha, w))
+		if f.pdfVersion < "1.4" {
+			f.pdfVersion = "1.4"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Gradient is a blending of colors, with any number of stops that may be
+// transparent, along a line or between two circles. It is made with
+// NewLinearGradient() or NewRadialGradient() and used with SetFillGradient()
+// or DrawGradient().
+type Gradient struct {
+	radial                 bool
+	x1, y1, r1, x2, y2, r2 float64
+	stops                  []gradientStop
+	extendStart, extendEnd bool
+}
+
+type gradientStop struct {
+	offset  float64
+	r, g, b int
+	alpha   float64
+}
+
+// NewLinearGradient returns a gradient blending colors along the line from
+// (x1, y1), where its offset is 0, to (x2, y2), where its offset is 1. The
+// coordinates are those of the page, in the unit of measure specified in
+// New(). The colors are blended perpendicularly to the line. Stops are added
+// with AddStop() and AddStopAlpha(). The colors of the first and the last
+// stops extend beyond the line unless SetExtend() is called.
+func NewLinearGradient(x1, y1, x2, y2 float64) *Gradient {
+	return &Gradient{x1: x1, y1: y1, x2: x2, y2: y2, extendStart: true, extendEnd: true}
+}
+
+// NewRadialGradient returns a gradient blending colors from the circle of
+// center (x1, y1) and radius r1, where its offset is 0, to the circle of
+// center (x2, y2) and radius r2, where its offset is 1. The values are those
+// of the page, in the unit of measure specified in New(). Stops are added with
+// AddStop() and AddStopAlpha(). The colors of the first and the last stops
+// extend beyond the circles unless SetExtend() is called.
+func NewRadialGradient(x1, y1, r1, x2, y2, r2 float64) *Gradient {
+	return &Gradient{radial: true, x1: x1, y1: y1, r1: r1, x2: x2, y2: y2, r2: r2,
+		extendStart: true, extendEnd: true}
+}
+
+// AddStop adds an opaque color to the gradient at offset, from 0 at its start
+// to 1 at its end. The color components range from 0 to 255. Stops may be
+// added in any order; two stops at the same offset make a sharp transition.
+// The gradient is returned for calls to be chained.
+func (gr *Gradient) AddStop(offset float64, r, g, b int) *Gradient {
+	return gr.AddStopAlpha(offset, r, g, b, 1)
+}
+
+// AddStopAlpha adds a color to the gradient at offset, as AddStop() does,
+// with an opacity ranging from 0 (transparent) to 1 (opaque).
+func (gr *Gradient) AddStopAlpha(offset float64, r, g, b int, alpha float64) *Gradient {
+	offset = math.Max(0, math.Min(1, offset))
+	alpha = math.Max(0, math.Min(1, alpha))
+	gr.stops = append(gr.stops, gradientStop{offset, r, g, b, alpha})
+	sort.SliceStable(gr.stops, func(i, j int) bool { return gr.stops[i].offset < gr.stops[j].offset })
+	return gr
+}
+
+// SetExtend sets whether the colors of the first and the last stops extend
+// beyond the start and the end of the gradient. Areas the gradient does not
+// cover are left unpainted. The gradient is returned for calls to be chained.
+func (gr *Gradient) SetExtend(start, end bool) *Gradient {
+	gr.extendStart = start
+	gr.extendEnd = end
+	return gr
+}
+
+func (gr *Gradient) hasAlpha() bool {
+	for _, s := range gr.stops {
+		if s.alpha < 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// function returns the function of the gradient, exponential between two
+// stops and stitching them together otherwise, with the values of the stops
+// given by value
+func (gr *Gradient) function(value func(s gradientStop) string) string {
+	stops := gr.stops
+	if stops[0].offset > 0 {
+		stops = append([]gradientStop{stops[0]}, stops...)
+		stops[0].offset = 0
+	}
+	if stops[len(stops)-1].offset < 1 {
+		last := stops[len(stops)-1]
+		last.offset = 1
+		stops = append(stops, last)
+	}
+	if len(stops) == 1 {
+		stops = append(stops, stops[0])
+	}
+	exponential := func(s1, s2 gradientStop) string {
+		return sprintf("<</FunctionType 2 /Domain [0 1] /C0 [%s] /C1 [%s] /N 1>>", value(s1), value(s2))
+	}
+	if len(stops) == 2 {
+		return exponential(stops[0], stops[1])
+	}
+	var fns, bounds, encode []string
+	for j := 1; j < len(stops); j++ {
+		fns = append(fns, exponential(stops[j-1], stops[j]))
+		encode = append(encode, "0 1")
+		if j < len(stops)-1 {
+			bounds = append(bounds, sprintf("%.5f", stops[j].offset))
+		}
+	}
+	return sprintf("<</FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s]>>",
+		strings.Join(fns, " "), strings.Join(bounds, " "), strings.Join(encode, " "))
+}
+
+// addGradient registers the shading of gr, of its colors or, if alpha is
+// true, of its opacity as shades of gray, and returns its position in the
+// gradient list
+func (f *Fpdf) addGradient(gr *Gradient, alpha bool) int {
+	sh := gradientType{
+		tp:        2,
+		x1:        gr.x1 * f.k,
+		y1:        (f.h - gr.y1) * f.k,
+		x2:        gr.x2 * f.k,
+		y2:        (f.h - gr.y2) * f.k,
+		extendStr: sprintf("%t %t", gr.extendStart, gr.extendEnd),
+		gray:      alpha,
+	}
+	if gr.radial {
+		sh.tp = 3
+		sh.r0 = gr.r1 * f.k
+		sh.r = gr.r2 * f.k
+	}
+	if alpha {
+		sh.fnStr = gr.function(func(s gradientStop) string { return sprintf("%.3f", s.alpha) })
+	} else {
+		sh.fnStr = gr.function(func(s gradientStop) string {
+			return rgbColorValue(s.r, s.g, s.b, "", "").str
+		})
+	}
+	return f.addShading(sh)
+}
+
+// addShading returns the position of sh in the gradient list, where it is
+// added unless already present
+func (f *Fpdf) addShading(sh gradientType) int {
+	for j := 1; j < len(f.gradientList); j++ {
+		if f.gradientList[j] == sh {
+			return j
+		}
+	}
+	f.gradientList = append(f.gradientList, sh)
+	return len(f.gradientList) - 1
+}
+
+func (f *Fpdf) gradientOk(gr *Gradient) bool {
+	if f.err != nil {
+		return false
+	}
+	if gr == nil || len(gr.stops) == 0 {
+		f.err = fmt.Errorf("gradient has no stops")
+		return false
+	}
+	return true
+}
+
+// SetFillGradient sets the gradient gr as the current fill color. It is used
+// by all the shapes that are filled, whatever their path, and by the cell
+// backgrounds, until another fill color is set. The opacity of the stops is
+// not applied: DrawGradient() within a clipping operation renders it.
+//
+// The NewLinearGradient() example demonstrates this method.
+func (f *Fpdf) SetFillGradient(gr *Gradient) {
+	if !f.gradientOk(gr) {
+		return
+	}
+	pt := patternType{shading: f.addGradient(gr, false)}
+	id := 0
+	for j := 1; j < len(f.patterns) && id == 0; j++ {
+		if f.patterns[j].shading == pt.shading {
+			id = j
+		}
+	}
+	if id == 0 {
+		f.patterns = append(f.patterns, pt)
+		id = len(f.patterns) - 1
+	}
+	f.color.fill.mode = colorModePattern
+	f.color.fill.str = sprintf("/Pattern cs /P%d scn", id)
+	f.colorFlag = f.color.fill.str != f.color.text.str
+	if f.page > 0 {
+		f.out(f.color.fill.str)
+	}
+}
+
+// DrawGradient paints the gradient gr, with the opacity of its stops, over
+// the current clipping area, which is the whole page outside of clipping
+// operations. Areas the gradient does not cover when it is not extended are
+// left unchanged. Any path is filled with a gradient when this method is
+// called between ClipPolygon(), ClipEllipse() or any other clipping
+// method, and ClipEnd().
+//
+// The NewLinearGradient() example demonstrates this method.
+func (f *Fpdf) DrawGradient(gr *Gradient) {
+	if !f.gradientOk(gr) {
+		return
+	}
+	pos := f.addGradient(gr, false)
+	if !gr.hasAlpha() {
+		f.outf("/Sh%d sh", pos)
+		return
+	}
+	f.softMasks = append(f.softMasks, softMaskType{
+		shading: f.addGradient(gr, true),
+		bbox:    [4]float64{0, 0, f.w * f.k, f.h * f.k},
+	})
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	f.outf("q /SM%d gs /Sh%d sh Q", len(f.softMasks)-1, pos)
+}
+
+// CoonsPatch is a patch of a mesh gradient, the edges of which are cubic
+// Bézier curves. Points holds the 12 control points of the edges, in the
+// unit of measure specified in New(), following them around the patch: the
+// corners are Points[0], Points[3], Points[6] and Points[9], each edge having
+// its two inner control points between its corners. Colors holds the colors
+// of the four corners, in the same order.
+type CoonsPatch struct {
+	Points [12]PointType
+	Colors [4]RGBType
+}
+
+// CoonsPatchRect returns a patch with straight edges that covers the
+// rectangle of width w and height h, the upper left corner of which is at
+// (x, y). colors holds the colors of the upper left, upper right, lower right
+// and lower left corners.
+func CoonsPatchRect(x, y, w, h float64, colors [4]RGBType) (patch CoonsPatch) {
+	corners := [5]PointType{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}, {X: x, Y: y}}
+	for j := 0; j < 4; j++ {
+		a, b := corners[j], corners[j+1]
+		patch.Points[3*j] = a
+		patch.Points[3*j+1] = PointType{X: a.X + (b.X-a.X)/3, Y: a.Y + (b.Y-a.Y)/3}
+		patch.Points[3*j+2] = PointType{X: a.X + 2*(b.X-a.X)/3, Y: a.Y + 2*(b.Y-a.Y)/3}
+	}
+	patch.Colors = colors
+	return
+}
+
+// MeshGradient paints a Coons patch mesh, in which the colors of the corners
+// of each patch are blended over its surface. Patches sharing edges make
+// smooth color transitions over arbitrary shapes. The painting is limited to
+// the patches and to the current clipping area.
+//
+// The NewLinearGradient() example demonstrates this method.
+func (f *Fpdf) MeshGradient(patches []CoonsPatch) {
+	if f.err != nil {
+		return
+	}
+	if len(patches) == 0 {
+		f.err = fmt.Errorf("mesh gradient has no patches")
+		return
+	}
+	// The coordinates are scaled to 16 bits within the bounds of the points
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, patch := range patches {
+		for _, pt := range patch.Points {
+			x, y := pt.X*f.k, (f.h-pt.Y)*f.k
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+	maxX = math.Max(maxX, minX+1)
+	maxY = math.Max(maxY, minY+1)
+	scale := func(v, lo, hi float64) uint16 {
+		return uint16(math.Round((v - lo) / (hi - lo) * 65535))
+	}
+	var buf bytes.Buffer
+	for _, patch := range patches {
+		buf.WriteByte(0)
+		for _, pt := range patch.Points {
+			binary.Write(&buf, binary.BigEndian, scale(pt.X*f.k, minX, maxX))
+			binary.Write(&buf, binary.BigEndian, scale((f.h-pt.Y)*f.k, minY, maxY))
+		}
+		for _, clr := range patch.Colors {
+			c := rgbColorValue(clr.R, clr.G, clr.B, "", "")
+			buf.Write([]byte{byte(c.ir), byte(c.ig), byte(c.ib)})
+		}
+	}
+	pos := f.addShading(gradientType{tp: 6, x1: minX, y1: minY, x2: maxX, y2: maxY, meshStr: buf.String()})
+	f.outf("/Sh%d sh", pos)
+}
+
+// putMesh writes the shading of a Coons patch mesh
+func (f *Fpdf) putMesh(gr gradientType) {
+	data := []byte(gr.meshStr)
+	filter := ""
+	if f.compress {
+		data = sliceCompress(data)
+		filter = "/Filter /FlateDecode "
+	}
+	f.newobj()
+	f.outf("<</ShadingType 6 /ColorSpace /%s /BitsPerCoordinate 16 /BitsPerComponent 8 /BitsPerFlag 8",
+		strIf(gr.gray, "DeviceGray", "DeviceRGB"))
+	f.outf("/Decode [%.5f %.5f %.5f %.5f 0 1 0 1 0 1] %s/Length %d>>", gr.x1, gr.x2, gr.y1, gr.y2, filter, len(data))
+	f.putstream(data)
+	f.out("endobj")
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// jpegMeta returns the EXIF orientation of the JPEG data, 1 if it has none,
+// its ICC profile, if any, and whether it has an Adobe APP14 marker, which
+// is found in the inverted CMYK images written by Adobe applications
+func jpegMeta(data []byte) (orientation int, icc []byte, adobe bool) {
+	orientation = 1
+	type iccChunk struct {
+		seq  int
+		data []byte
+	}
+	var chunks []iccChunk
+	iccCount := 0
+	pos := 2
+scan:
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte
+			pos++
+			continue
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd8:
+			pos += 2
+			continue
+		case marker == 0xd9 || marker == 0xda:
+			// The markers are in the header, before the scans
+			break scan
+		}
+		n := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if n < 2 || pos+2+n > len(data) {
+			break
+		}
+		seg := data[pos+4 : pos+2+n]
+		switch {
+		case marker == 0xe1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00":
+			if o := exifOrientation(seg[6:]); o >= 1 && o <= 8 {
+				orientation = o
+			}
+		case marker == 0xe2 && len(seg) > 14 && string(seg[:12]) == "ICC_PROFILE\x00":
+			// The profile may be split into numbered chunks
+			chunks = append(chunks, iccChunk{int(seg[12]), seg[14:]})
+			iccCount = int(seg[13])
+		case marker == 0xee && len(seg) >= 5 && string(seg[:5]) == "Adobe":
+			adobe = true
+		}
+		pos += 2 + n
+	}
+	if len(chunks) > 0 && len(chunks) == iccCount {
+		sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].seq < chunks[j].seq })
+		for _, c := range chunks {
+			icc = append(icc, c.data...)
+		}
+	}
+	return
+}
+
+// exifOrientation returns the Orientation tag of the first image file
+// directory of the TIFF structure of EXIF data, 0 if not found
+func exifOrientation(data []byte) int {
+	if len(data) < 8 {
+		return 0
+	}
+	var bo binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(bo.Uint32(data[4:]))
+	if ifd < 8 || ifd+2 > len(data) {
+		return 0
+	}
+	n := int(bo.Uint16(data[ifd:]))
+	for j := 0; j < n; j++ {
+		entry := ifd + 2 + 12*j
+		if entry+12 > len(data) {
+			break
+		}
+		if bo.Uint16(data[entry:]) == 0x0112 && bo.Uint16(data[entry+2:]) == 3 {
+			return int(bo.Uint16(data[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// iccComponents returns the number of color components of the ICC profile
+// icc, 0 if its color space is not gray, RGB or CMYK
+func iccComponents(icc []byte) int {
+	if len(icc) < 128 {
+		return 0
+	}
+	switch string(icc[16:20]) {
+	case "GRAY":
+		return 1
+	case "RGB ":
+		return 3
+	case "CMYK":
+		return 4
+	}
+	return 0
+}
+
+// setICC sets the ICC profile of info, whose color space is set, to icc
+// unless its number of color components differs
+func (info *ImageInfoType) setICC(icc []byte) {
+	colors := 3
+	switch info.cs {
+	case "DeviceGray":
+		colors = 1
+	case "DeviceCMYK":
+		colors = 4
+	}
+	if iccComponents(icc) == colors {
+		info.icc = icc
+	} else {
+		info.icc = nil
+	}
+}
+
+// imageOrientations holds, for each EXIF orientation, the transformation of
+// the unit square that shows upright an image whose pixels are stored
+// rotated or mirrored
+var imageOrientations = [9][6]float64{
+	{1, 0, 0, 1, 0, 0},
+	{1, 0, 0, 1, 0, 0},
+	{-1, 0, 0, 1, 1, 0},
+	{-1, 0, 0, -1, 1, 1},
+	{1, 0, 0, -1, 0, 1},
+	{0, -1, -1, 0, 1, 1},
+	{0, -1, 1, 0, 0, 1},
+	{0, 1, 1, 0, 0, 0},
+	{0, 1, -1, 0, 1, 0},
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// ImposeNUp places several pages side by side on each sheet
+	ImposeNUp = "nup"
+	// ImposeBooklet arranges the pages for a saddle-stitched booklet
+	ImposeBooklet = "booklet"
+	// ImposePoster splits each page, enlarged, across several sheets
+	ImposePoster = "poster"
+)
+
+// ImpositionLayout defines how Impose() lays out the pages of a document on
+// the sheets of the imposed document. Lengths are in the unit of measure of
+// the imposed document.
+type ImpositionLayout struct {
+	Kind       string   // ImposeNUp, ImposeBooklet or ImposePoster; ImposeNUp if empty
+	SheetSize  SizeType // Size of the sheets; the first page turned to fit the grid best for ImposeNUp, twice as wide for ImposeBooklet, A4 for ImposePoster if zero
+	Cols, Rows int      // Grid of the pages on a sheet for ImposeNUp, 2 by 1 if zero
+	Margin     float64  // Blank space along the edges of the sheets
+	Gutter     float64  // Room between the cells of the grid for ImposeNUp
+	Creep      float64  // Shift towards the spine of the pages of the innermost sheet for ImposeBooklet
+	Overlap    float64  // Width of the strip repeated on adjacent tiles for ImposePoster
+	Scale      float64  // Enlargement of the pages for ImposePoster, 1 if zero
+	CropMarks  bool     // Draw crop marks at the corners of the pages, or of the tiles, and the fold marks of booklets
+	MarkLength float64  // Length of the marks, 5 mm if zero
+	AutoRotate bool     // Turn the pages by a quarter turn when they fit larger in the cells for ImposeNUp, the sheets when fewer are needed for ImposePoster
+}
+
+// imposedPageType is a page of the imposed document, captured as a template
+type imposedPageType struct {
+	tpl      Template
+	wPt, hPt float64
+}
+
+// Impose returns a new document with the pages of src laid out on sheets for
+// printing, as defined by layout:
+//
+//	ImposeNUp      the pages fill, in reading order, a grid of cells on each
+//	               sheet, scaled to fit and centered in their cell
+//	ImposeBooklet  the pages, padded with blank pages to a multiple of four,
+//	               are paired on both sides of the sheets so that the folded
+//	               and nested sheets read in order. The pages of the inner
+//	               sheets are shifted towards the spine by up to Creep to
+//	               compensate the thickness of the paper.
+//	ImposePoster   each page, enlarged by Scale, is split into tiles that
+//	               overlap by Overlap, one per sheet
+//
+// Each page of src is used as a form XObject, as with CreateTemplate(), so
+// that its content is written only once whatever the number of sheets it
+// appears on. The page being written by src is finished first, as Close()
+// would do, and src must not be changed afterwards. Aliases and page
+// references are replaced with the page numbers of src. Links, annotations
+// and bookmarks of src are not carried over.
+//
+// The imposed document uses the unit of measure of src. Pages can be added
+// to it, and it is output as any other document.
+func Impose(src *Fpdf, layout ImpositionLayout) (out *Fpdf, err error) {
+	switch {
+	case src.err != nil:
+		return nil, src.err
+	case src.state == 3:
+		return nil, fmt.Errorf("the imposed document is already closed")
+	case src.page == 0:
+		return nil, fmt.Errorf("the imposed document has no page")
+	}
+	if err = src.closepages(); err != nil {
+		return nil, err
+	}
+	src.replacePagePlaceholders()
+	if out, err = New("P", src.unitStr, "", ""); err != nil {
+		return nil, err
+	}
+	out.SetCompression(src.compress)
+	if err = out.mergeFonts(src); err != nil {
+		return nil, err
+	}
+	out.mergeImages(src)
+	renumber := out.mergeResources(src)
+	if src.pdfVersion > out.pdfVersion {
+		out.pdfVersion = src.pdfVersion
+	}
+	pages := out.imposedPages(src, renumber)
+	if layout.MarkLength == 0 {
+		layout.MarkLength = 5 * 72 / 25.4 / out.k
+	}
+	switch layout.Kind {
+	case ImposeNUp, "":
+		out.imposeNUp(pages, layout)
+	case ImposeBooklet:
+		out.imposeBooklet(pages, layout)
+	case ImposePoster:
+		out.imposePoster(pages, layout)
+	default:
+		out.SetErrorf("unknown imposition %s", layout.Kind)
+	}
+	if out.err != nil {
+		return nil, out.err
+	}
+	return out, nil
+}
+
+// imposedPages captures the pages of src as templates of the document. The
+// returned list is one-based like the page list of src.
+func (f *Fpdf) imposedPages(src *Fpdf, renumber func(op []byte) []byte) []imposedPageType {
+	images := make(map[string]*ImageInfoType, len(f.images))
+	for key, info := range f.images {
+		images[key] = info
+	}
+	var ids []string
+	for id := range src.templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	templates := make([]Template, len(ids))
+	for j, id := range ids {
+		templates[j] = src.templates[id]
+	}
+	pages := make([]imposedPageType, len(src.pages))
+	for n := 1; n < len(src.pages); n++ {
+		g := src.pageGeometryOf(n)
+		content := numberedResourceRe.ReplaceAllFunc(src.pages[n].Bytes(), renumber)
+		tpl := &FpdfTpl{size: SizeType{g.w, g.h}, bytes: [][]byte{nil, content},
+			images: images, templates: templates, page: 1}
+		f.templates[tpl.ID()] = tpl
+		pages[n] = imposedPageType{tpl, g.wPt, g.hPt}
+	}
+	return pages
+}
+
+// fitImposedPage returns the scale at which page p fits the cell (cw, ch)
+// and the quarter turns that make it largest
+func (f *Fpdf) fitImposedPage(p imposedPageType, cw, ch float64, autoRotate bool) (s float64, rot int) {
+	w, h := p.wPt/f.k, p.hPt/f.k
+	s = math.Min(cw/w, ch/h)
+	if autoRotate {
+		if turned := math.Min(cw/h, ch/w); turned > s {
+			return turned, 1
+		}
+	}
+	return
+}
+
+// placeImposedPage draws page p scaled by s and turned by rot quarter turns
+// counterclockwise, with the top left corner of its turned box at (x, y),
+// clipped to the rectangle (cx, cy, cw, ch) of the current page
+func (f *Fpdf) placeImposedPage(p imposedPageType, x, y, s float64, rot int, cx, cy, cw, ch float64) {
+	k := f.k
+	w, h := p.wPt*s, p.hPt*s
+	if rot%2 == 1 {
+		w, h = h, w
+	}
+	xPt, yPt := x*k, (f.h-y)*k-h
+	m := [6]float64{s, 0, 0, s, xPt, yPt}
+	switch rot {
+	case 1:
+		m = [6]float64{0, s, -s, 0, xPt + w, yPt}
+	case 2:
+		m = [6]float64{-s, 0, 0, -s, xPt + w, yPt + h}
+	case 3:
+		m = [6]float64{0, -s, s, 0, xPt, yPt + h}
+	}
+	f.outf("q %.2f %.2f %.2f %.2f re W n", cx*k, (f.h-cy-ch)*k, cw*k, ch*k)
+	f.outf("%.5f %.5f %.5f %.5f %.2f %.2f cm /TPL%s Do Q", m[0], m[1], m[2], m[3], m[4], m[5], p.tpl.ID())
+}
+
+// putImpositionMarks draws crop marks at the corners of the rectangle (x, y,
+// w, h) and, when spine is true, fold marks at the middle of its top and
+// bottom edges
+func (f *Fpdf) putImpositionMarks(x, y, w, h, length float64, spine bool) {
+	k := f.k
+	gap := length / 2
+	f.out("q 0 G 0.25 w")
+	line := func(x1, y1, x2, y2 float64) {
+		f.outf("%.2f %.2f m %.2f %.2f l S", x1*k, (f.h-y1)*k, x2*k, (f.h-y2)*k)
+	}
+	for _, cx := range []float64{x, x + w} {
+		dx := 1.0
+		if cx == x {
+			dx = -1
+		}
+		for _, cy := range []float64{y, y + h} {
+			dy := 1.0
+			if cy == y {
+				dy = -1
+			}
+			line(cx+dx*gap, cy, cx+dx*(gap+length), cy)
+			line(cx, cy+dy*gap, cx, cy+dy*(gap+length))
+		}
+	}
+	if spine {
+		line(x+w/2, y-gap, x+w/2, y-gap-length)
+		line(x+w/2, y+h+gap, x+w/2, y+h+gap+length)
+	}
+	f.out("Q")
+}
+
+// imposeNUp lays out pages on a grid of cells
+func (f *Fpdf) imposeNUp(pages []imposedPageType, l ImpositionLayout) {
+	cols, rows := l.Cols, l.Rows
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 2, 1
+	}
+	cells := func(sheet SizeType) (cw, ch float64) {
+		cw = (sheet.Wd - 2*l.Margin - float64(cols-1)*l.Gutter) / float64(cols)
+		ch = (sheet.Ht - 2*l.Margin - float64(rows-1)*l.Gutter) / float64(rows)
+		return
+	}
+	sheet := l.SheetSize
+	if sheet.Wd == 0 || sheet.Ht == 0 {
+		// The first page, turned if its pages are larger so
+		sheet = SizeType{pages[1].wPt / f.k, pages[1].hPt / f.k}
+		cw, ch := cells(sheet)
+		s, _ := f.fitImposedPage(pages[1], cw, ch, l.AutoRotate)
+		turned := SizeType{sheet.Ht, sheet.Wd}
+		cw, ch = cells(turned)
+		if ts, _ := f.fitImposedPage(pages[1], cw, ch, l.AutoRotate); ts > s {
+			sheet = turned
+		}
+	}
+	cw, ch := cells(sheet)
+	if cw <= 0 || ch <= 0 {
+		f.SetErrorf("the sheets are too small for a grid of %d by %d pages", cols, rows)
+		return
+	}
+	for n := 1; n < len(pages) && f.err == nil; n++ {
+		j := (n - 1) % (cols * rows)
+		if j == 0 {
+			f.SetError(f.AddPageFormat("P", sheet))
+		}
+		cx := l.Margin + float64(j%cols)*(cw+l.Gutter)
+		cy := l.Margin + float64(j/cols)*(ch+l.Gutter)
+		s, rot := f.fitImposedPage(pages[n], cw, ch, l.AutoRotate)
+		w, h := pages[n].wPt*s/f.k, pages[n].hPt*s/f.k
+		if rot == 1 {
+			w, h = h, w
+		}
+		x, y := cx+(cw-w)/2, cy+(ch-h)/2
+		f.placeImposedPage(pages[n], x, y, s, rot, cx, cy, cw, ch)
+		if l.CropMarks {
+			f.putImpositionMarks(x, y, w, h, l.MarkLength, false)
+		}
+	}
+}
+
+// imposeBooklet lays out pages for a saddle-stitched booklet
+func (f *Fpdf) imposeBooklet(pages []imposedPageType, l ImpositionLayout) {
+	count := len(pages) - 1
+	padded := (count + 3) / 4 * 4
+	sheets := padded / 4
+	sheet := l.SheetSize
+	if sheet.Wd == 0 || sheet.Ht == 0 {
+		sheet = SizeType{2 * pages[1].wPt / f.k, pages[1].hPt / f.k}
+	}
+	half := sheet.Wd / 2
+	cw, ch := half-l.Margin, sheet.Ht-2*l.Margin
+	if cw <= 0 || ch <= 0 {
+		f.SetErrorf("the sheets are too small for a booklet")
+		return
+	}
+	for i := 0; i < sheets && f.err == nil; i++ {
+		creep := 0.0
+		if sheets > 1 {
+			creep = l.Creep * float64(i) / float64(sheets-1)
+		}
+		// Front then back of the sheet, the outer page on the left
+		for _, pair := range [2][2]int{{padded - 2*i, 2*i + 1}, {2*i + 2, padded - 2*i - 1}} {
+			f.SetError(f.AddPageFormat("P", sheet))
+			trimWd, trimHt := 0.0, 0.0
+			for j, num := range pair {
+				p := pages[1]
+				if num <= count {
+					p = pages[num]
+				}
+				s, _ := f.fitImposedPage(p, cw, ch, false)
+				w, h := p.wPt*s/f.k, p.hPt*s/f.k
+				trimWd, trimHt = math.Max(trimWd, w), math.Max(trimHt, h)
+				if num > count {
+					continue
+				}
+				y := l.Margin + (ch-h)/2
+				if j == 0 {
+					f.placeImposedPage(p, half-w+creep, y, s, 0, l.Margin, l.Margin, cw, ch)
+				} else {
+					f.placeImposedPage(p, half-creep, y, s, 0, half, l.Margin, cw, ch)
+				}
+			}
+			if l.CropMarks {
+				f.putImpositionMarks(half-trimWd, l.Margin+(ch-trimHt)/2, 2*trimWd, trimHt, l.MarkLength, true)
+			}
+		}
+	}
+}
+
+// imposePoster splits each page into tiles
+func (f *Fpdf) imposePoster(pages []imposedPageType, l ImpositionLayout) {
+	scale := l.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	sheet := l.SheetSize
+	if sheet.Wd == 0 || sheet.Ht == 0 {
+		sheet = SizeType{595.28 / f.k, 841.89 / f.k}
+	}
+	for n := 1; n < len(pages) && f.err == nil; n++ {
+		p := pages[n]
+		w, h := p.wPt*scale/f.k, p.hPt*scale/f.k
+		tiles := func(sheet SizeType) (cols, rows int) {
+			stepWd := sheet.Wd - 2*l.Margin - l.Overlap
+			stepHt := sheet.Ht - 2*l.Margin - l.Overlap
+			if stepWd <= 0 || stepHt <= 0 {
+				return 0, 0
+			}
+			cols = int(math.Max(1, math.Ceil((w-l.Overlap)/stepWd-1e-9)))
+			rows = int(math.Max(1, math.Ceil((h-l.Overlap)/stepHt-1e-9)))
+			return
+		}
+		sh := sheet
+		cols, rows := tiles(sh)
+		if cols == 0 {
+			f.SetErrorf("the overlap of the tiles is larger than the sheets")
+			return
+		}
+		if l.AutoRotate {
+			turned := SizeType{sheet.Ht, sheet.Wd}
+			if tc, tr := tiles(turned); tc*tr < cols*rows {
+				sh, cols, rows = turned, tc, tr
+			}
+		}
+		aw, ah := sh.Wd-2*l.Margin, sh.Ht-2*l.Margin
+		for r := 0; r < rows && f.err == nil; r++ {
+			for c := 0; c < cols && f.err == nil; c++ {
+				f.SetError(f.AddPageFormat("P", sh))
+				x := l.Margin - float64(c)*(aw-l.Overlap)
+				y := l.Margin - float64(r)*(ah-l.Overlap)
+				f.placeImposedPage(p, x, y, scale, 0, l.Margin, l.Margin, aw, ah)
+				if l.CropMarks {
+					f.putImpositionMarks(l.Margin, l.Margin, aw, ah, l.MarkLength, false)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type indexEntryType struct {
+	path []string // term followed by its subterms
+	link int      // internal link to the position of the entry
+}
+
+// indexNodeType is a term of the index together with its subterms
+type indexNodeType struct {
+	text  string
+	links []int // links of the entries recorded for this exact term
+	subs  []*indexNodeType
+}
+
+// IndexEntry records an entry of the alphabetical index pointing to the
+// current page. term is the main heading of the entry; optional subterms
+// place it below that heading, for instance IndexEntry("font", "embedding").
+// The index itself is written by RenderIndex() once all the entries have been
+// recorded.
+func (f *Fpdf) IndexEntry(term string, subterm ...string) {
+	if f.page == 0 || term == "" {
+		return
+	}
+	link := f.AddLink()
+	f.SetLink(link, -1, -1)
+	path := append([]string{term}, subterm...)
+	f.index = append(f.index, indexEntryType{path: path, link: link})
+}
+
+// RenderIndex writes the alphabetical index at the current position, in the
+// specified number of columns, using the current font. Pages are added as
+// needed.
+//
+// Terms are sorted with a simplified Unicode collation, by their letters
+// first, then by their accents, then by their case, and grouped below their
+// initial letter. Subterms are indented below their term. The pages of a term
+// are listed after it, consecutive pages being merged into ranges such as
+// "12–14, 20". Each page number is a link to the first entry recorded on that
+// page.
+//
+// Since page numbers can change when pages are inserted, for instance by
+// InsertTOC(), they are only final once the whole document has been
+// written.
+func (f *Fpdf) RenderIndex(columns int) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	if f.page == 0 || f.fontFamily == "" {
+		f.err = fmt.Errorf("a page and a font must be set before rendering the index")
+		return f.err
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	root := f.indexTree()
+	ps := f.pageState()
+	lh := 1.25 * f.fontSize
+	gap := 2 * f.fontSize
+	colWd := (f.w - f.lMargin - f.rMargin - float64(columns-1)*gap) / float64(columns)
+	indent := 1.5 * f.fontSize
+	col := 0
+	top := f.y
+	dash := "–"
+	if !f.isCurrentUTF8 {
+		dash = "\x96"
+	}
+
+	// nextColumn moves to the top of the next column, on a new page if needed
+	nextColumn := func() error {
+		col++
+		if col == columns {
+			col = 0
+			if err := f.AddPageFormat(f.curOrientation, f.curPageSize); err != nil {
+				return err
+			}
+			top = f.y
+		}
+		f.y = top
+		return nil
+	}
+	// room makes sure that lines of height lh fit in the current column
+	room := func(lines int) error {
+		if f.y+float64(lines)*lh > f.pageBreakTrigger {
+			return nextColumn()
+		}
+		return nil
+	}
+	colX := func() float64 {
+		return f.lMargin + float64(col)*(colWd+gap)
+	}
+
+	// writeNode writes a term, its pages and its subterms
+	var writeNode func(nd *indexNodeType, level int) error
+	writeNode = func(nd *indexNodeType, level int) error {
+		x0 := colX() + float64(level)*indent
+		lines := f.splitCellText(nd.text, colWd-float64(level)*indent)
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		if err := room(len(lines)); err != nil {
+			return err
+		}
+		x0 = colX() + float64(level)*indent
+		var x float64
+		for j, line := range lines {
+			if j > 0 {
+				f.y += lh
+			}
+			f.Text(x0, f.y+.5*lh+.3*f.fontSize, line)
+			x = x0 + f.GetStringWidth(line)
+		}
+		for j, r := range f.indexRanges(nd.links) {
+			label := f.pageLabel(r.first)
+			if r.last > r.first {
+				label += dash + f.pageLabel(r.last)
+			}
+			sep := ", "
+			if j == 0 {
+				sep = "  "
+			}
+			wd := f.GetStringWidth(label)
+			if x+f.GetStringWidth(sep)+wd > colX()+colWd {
+				// Continuation lines are indented below the term
+				f.y += lh
+				if err := room(1); err != nil {
+					return err
+				}
+				x = colX() + float64(level+1)*indent
+			} else {
+				f.Text(x, f.y+.5*lh+.3*f.fontSize, sep)
+				x += f.GetStringWidth(sep)
+			}
+			f.Text(x, f.y+.5*lh+.3*f.fontSize, label)
+			f.Link(x, f.y, wd, lh, r.link)
+			x += wd
+		}
+		f.y += lh
+		for _, sub := range nd.subs {
+			if err := writeNode(sub, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	letter := ""
+	for _, nd := range root.subs {
+		if err != nil {
+			break
+		}
+		if l := indexLetter(nd.text); l != letter {
+			letter = l
+			// Keep the letter with the first term below it
+			if f.y > top {
+				f.y += .5 * lh
+			}
+			if err = room(2); err != nil {
+				break
+			}
+			if err = f.SetFont(ps.familyStr, "B", ps.fontSizePt); err != nil {
+				break
+			}
+			f.Text(colX(), f.y+.5*lh+.3*f.fontSize, letter)
+			f.y += lh
+			if err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt); err != nil {
+				break
+			}
+		}
+		err = writeNode(nd, 0)
+	}
+	f.x = f.lMargin
+	f.SetError(err)
+	return
+}
+
+// indexTree returns the recorded index entries as a sorted tree
+func (f *Fpdf) indexTree() *indexNodeType {
+	entries := make([]indexEntryType, len(f.index))
+	copy(entries, f.index)
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].path, entries[j].path
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if c := collate(a[k], b[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return len(a) < len(b)
+	})
+	root := &indexNodeType{}
+	for _, e := range entries {
+		nd := root
+		for _, text := range e.path {
+			last := len(nd.subs) - 1
+			if last < 0 || nd.subs[last].text != text {
+				nd.subs = append(nd.subs, &indexNodeType{text: text})
+				last++
+			}
+			nd = nd.subs[last]
+		}
+		nd.links = append(nd.links, e.link)
+	}
+	return root
+}
+
+type indexRangeType struct {
+	first, last int // page numbers
+	link        int // link to the first entry on page first
+}
+
+// indexRanges returns the pages targeted by links, consecutive pages being
+// merged
+func (f *Fpdf) indexRanges(links []int) (ranges []indexRangeType) {
+	pageLink := make(map[int]int)
+	var pages []int
+	for _, link := range links {
+		p := f.links[link].page
+		if _, ok := pageLink[p]; !ok {
+			pageLink[p] = link
+			pages = append(pages, p)
+		}
+	}
+	sort.Ints(pages)
+	for _, p := range pages {
+		n := len(ranges)
+		if n > 0 && ranges[n-1].last == p-1 {
+			ranges[n-1].last = p
+		} else {
+			ranges = append(ranges, indexRangeType{p, p, pageLink[p]})
+		}
+	}
+	return
+}
+
+// accentFold maps the lower case letters that carry an accent, or that are
+// variants of other letters, to their base letters. Letters given with
+// combining marks instead are folded without it.
+var accentFold = map[rune]string{}
+
+func init() {
+	for base, list := range map[string]string{
+		// Latin
+		"a": "àáâãäåāăąǎ", "c": "çćĉċč", "d": "ďđð", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő", "r": "ŕŗř",
+		"s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ",
+		"z": "źżž", "ae": "æ", "oe": "œ", "ss": "ß", "th": "þ",
+		// Greek
+		"α": "ά", "ε": "έ", "η": "ή", "ι": "ίϊΐ", "ο": "ό", "σ": "ς",
+		"υ": "ύϋΰ", "ω": "ώ",
+		// Cyrillic
+		"е": "ё", "і": "ї",
+	} {
+		for _, r := range list {
+			accentFold[r] = base
+		}
+	}
+}
+
+// cp1252Letters maps the letters of code page 1252 that differ from
+// ISO-8859-1
+var cp1252Letters = map[byte]rune{
+	0x8a: 'Š', 0x8c: 'Œ', 0x8e: 'Ž', 0x9a: 'š', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// Weights of the secondary and tertiary levels of a collation key. They sort
+// before any letter, and levelSeparator before any weight, so that a key
+// that is the prefix of another one sorts first.
+const (
+	levelSeparator = '\x00'
+	noAccentWeight = '\x01'
+	lowerWeight    = '\x01'
+	upperWeight    = '\x02'
+)
+
+// collationRunes returns the characters of s. Strings that are not valid
+// UTF-8 are taken as encoded with code page 1252, as for the core fonts.
+func collationRunes(s string) []rune {
+	runes := []rune(s)
+	if !utf8.ValidString(s) {
+		runes = runes[:0]
+		for j := 0; j < len(s); j++ {
+			r, ok := cp1252Letters[s[j]]
+			if !ok {
+				r = rune(s[j])
+			}
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// primaryKey returns the first level of the collation key of s: letters are
+// lowered and stripped of their accents, and punctuation is ignored
+func primaryKey(s string) string {
+	var b strings.Builder
+	for _, r := range collationRunes(s) {
+		r = unicode.ToLower(r)
+		if base, ok := accentFold[r]; ok {
+			b.WriteString(base)
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// collationKey returns a sort key of s in the manner of the Unicode
+// collation algorithm, simplified. Its three levels are compared in turn:
+// the letters regardless of accents and case, as returned by primaryKey(),
+// then the accents, then the case. Thus "cote" sorts before "côte", itself
+// before "Côte", and all of them before "coter". The accents are those of
+// accentFold and the combining marks; the letters of a script sort in the
+// order of their code points.
+func collationKey(s string) string {
+	var accents, cases strings.Builder
+	for _, r := range collationRunes(s) {
+		lower := unicode.ToLower(r)
+		_, folded := accentFold[lower]
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining mark, the accent of the previous letter
+			accents.WriteRune(r)
+		case folded || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r):
+			if folded {
+				accents.WriteRune(lower)
+			} else {
+				accents.WriteRune(noAccentWeight)
+			}
+			if lower != r {
+				cases.WriteRune(upperWeight)
+			} else {
+				cases.WriteRune(lowerWeight)
+			}
+		}
+	}
+	return primaryKey(s) + string(levelSeparator) + accents.String() + string(levelSeparator) + cases.String()
+}
+
+// collate compares a and b by their collation keys and, when they are equal,
+// by their code points so that the order is total
+func collate(a, b string) int {
+	if c := strings.Compare(collationKey(a), collationKey(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// indexLetter returns the heading letter under which term is listed, "#" for
+// terms that do not begin with a letter
+func indexLetter(term string) string {
+	key := []rune(primaryKey(term))
+	if len(key) > 0 && unicode.IsLetter(key[0]) {
+		return strings.ToUpper(string(key[0]))
+	}
+	return "#"
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errJPXData = errors.New("invalid or truncated JPEG 2000 data")
+
+// parsejpx extracts info from JPEG 2000 data, either a JP2 file or a bare
+// codestream. The data is embedded as it is with the JPXDecode filter; only
+// its header is read.
+func (f *Fpdf) parsejpx(r io.Reader) (info *ImageInfoType) {
+	info = f.newImageInfo()
+	buf, err := bufferFromReader(r)
+	if err != nil {
+		f.err = err
+		return
+	}
+	info.data = buf.Bytes()
+	if err = jpxHeader(info, info.data); err != nil {
+		f.err = err
+		return
+	}
+	info.f = "JPXDecode"
+	if f.pdfVersion < "1.5" {
+		f.pdfVersion = "1.5"
+	}
+	return
+}
+
+// jpxHeader sets the size, color space and depth of info from the boxes of
+// a JP2 file or from the SIZ marker segment of a codestream
+func jpxHeader(info *ImageInfoType, data []byte) (err error) {
+	if len(data) >= 4 && data[0] == 0xff && data[1] == 0x4f && data[2] == 0xff && data[3] == 0x51 {
+		var comps int
+		if comps, err = jpxSIZ(info, data[4:]); err != nil {
+			return
+		}
+		return jpxColorSpace(info, comps, 0)
+	}
+	if len(data) < 12 || string(data[4:8]) != "jP  " {
+		return errors.New("JPEG 2000 image has neither a JP2 signature nor a codestream marker")
+	}
+	comps, enumCS := 0, 0
+	found := false
+	err = jpxBoxes(data, func(typ string, box []byte) error {
+		switch typ {
+		case "jp2h":
+			return jpxBoxes(box, func(typ string, box []byte) error {
+				switch typ {
+				case "ihdr":
+					if len(box) < 14 {
+						return errJPXData
+					}
+					info.h = float64(binary.BigEndian.Uint32(box[0:]))
+					info.w = float64(binary.BigEndian.Uint32(box[4:]))
+					comps = int(binary.BigEndian.Uint16(box[8:]))
+					info.bpc = int(box[10]&0x7f) + 1
+				case "colr":
+					if len(box) >= 7 && box[0] == 1 && enumCS == 0 {
+						enumCS = int(binary.BigEndian.Uint32(box[3:]))
+					}
+				case "pclr":
+					// The palette maps a component to its columns
+					if len(box) < 3 {
+						return errJPXData
+					}
+					comps = int(box[2])
+				case "cdef":
+					// Opacity channels are not colors
+					if len(box) < 2 {
+						return errJPXData
+					}
+					n := int(binary.BigEndian.Uint16(box))
+					for j := 0; j < n && 2+6*j+6 <= len(box); j++ {
+						if typ := binary.BigEndian.Uint16(box[2+6*j+2:]); typ == 1 || typ == 2 {
+							comps--
+						}
+					}
+				}
+				return nil
+			})
+		case "jp2c":
+			found = true
+			if len(box) < 4 || box[0] != 0xff || box[1] != 0x4f || box[2] != 0xff || box[3] != 0x51 {
+				return errJPXData
+			}
+			if info.bpc == 0 || info.bpc == 128 {
+				_, err := jpxSIZ(info, box[4:])
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if !found || info.w == 0 || info.h == 0 {
+		return errors.New("JPEG 2000 image has no codestream")
+	}
+	return jpxColorSpace(info, comps, enumCS)
+}
+
+// jpxBoxes calls fn with the type and the contents of each box of data
+func jpxBoxes(data []byte, fn func(typ string, box []byte) error) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		head := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return errJPXData
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			head = 16
+		}
+		if size < head || size > uint64(len(data)) {
+			return errJPXData
+		}
+		if err := fn(typ, data[head:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// jpxSIZ sets the size and depth of info from the SIZ marker segment data
+// and returns the number of components
+func jpxSIZ(info *ImageInfoType, data []byte) (comps int, err error) {
+	if len(data) < 41 {
+		return 0, errJPXData
+	}
+	be := binary.BigEndian
+	info.w = float64(be.Uint32(data[4:]) - be.Uint32(data[12:]))
+	info.h = float64(be.Uint32(data[8:]) - be.Uint32(data[16:]))
+	comps = int(be.Uint16(data[36:]))
+	info.bpc = int(data[38]&0x7f) + 1
+	return
+}
+
+// jpxColorSpace sets the color space of info given its number of color
+// components and the enumerated color space of the JP2 header, if any
+func jpxColorSpace(info *ImageInfoType, comps, enumCS int) error {
+	switch {
+	case enumCS == 17 || enumCS == 0 && comps == 1:
+		info.cs = "DeviceGray"
+	case enumCS == 16 || enumCS == 18 || enumCS == 0 && comps == 3:
+		info.cs = "DeviceRGB"
+	case enumCS == 12 || enumCS == 0 && comps == 4:
+		info.cs = "DeviceCMYK"
+	case comps == 1:
+		info.cs = "DeviceGray"
+	case comps == 3:
+		info.cs = "DeviceRGB"
+	case comps == 4:
+		info.cs = "DeviceCMYK"
+	default:
+		return fmt.Errorf("JPEG 2000 image has unsupported number of components (%d)", comps)
+	}
+	return nil
+}
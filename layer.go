@@ -20,9 +20,10 @@ package gofpdf
 // http://www.fpdf.org/en/script/script97.php
 
 type layerType struct {
-	name    string
-	visible bool
-	objNum  int // object number
+	name      string
+	visible   bool
+	printOnly bool // hidden on screen and printed, see AddWatermark()
+	objNum    int  // object number
 }
 
 type layerRecType struct {
@@ -87,7 +88,12 @@ func (f *Fpdf) layerPutLayers() {
 	for j, l := range f.layer.list {
 		f.newobj()
 		f.layer.list[j].objNum = f.n
-		f.outf("<</Type /OCG /Name %s>>", f.textstring(utf8toutf16(l.name)))
+		if l.printOnly {
+			f.outf("<</Type /OCG /Name %s /Usage <</Print <</PrintState /ON>> /View <</ViewState /OFF>>>>>>",
+				f.textstring(utf8toutf16(l.name)))
+		} else {
+			f.outf("<</Type /OCG /Name %s>>", f.textstring(utf8toutf16(l.name)))
+		}
 		f.out("endobj")
 	}
 }
@@ -107,13 +113,23 @@ func (f *Fpdf) layerPutCatalog() {
 	if len(f.layer.list) > 0 {
 		onStr := ""
 		offStr := ""
+		printStr := ""
 		for _, layer := range f.layer.list {
 			onStr += sprintf("%d 0 R ", layer.objNum)
-			if !layer.visible {
+			if !layer.visible || layer.printOnly {
 				offStr += sprintf("%d 0 R ", layer.objNum)
 			}
+			if layer.printOnly {
+				printStr += sprintf("%d 0 R ", layer.objNum)
+			}
+		}
+		// Print only layers are turned on for printing from their usage
+		asStr := ""
+		if printStr != "" {
+			asStr = sprintf(" /AS [<</Event /View /OCGs [%s] /Category [/View]>> <</Event /Print /OCGs [%s] /Category [/Print]>>]",
+				printStr, printStr)
 		}
-		f.outf("/OCProperties <</OCGs [%s] /D <</OFF [%s] /Order [%s]>>>>", onStr, offStr, onStr)
+		f.outf("/OCProperties <</OCGs [%s] /D <</OFF [%s] /Order [%s]%s>>>>", onStr, offStr, onStr, asStr)
 		if f.layer.openLayerPane {
 			f.out("/PageMode /UseOC")
 		}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListItem is an item of a list written by WriteList(), with its nested
+// items
+type ListItem struct {
+	Text  string     // Text of the item, wrapped with a hanging indent; "\n" breaks lines
+	Items []ListItem // Nested items, one level deeper
+}
+
+// ListLevelStyle defines the markers of the items of a level of a list.
+// Numbered levels use one of the page number styles NumberDecimal,
+// NumberLowerAlpha, NumberUpperAlpha, NumberLowerRoman and NumberUpperRoman,
+// for instance {NumberStyle: NumberLowerAlpha, Format: "%s)"} for a), b),
+// c)... Other levels are bulleted.
+type ListLevelStyle struct {
+	NumberStyle string  // Numbering style of the items, bullets if empty
+	Format      string  // Marker of numbered items, %s being replaced with the number, "%s." if empty
+	Outline     bool    // Prefix numbers with the numbers of the parent items, as in 1.2.3
+	Bullet      string  // Bullet written in the current font, such as "-" or "•" with a UTF-8 font; a drawn disc, circle or square if empty
+	BulletImage string  // Name of an image file, or of a registered image, used as bullet instead of Bullet
+	Indent      float64 // Room for the marker in user units, the Indent of the list if zero
+}
+
+// ListStyle defines the layout of a list written by WriteList().
+type ListStyle struct {
+	Levels     []ListLevelStyle // Styles of the levels, the last one being repeated for deeper levels; bullets if empty
+	Indent     float64          // Room for the markers in user units, twice the font size if zero
+	LineHeight float64          // Height of the lines in user units, 1.2 times the font size if zero
+	Spacing    float64          // Room between items in user units
+	Align      string           // Alignment of the text of the items as in MultiCell(), "L" if empty
+	Start      int              // Number of the first item, 1 if zero, to continue a list interrupted by other content
+}
+
+// listEntryType is an item of a flattened list
+type listEntryType struct {
+	level  int
+	style  ListLevelStyle
+	marker string // text of a numbered or glyph marker
+	text   string
+}
+
+// resolved returns style with its defaults
+func (style ListStyle) resolved(f *Fpdf) ListStyle {
+	if style.Indent == 0 {
+		style.Indent = 2 * f.fontSize
+	}
+	if style.LineHeight == 0 {
+		style.LineHeight = 1.2 * f.fontSize
+	}
+	if style.Align == "" {
+		style.Align = "L"
+	}
+	if style.Start == 0 {
+		style.Start = 1
+	}
+	return style
+}
+
+// level returns the style of the level lvl of the list
+func (style ListStyle) level(lvl int) (ls ListLevelStyle) {
+	if n := len(style.Levels); n > 0 {
+		if lvl >= n {
+			lvl = n - 1
+		}
+		ls = style.Levels[lvl]
+	}
+	if ls.Indent == 0 {
+		ls.Indent = style.Indent
+	}
+	if ls.Format == "" {
+		ls.Format = "%s."
+	}
+	return
+}
+
+// listEntries flattens items and computes their markers
+func listEntries(items []ListItem, style ListStyle) (entries []listEntryType, err error) {
+	var numbers []string // numbers of the parent items
+	var walk func(items []ListItem, lvl int) error
+	walk = func(items []ListItem, lvl int) error {
+		ls := style.level(lvl)
+		switch ls.NumberStyle {
+		case "", NumberDecimal, NumberUpperRoman, NumberLowerRoman, NumberUpperAlpha, NumberLowerAlpha:
+		default:
+			return fmt.Errorf("unknown list number style %q", ls.NumberStyle)
+		}
+		for j, item := range items {
+			e := listEntryType{level: lvl, style: ls, text: item.Text}
+			number := ""
+			if ls.NumberStyle != "" {
+				n := j + 1
+				if lvl == 0 {
+					n += style.Start - 1
+				}
+				number = formatPageNumber(n, ls.NumberStyle)
+				full := number
+				if ls.Outline {
+					for k := len(numbers) - 1; k >= 0 && numbers[k] != ""; k-- {
+						full = numbers[k] + "." + full
+					}
+				}
+				e.marker = strings.Replace(ls.Format, "%s", full, -1)
+			} else if ls.BulletImage == "" {
+				e.marker = ls.Bullet
+			}
+			entries = append(entries, e)
+			if len(item.Items) > 0 {
+				numbers = append(numbers, number)
+				if err := walk(item.Items, lvl+1); err != nil {
+					return err
+				}
+				numbers = numbers[:len(numbers)-1]
+			}
+		}
+		return nil
+	}
+	err = walk(items, 0)
+	return
+}
+
+// listIndent returns the offset of the text of entry e from the left of the
+// list
+func listIndent(e listEntryType, style ListStyle) (x float64) {
+	x = e.style.Indent
+	for lvl := 0; lvl < e.level; lvl++ {
+		x += style.level(lvl).Indent
+	}
+	return
+}
+
+// WriteList writes a list of items at the current position, up to the right
+// margin, and moves the current position below it.
+//
+// Each item is preceded by its marker: a number formatted as set by the
+// style of its level, such as "3.", "c)", "iii." or "1.2.3", or a bullet. The
+// text of the items is wrapped with MultiCell() and indented past the
+// marker. Nested items are indented one more level. Items flow across pages
+// like MultiCell() text does and keep their numbering; a marker is never
+// separated from the first line of its item.
+func (f *Fpdf) WriteList(items []ListItem, style ListStyle) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	if f.page == 0 || f.fontFamily == "" {
+		f.err = fmt.Errorf("a page and a font must be set before writing a list")
+		return f.err
+	}
+	x := f.x
+	err = f.writeList(items, style.resolved(f), x, f.w-f.rMargin-x)
+	f.x = x
+	f.SetError(err)
+	return
+}
+
+// writeList writes items at x, in width w
+func (f *Fpdf) writeList(items []ListItem, style ListStyle, x, w float64) (err error) {
+	entries, err := listEntries(items, style)
+	if err != nil {
+		return
+	}
+	lh := style.LineHeight
+	for j, e := range entries {
+		if j > 0 {
+			f.y += style.Spacing
+		}
+		if f.y+lh > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.acceptPageBreak() {
+			if err = f.AddPageFormat(f.curOrientation, f.curPageSize); err != nil {
+				return
+			}
+		}
+		tx := x + listIndent(e, style)
+		if err = f.listMarker(e, tx, f.y, lh); err != nil {
+			return
+		}
+		f.SetXY(tx, f.y)
+		if err = f.MultiCell(x+w-tx, lh, e.text, "", style.Align, false); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// listMarker writes the marker of e ending at x, on a line at y of height
+// lh
+func (f *Fpdf) listMarker(e listEntryType, x, y, lh float64) (err error) {
+	gap := 0.3 * f.fontSize
+	switch {
+	case e.marker != "":
+		f.Text(x-gap-f.GetStringWidth(e.marker), y+.5*lh+.3*f.fontSize, e.marker)
+	case e.style.BulletImage != "":
+		var info *ImageInfoType
+		if info, err = f.RegisterImageOptions(e.style.BulletImage, ImageOptions{}); err != nil {
+			return
+		}
+		ht := 0.6 * f.fontSize
+		wd := ht * info.Width() / info.Height()
+		err = f.ImageOptions(e.style.BulletImage, x-gap-wd, y+(lh-ht)/2, wd, ht, false, ImageOptions{}, 0, "")
+	default:
+		// Discs, circles and squares by level, as drawn by Bullet()
+		r := 0.15 * f.fontSize
+		cx, cy := x-gap-r, y+.5*lh
+		ps := f.pageState()
+		f.SetFillColor(f.GetDrawColor())
+		if lvl := e.level % 4; lvl < 2 {
+			f.Bullet(cx, cy, r, lvl)
+		} else {
+			f.Bullet(cx-r, cy-r, 2*r, lvl)
+		}
+		f.color.fill = ps.fill
+		f.colorFlag = ps.colorFlag
+		f.out(ps.fill.str)
+	}
+	return
+}
+
+// ListBlock is a content block of a table cell holding a list written like
+// WriteList() does.
+type ListBlock struct {
+	Items []ListItem
+	Style ListStyle
+}
+
+// ContentHeight implements CellContent.
+func (lb *ListBlock) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	style := lb.Style.resolved(f)
+	entries, err := listEntries(lb.Items, style)
+	if err != nil {
+		return
+	}
+	for j, e := range entries {
+		if j > 0 {
+			h += style.Spacing
+		}
+		lines := len(f.splitCellText(e.text, w-listIndent(e, style)))
+		if lines == 0 {
+			lines = 1
+		}
+		h += float64(lines) * style.LineHeight
+	}
+	return
+}
+
+// DrawContent implements CellContent.
+func (lb *ListBlock) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	x0, y0 := f.x, f.y
+	f.y = y
+	err = f.writeList(lb.Items, lb.Style.resolved(f), x, w)
+	f.x, f.y = x0, y0
+	return
+}
@@ -0,0 +1,431 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberedResourceRe matches the operators of page content that refer to
+// the resources numbered by their position in the document: blend modes,
+// soft masks, tiling patterns, gradients, spot colors and layers. Fonts,
+// images and templates are named after a hash of their content and need no
+// renaming.
+var numberedResourceRe = regexp.MustCompile(`/(GS|Sh|CS|OC|SM|P)(\d+) (gs|sh|cs|CS|BDC|scn|SCN)\b`)
+
+// replaceOperators returns a copy of content in which the text matched by re
+// is replaced by fn, except within the string operands, whose text is left
+// as it is
+func replaceOperators(content []byte, re *regexp.Regexp, fn func([]byte) []byte) []byte {
+	out := make([]byte, 0, len(content))
+	start := 0 // start of the text that follows the last string
+	for j := 0; j < len(content); j++ {
+		if content[j] != '(' {
+			continue
+		}
+		out = append(out, re.ReplaceAllFunc(content[start:j], fn)...)
+		// Strings may hold balanced parentheses and escaped ones
+		depth, k := 0, j
+		for ; k < len(content); k++ {
+			switch content[k] {
+			case '\\':
+				k++
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if k >= len(content) {
+			k = len(content) - 1
+		}
+		out = append(out, content[j:k+1]...)
+		start, j = k+1, k
+	}
+	return append(out, re.ReplaceAllFunc(content[start:], fn)...)
+}
+
+// Merge appends the pages of each document of srcs to dst, in order, as
+// AppendDocument() does.
+func Merge(dst *Fpdf, srcs ...*Fpdf) error {
+	for _, src := range srcs {
+		if err := dst.AppendDocument(src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendDocument appends the pages of src after the last page of the
+// document, for instance to gather chapters built concurrently by separate
+// Fpdf instances. The page being written by src is finished first, as
+// Close() would do, and src must not be changed afterwards.
+//
+// Each page keeps its size and orientation. Fonts are shared by key when
+// they are the same, images by content. Internal links, bookmarks, table of
+// contents and index entries, sections, layers, spot colors, gradients and
+// blend modes are carried over and renumbered. Anchors are merged by name,
+// so that the page references returned by PageRef() in a document can point
+// to an anchor set in another one. Aliases such as the one of AliasNbPages()
+// are replaced when the document is output, so they count the pages of the
+// whole document.
+//
+// The watermarks of the document are drawn on the appended pages, over those
+// of src. The page being written, if any, remains so and still receives its footer
+// when the next page is added or the document is closed. Endnotes of src
+// that were not written with WriteEndnotes() are dropped.
+func (f *Fpdf) AppendDocument(src *Fpdf) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	switch {
+	case src == f:
+		f.err = fmt.Errorf("a document cannot be appended to itself")
+	case src.err != nil:
+		f.err = fmt.Errorf("appended document: %v", src.err)
+	case src.state == 3:
+		f.err = fmt.Errorf("the appended document is already closed")
+	}
+	if f.err != nil || src.page == 0 {
+		return f.err
+	}
+	if err = src.closepages(); err != nil {
+		f.SetError(err)
+		return
+	}
+	if f.state == 0 {
+		f.open()
+	}
+	if err = f.mergeFonts(src); err != nil {
+		f.SetError(err)
+		return
+	}
+	f.mergeImages(src)
+	renumber := f.mergeResources(src)
+	linkMap, pageRefs := f.mergeLinks(src)
+	offset := f.PageCount()
+	for n := 1; n < len(src.pages); n++ {
+		content := replaceOperators(src.pages[n].Bytes(), numberedResourceRe, renumber)
+		if pageRefs != nil {
+			content = []byte(pageRefs.Replace(string(content)))
+		}
+		// The default page size of the document may differ from the one of src
+		g, sg := f.pageGeometryOf(len(f.pages)), src.pageGeometryOf(n)
+		f.pages = append(f.pages, bytes.NewBuffer(content))
+		if g.wPt != sg.wPt || g.hPt != sg.hPt {
+			f.pageSizes[len(f.pages)-1] = SizeType{sg.wPt, sg.hPt}
+		}
+		boxes := make(map[string]PageBox)
+		for t, pb := range src.pageBoxes[n] {
+			boxes[t] = pb
+		}
+		f.pageBoxes[len(f.pages)-1] = boxes
+		links := make([]linkType, len(src.pageLinks[n]))
+		for j, pl := range src.pageLinks[n] {
+			if pl.link > 0 {
+				pl.link = linkMap[pl.link]
+			}
+			links[j] = pl
+		}
+		f.pageLinks = append(f.pageLinks, links)
+		f.pageAttachments = append(f.pageAttachments, append([]annotationAttach(nil), src.pageAttachments[n]...))
+	}
+	f.mergeStructure(src, offset, linkMap)
+	for n := offset + 1; n <= f.PageCount(); n++ {
+		f.stampPage(n, f.watermarks...)
+	}
+	if f.page == 0 {
+		f.selectPage(f.PageCount())
+	}
+	return
+}
+
+// mergeFonts adds the fonts of src, and the files they need, to the document
+func (f *Fpdf) mergeFonts(src *Fpdf) error {
+	for key, font := range src.fonts {
+		found := false
+		for _, own := range f.fonts {
+			if own.i == font.i {
+				// Same font: the glyphs used by src are needed too
+				if own.usedRunes != nil {
+					for r := range font.usedRunes {
+						own.usedRunes[r] = r
+					}
+				}
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if _, ok := f.fonts[key]; ok {
+			// Another font was added with the same family and style
+			key += "#" + font.i
+		}
+		if font.usedRunes != nil {
+			runes := make(map[int]int, len(font.usedRunes))
+			for r := range font.usedRunes {
+				runes[r] = r
+			}
+			font.usedRunes = runes
+		}
+		if font.DiffN > 0 {
+			diff := src.diffs[font.DiffN-1]
+			font.DiffN = 0
+			for j, own := range f.diffs {
+				if own == diff {
+					font.DiffN = j + 1
+				}
+			}
+			if font.DiffN == 0 {
+				f.diffs = append(f.diffs, diff)
+				font.DiffN = len(f.diffs)
+			}
+		}
+		font.N = 0
+		f.fonts[key] = font
+	}
+	for file, info := range src.fontFiles {
+		if _, ok := f.fontFiles[file]; ok {
+			continue
+		}
+		if info.fontType != "UTF8" && !info.embedded {
+			// The file may not be found from the font location of the document
+			content, err := src.loadFontFile(file)
+			if err != nil {
+				return err
+			}
+			info.content = content
+			info.embedded = true
+		}
+		info.n = 0
+		f.fontFiles[file] = info
+	}
+	return nil
+}
+
+// mergeImages adds the images of src to the document. Images with the same
+// content are written once when the document is output.
+func (f *Fpdf) mergeImages(src *Fpdf) {
+	for key, info := range src.images {
+		if own, ok := f.images[key]; ok {
+			if own.i == info.i {
+				continue
+			}
+			key = info.i
+		}
+		image := *info
+		f.images[key] = &image
+	}
+	for id, tpl := range src.templates {
+		if _, ok := f.templates[id]; !ok {
+			f.templates[id] = tpl
+		}
+	}
+	for key, obj := range src.importedObjs {
+		if _, ok := f.importedObjs[key]; !ok {
+			f.importedObjs[key] = obj
+		}
+	}
+	for key, pos := range src.importedObjPos {
+		if _, ok := f.importedObjPos[key]; !ok {
+			f.importedObjPos[key] = pos
+		}
+	}
+	for name, id := range src.importedTplObjs {
+		if _, ok := f.importedTplObjs[name]; !ok {
+			f.importedTplObjs[name] = id
+		}
+	}
+}
+
+// mergeResources adds the blend modes, soft masks, tiling patterns,
+// gradients, spot colors and layers of src to the document and returns the
+// function that renumbers the operators of the content of src that refer to
+// them
+func (f *Fpdf) mergeResources(src *Fpdf) func(op []byte) []byte {
+	numbers := map[string]map[int]int{"GS": {}, "Sh": {}, "CS": {}, "OC": {}, "SM": {}, "P": {}}
+	for j := 1; j < len(src.blendList); j++ {
+		bm := src.blendList[j]
+		keyStr := sprintf("%s %s", bm.fillStr, bm.modeStr)
+		pos, ok := f.blendMap[keyStr]
+		if !ok {
+			pos = len(f.blendList)
+			f.blendList = append(f.blendList, blendModeType{bm.strokeStr, bm.fillStr, bm.modeStr, 0})
+			f.blendMap[keyStr] = pos
+		}
+		numbers["GS"][j] = pos
+	}
+	for j := 1; j < len(src.gradientList); j++ {
+		gr := src.gradientList[j]
+		gr.objNum = 0
+		pos := 0
+		for k := 1; k < len(f.gradientList) && pos == 0; k++ {
+			if f.gradientList[k] == gr {
+				pos = k
+			}
+		}
+		if pos == 0 {
+			pos = len(f.gradientList)
+			f.gradientList = append(f.gradientList, gr)
+		}
+		numbers["Sh"][j] = pos
+	}
+	for j := 1; j < len(src.softMasks); j++ {
+		sm := src.softMasks[j]
+		sm.objNum = 0
+		sm.shading = numbers["Sh"][sm.shading]
+		numbers["SM"][j] = len(f.softMasks)
+		f.softMasks = append(f.softMasks, sm)
+	}
+	for j := 1; j < len(src.patterns); j++ {
+		pt := src.patterns[j]
+		pt.objNum = 0
+		pt.shading = numbers["Sh"][pt.shading]
+		numbers["P"][j] = len(f.patterns)
+		f.patterns = append(f.patterns, pt)
+	}
+	for name, clr := range src.spotColorMap {
+		// Spot colors are inks: the same name is the same ink
+		own, ok := f.spotColorMap[name]
+		if !ok {
+			own = spotColorType{id: len(f.spotColorMap) + 1, val: clr.val}
+			f.spotColorMap[name] = own
+		}
+		numbers["CS"][clr.id] = own.id
+	}
+	for j, layer := range src.layer.list {
+		pos := -1
+		for k, own := range f.layer.list {
+			if own.name == layer.name {
+				pos = k
+				break
+			}
+		}
+		if pos < 0 {
+			pos = len(f.layer.list)
+			f.layer.list = append(f.layer.list, layerType{name: layer.name, visible: layer.visible})
+		}
+		numbers["OC"][j] = pos
+	}
+	return func(op []byte) []byte {
+		m := numberedResourceRe.FindSubmatch(op)
+		num, err := strconv.Atoi(string(m[2]))
+		if err != nil {
+			return op
+		}
+		if pos, ok := numbers[string(m[1])][num]; ok {
+			return []byte(sprintf("/%s%d %s", m[1], pos, m[3]))
+		}
+		return op
+	}
+}
+
+// mergeLinks adds the internal links and the anchors of src to the document.
+// It returns the new number of each link of src, the links of its pages not
+// being renumbered yet, and the replacer of the page reference placeholders
+// of src, nil if there are none.
+func (f *Fpdf) mergeLinks(src *Fpdf) (linkMap []int, pageRefs *strings.Replacer) {
+	offset := f.PageCount()
+	moved := func(l intLinkType) intLinkType {
+		if l.page > 0 {
+			l.page += offset
+		}
+		l.y = l.y * src.k / f.k
+		return l
+	}
+	linkMap = make([]int, len(src.links))
+	for _, a := range src.anchors {
+		link := f.anchors[f.anchorID(a.name)].link
+		if src.links[a.link].page > 0 && f.links[link].page == 0 {
+			f.links[link] = moved(src.links[a.link])
+		}
+		linkMap[a.link] = link
+	}
+	for j := 1; j < len(src.links); j++ {
+		if linkMap[j] == 0 {
+			f.links = append(f.links, moved(src.links[j]))
+			linkMap[j] = len(f.links) - 1
+		}
+	}
+	var pairs []string
+	for placeholder, id := range src.pageRefs {
+		// The label expected by src keeps the width of the text
+		label := strings.TrimPrefix(placeholder, string(pageRefStart))
+		label = strings.TrimLeft(label, string([]rune{pageRefBit0, pageRefBit1}))
+		label = strings.TrimSuffix(label, string(pageRefEnd))
+		own := f.pageRefPlaceholder(f.anchorIDs[src.anchors[id].name], label)
+		pairs = append(pairs, placeholder, own,
+			utf8toutf16(placeholder, false), utf8toutf16(own, false))
+	}
+	if len(pairs) > 0 {
+		pageRefs = strings.NewReplacer(pairs...)
+	}
+	return
+}
+
+// mergeStructure adds the outlines, table of contents and index entries,
+// sections, running marks, aliases and attachments of src, whose pages
+// follow page offset
+func (f *Fpdf) mergeStructure(src *Fpdf, offset int, linkMap []int) {
+	for _, o := range src.outlines {
+		f.outlines = append(f.outlines, outlineType{text: o.text, level: o.level, y: o.y * src.k / f.k,
+			p: o.p + offset, prev: -1, last: -1, next: -1, first: -1})
+	}
+	for _, e := range src.toc {
+		e.link = linkMap[e.link]
+		f.toc = append(f.toc, e)
+	}
+	for _, e := range src.index {
+		e.link = linkMap[e.link]
+		f.index = append(f.index, e)
+	}
+	for _, sec := range src.sections {
+		own := *sec
+		own.firstPage += offset
+		f.sections = append(f.sections, &own)
+	}
+	if len(src.sections) > 0 {
+		if f.aliasPageNoStr == "" {
+			f.AliasPageNo(src.aliasPageNoStr)
+		}
+		if f.aliasSectNbStr == "" {
+			f.AliasSectionNbPages(src.aliasSectNbStr)
+		}
+	}
+	for _, m := range src.runningMarks {
+		m.page += offset
+		f.runningMarks = append(f.runningMarks, m)
+	}
+	for alias, replacement := range src.aliasMap {
+		if _, ok := f.aliasMap[alias]; !ok {
+			f.aliasMap[alias] = replacement
+		}
+	}
+	f.attachments = append(f.attachments, src.attachments...)
+	if src.pdfVersion > f.pdfVersion {
+		f.pdfVersion = src.pdfVersion
+	}
+}
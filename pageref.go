@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"strings"
+)
+
+// A page reference placeholder is made of the expected page label enclosed
+// in control characters that have no width: pageRefStart, the number of the
+// anchor written in binary with pageRefBit0 and pageRefBit1, the label and
+// pageRefEnd.
+const (
+	pageRefBit0  = '\x1c'
+	pageRefBit1  = '\x1d'
+	pageRefStart = '\x1e'
+	pageRefEnd   = '\x1f'
+)
+
+// unknownPageRef is the label of the references to anchors that were never
+// set
+const unknownPageRef = "??"
+
+type anchorType struct {
+	name string
+	link int // internal link to the position of the anchor
+}
+
+// isPageRefMark reports whether c is one of the characters that delimit the
+// placeholders returned by PageRef(). These characters take no room.
+func isPageRefMark(c rune) bool {
+	return c >= pageRefBit0 && c <= pageRefEnd
+}
+
+// anchorID returns the number of the named anchor, creating it if needed
+func (f *Fpdf) anchorID(name string) int {
+	id, ok := f.anchorIDs[name]
+	if !ok {
+		id = len(f.anchors)
+		f.anchors = append(f.anchors, anchorType{name: name, link: f.AddLink()})
+		f.anchorIDs[name] = id
+	}
+	return id
+}
+
+// SetAnchor names the current position so that it can be referred to with
+// PageRef(), before or after this call.
+func (f *Fpdf) SetAnchor(name string) {
+	if f.page == 0 {
+		return
+	}
+	f.SetLink(f.anchors[f.anchorID(name)].link, -1, -1)
+}
+
+// PageRef returns a placeholder for the label of the page where the named
+// anchor is set with SetAnchor(). It is meant to be embedded in text written
+// with Cell(), CellFormat(), MultiCell() or Write(), as in
+//
+//	pdf.Write(5, "See page "+pdf.PageRef("results")+".")
+//
+// The placeholder is replaced when the document is output, once every page
+// is in place, so the anchor can be set later in the document and pages can
+// still be inserted or moved. The reference becomes a link to the anchor.
+// References to anchors that are never set read "??".
+//
+// While the document is laid out, the width of the placeholder is the width
+// of the expected label: the label of the anchor if it is already set, two
+// digits otherwise. When the actual label is wider or narrower, the text that
+// follows the reference is not quite in place. RelayoutPageRefs() lays the
+// document out a second time with the labels found by the first pass to
+// avoid this.
+func (f *Fpdf) PageRef(name string) string {
+	id := f.anchorID(name)
+	label, ok := f.pageRefLabels[name]
+	if !ok {
+		if p := f.links[f.anchors[id].link].page; p > 0 {
+			label = f.pageLabel(p)
+		} else {
+			label = "00"
+		}
+	}
+	return f.pageRefPlaceholder(id, label)
+}
+
+// pageRefPlaceholder returns the placeholder of a reference to anchor id
+// whose expected label is label
+func (f *Fpdf) pageRefPlaceholder(id int, label string) string {
+	var b strings.Builder
+	b.WriteRune(pageRefStart)
+	for n := id; ; n >>= 1 {
+		if n&1 == 0 {
+			b.WriteRune(pageRefBit0)
+		} else {
+			b.WriteRune(pageRefBit1)
+		}
+		if n <= 1 {
+			break
+		}
+	}
+	b.WriteString(label)
+	b.WriteRune(pageRefEnd)
+	placeholder := b.String()
+	f.pageRefs[placeholder] = id
+	return placeholder
+}
+
+// PageRefLabels returns the page labels of the anchors set so far, by name.
+// Passed to SetPageRefLabels() in a new document laid out the same way, they
+// give the placeholders returned by PageRef() their final width.
+func (f *Fpdf) PageRefLabels() map[string]string {
+	labels := make(map[string]string)
+	for _, a := range f.anchors {
+		if p := f.links[a.link].page; p > 0 {
+			labels[a.name] = f.pageLabel(p)
+		}
+	}
+	return labels
+}
+
+// SetPageRefLabels sets the labels that PageRef() expects for the anchors,
+// usually obtained by PageRefLabels() from a first layout pass.
+func (f *Fpdf) SetPageRefLabels(labels map[string]string) {
+	f.pageRefLabels = labels
+}
+
+// RelayoutPageRefs builds a document twice so that page references are laid
+// out with their final width. The {nb} field of running headers and footers
+// is laid out with the final number of pages as well. newPdf returns a new
+// document, for instance by calling New(), and build writes the whole content
+// into it. The document of the second pass is returned, ready for output.
+func RelayoutPageRefs(newPdf func() (*Fpdf, error), build func(pdf *Fpdf) error) (pdf *Fpdf, err error) {
+	pdf, err = newPdf()
+	if err == nil {
+		err = build(pdf)
+	}
+	if err != nil {
+		return
+	}
+	labels := pdf.PageRefLabels()
+	pageCount := pdf.PageCount()
+	pdf, err = newPdf()
+	if err == nil {
+		pdf.SetPageRefLabels(labels)
+		pdf.pageCountHint = pageCount
+		err = build(pdf)
+	}
+	return
+}
+
+// pageRefLinks adds a link to the anchor over each page reference found in
+// txtStr, a cell text written at x and y with a height of h
+func (f *Fpdf) pageRefLinks(txtStr string, x, y, h float64) {
+	for pos := 0; ; {
+		start := strings.IndexRune(txtStr[pos:], pageRefStart)
+		if start < 0 {
+			return
+		}
+		start += pos
+		end := strings.IndexRune(txtStr[start:], pageRefEnd)
+		if end < 0 {
+			return
+		}
+		end += start
+		placeholder := txtStr[start : end+1]
+		pos = end + 1
+		if id, ok := f.pageRefs[placeholder]; ok {
+			f.newLink(x+f.GetStringWidth(txtStr[:start]), y, f.GetStringWidth(placeholder), h, f.anchors[id].link, "")
+		}
+	}
+}
+
+// replacePageRefs replaces the page reference placeholders with the labels of
+// the pages of their anchors and removes the links to anchors never set. It
+// is called once all the pages are in place.
+func (f *Fpdf) replacePageRefs() {
+	if len(f.pageRefs) == 0 {
+		return
+	}
+	unset := make(map[int]bool)
+	for _, a := range f.anchors {
+		if f.links[a.link].page == 0 {
+			unset[a.link] = true
+		}
+	}
+	for placeholder, id := range f.pageRefs {
+		label := unknownPageRef
+		if p := f.links[f.anchors[id].link].page; p > 0 {
+			label = f.pageLabel(p)
+		}
+		// The label may use glyphs not found elsewhere in the document
+		for _, font := range f.fonts {
+			if font.usedRunes != nil {
+				for _, r := range label {
+					font.usedRunes[int(r)] = int(r)
+				}
+			}
+		}
+		utf16Placeholder := utf8toutf16(placeholder, false)
+		utf16Label := utf8toutf16(label, false)
+		for n := 1; n < len(f.pages); n++ {
+			f.replacePageAlias(n, placeholder, label)
+			f.replacePageAlias(n, utf16Placeholder, utf16Label)
+		}
+	}
+	for n := 1; n < len(f.pageLinks); n++ {
+		links := f.pageLinks[n][:0]
+		for _, pl := range f.pageLinks[n] {
+			if !unset[pl.link] || pl.link == 0 {
+				links = append(links, pl)
+			}
+		}
+		f.pageLinks[n] = links
+	}
+}
@@ -0,0 +1,351 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// pageGeometryType holds the size and orientation of the page being written
+type pageGeometryType struct {
+	orientationStr string
+	size           SizeType
+	w, h           float64
+	wPt, hPt       float64
+}
+
+// pageGeometry returns the geometry of the current page
+func (f *Fpdf) pageGeometry() pageGeometryType {
+	return pageGeometryType{f.curOrientation, f.curPageSize, f.w, f.h, f.wPt, f.hPt}
+}
+
+// setPageGeometry restores a geometry returned by pageGeometry()
+func (f *Fpdf) setPageGeometry(g pageGeometryType) {
+	f.curOrientation = g.orientationStr
+	f.curPageSize = g.size
+	f.w, f.h = g.w, g.h
+	f.wPt, f.hPt = g.wPt, g.hPt
+	f.setPageBreakTrigger()
+	f.updateWorkingSize()
+}
+
+// setPageBreakTrigger sets the page break trigger of the current page above
+// the bottom margin and the room reserved for the footnotes of the page
+func (f *Fpdf) setPageBreakTrigger() {
+	f.pageBreakTrigger = f.h - f.bMargin - f.footnote.reserved[f.page]
+}
+
+// pageGeometryOf returns the geometry of the specified one-based page
+func (f *Fpdf) pageGeometryOf(pageNum int) (g pageGeometryType) {
+	sz, ok := f.pageSizes[pageNum]
+	if !ok {
+		g.orientationStr = f.defOrientation
+		g.size = f.defPageSize
+		if g.orientationStr == "P" {
+			g.w, g.h = g.size.Wd, g.size.Ht
+		} else {
+			g.w, g.h = g.size.Ht, g.size.Wd
+		}
+		g.wPt, g.hPt = g.w*f.k, g.h*f.k
+		return
+	}
+	g.wPt, g.hPt = sz.Wd, sz.Ht
+	g.w, g.h = sz.Wd/f.k, sz.Ht/f.k
+	g.orientationStr = "P"
+	g.size = SizeType{g.w, g.h}
+	if g.w > g.h {
+		g.orientationStr = "L"
+		g.size = SizeType{g.h, g.w}
+	}
+	return
+}
+
+// selectPage makes the specified one-based page the current one, including
+// its dimensions, so that content can be drawn on it.
+func (f *Fpdf) selectPage(pageNum int) {
+	f.page = pageNum
+	f.setPageGeometry(f.pageGeometryOf(pageNum))
+}
+
+// permutePages rebuilds the page list from order. order is one-based like the
+// page list; order[j] is the current number of the page that becomes page j,
+// or zero to insert a blank page at position j. Pages that do not appear in
+// order are dropped. Every stored page reference is updated accordingly.
+func (f *Fpdf) permutePages(order []int) {
+	pages := make([]*bytes.Buffer, len(order))
+	pageLinks := make([][]linkType, len(order))
+	pageAttachments := make([][]annotationAttach, len(order))
+	pageSizes := make(map[int]SizeType)
+	pageBoxes := make(map[int]map[string]PageBox)
+	newNum := make(map[int]int)
+	pages[0] = f.pages[0]
+	pageLinks[0] = f.pageLinks[0]
+	pageAttachments[0] = f.pageAttachments[0]
+	for j := 1; j < len(order); j++ {
+		old := order[j]
+		if old == 0 {
+			pages[j] = bytes.NewBufferString("")
+			pageLinks[j] = make([]linkType, 0)
+			pageAttachments[j] = []annotationAttach{}
+			continue
+		}
+		newNum[old] = j
+		pages[j] = f.pages[old]
+		pageLinks[j] = f.pageLinks[old]
+		pageAttachments[j] = f.pageAttachments[old]
+		if sz, ok := f.pageSizes[old]; ok {
+			pageSizes[j] = sz
+		}
+		if pb, ok := f.pageBoxes[old]; ok {
+			pageBoxes[j] = pb
+		}
+	}
+	f.pages = pages
+	f.pageLinks = pageLinks
+	f.pageAttachments = pageAttachments
+	f.pageSizes = pageSizes
+	f.pageBoxes = pageBoxes
+	f.remapPages(func(old int) int {
+		return newNum[old]
+	})
+}
+
+// remapPages updates every page reference held by the document using fn,
+// which returns the new number of a page from its old one, or zero if the
+// page no longer exists.
+func (f *Fpdf) remapPages(fn func(old int) int) {
+	if f.openPage > 0 {
+		f.openPage = fn(f.openPage)
+	}
+	if f.page > 0 {
+		f.page = fn(f.page)
+		if f.page == 0 {
+			f.page = f.openPage
+		}
+	}
+	for j := 1; j < len(f.links); j++ {
+		if f.links[j].page > 0 {
+			f.links[j].page = fn(f.links[j].page)
+		}
+	}
+	for j := range f.outlines {
+		f.outlines[j].p = fn(f.outlines[j].p)
+	}
+	for j := range f.runningMarks {
+		f.runningMarks[j].page = fn(f.runningMarks[j].page)
+	}
+	if len(f.footnote.reserved) > 0 {
+		reserved := make(map[int]float64, len(f.footnote.reserved))
+		for old, ht := range f.footnote.reserved {
+			if n := fn(old); n > 0 {
+				reserved[n] = ht
+			}
+		}
+		f.footnote.reserved = reserved
+	}
+	// A section begins with its first page that is left. Since a page moved
+	// elsewhere takes the beginning of its section along, the sections are
+	// sorted again, a section that no longer has a page of its own giving way
+	// to the one that follows it.
+	sections := f.sections[:0]
+	for j, sec := range f.sections {
+		end := len(f.pages) + 1
+		if j+1 < len(f.sections) {
+			end = f.sections[j+1].firstPage
+		}
+		first := 0
+		for old := sec.firstPage; old < end && first == 0; old++ {
+			first = fn(old)
+		}
+		if first > 0 {
+			sec.firstPage = first
+			sections = append(sections, sec)
+		}
+	}
+	sort.SliceStable(sections, func(a, b int) bool {
+		return sections[a].firstPage < sections[b].firstPage
+	})
+	f.sections = sections[:0]
+	for _, sec := range sections {
+		if n := len(f.sections); n > 0 && f.sections[n-1].firstPage == sec.firstPage {
+			f.sections[n-1] = sec
+		} else {
+			f.sections = append(f.sections, sec)
+		}
+	}
+}
+
+// insertPages inserts count blank pages before the one-based page at. If at
+// is one more than the page count, the pages are appended.
+func (f *Fpdf) insertPages(at, count int) {
+	order := make([]int, 0, len(f.pages)+count)
+	for j := 0; j < at; j++ {
+		order = append(order, j)
+	}
+	for j := 0; j < count; j++ {
+		order = append(order, 0)
+	}
+	for j := at; j < len(f.pages); j++ {
+		order = append(order, j)
+	}
+	f.permutePages(order)
+}
+
+// beginInsertedPage inserts a blank page before the one-based page at, makes
+// it the current page and starts it the same way AddPage() does, including
+// the call to the header function. ps is the graphic state to begin with.
+func (f *Fpdf) beginInsertedPage(at int, ps pageStateType) (err error) {
+	f.insertPages(at, 1)
+	f.page = at
+	f.initpage(f.defOrientation, f.defPageSize)
+	return f.startpage(ps)
+}
+
+// endInsertedPage draws the footer of a page begun by beginInsertedPage() and
+// closes the layer left open on it. Unlike endpage(), it leaves the page being
+// written open.
+func (f *Fpdf) endInsertedPage() {
+	f.putfooter(false)
+	f.EndLayer()
+}
+
+// pageLabel returns the page number displayed for the specified one-based
+// page, for instance in a table of contents.
+func (f *Fpdf) pageLabel(pageNum int) string {
+	if sec, _ := f.sectionOf(pageNum); sec != nil {
+		return sec.opts.NumberPrefix + formatPageNumber(sec.number(pageNum), sec.opts.NumberStyle)
+	}
+	return strconv.Itoa(pageNum)
+}
+
+// checkPageNum sets the error of the document if the one-based page pageNum
+// does not exist
+func (f *Fpdf) checkPageNum(pageNum int) error {
+	if f.err == nil && (pageNum < 1 || pageNum >= len(f.pages)) {
+		f.err = fmt.Errorf("page %d does not exist, the document has %d pages", pageNum, f.PageCount())
+	}
+	return f.err
+}
+
+// MovePage moves the one-based page from so that it becomes page to, the
+// pages in between shifting by one. Links, bookmarks, anchors, index and
+// table of contents entries follow the pages they point to, as do the sizes,
+// boxes, annotations and attachments of the pages.
+//
+// The page being written can be moved too, for instance to put at the
+// front a summary written last: AddPage() and Close() finish it wherever it
+// stands. Page numbers written with the aliases set by AliasPageNo() and
+// AliasNbPages(), and page references returned by PageRef(), are only
+// replaced when the document is output, so they reflect the new order.
+func (f *Fpdf) MovePage(from, to int) (err error) {
+	if f.checkPageNum(from) != nil || f.checkPageNum(to) != nil {
+		return f.err
+	}
+	if from == to {
+		return
+	}
+	order := make([]int, 0, len(f.pages))
+	for j := 0; j < len(f.pages); j++ {
+		if j != from {
+			order = append(order, j)
+		}
+	}
+	order = append(order[:to], append([]int{from}, order[to:]...)...)
+	f.permutePages(order)
+	f.selectPage(f.page)
+	return
+}
+
+// InsertPageBefore inserts a new page before the one-based page pageNum, or
+// after the last page if pageNum is one more than the page count. The page
+// has the default size and orientation, and its header and footer are drawn
+// the same way AddPage() does. The current page does not change: content is
+// drawn on the new page after selecting it with SetPage(). The following
+// pages are renumbered as MovePage() does.
+func (f *Fpdf) InsertPageBefore(pageNum int) (err error) {
+	if pageNum != len(f.pages) && f.checkPageNum(pageNum) != nil {
+		return f.err
+	}
+	if f.err != nil {
+		return f.err
+	}
+	ps := f.pageState()
+	curPage, x, y := f.page, f.x, f.y
+	layer := f.layer.currentLayer
+	f.layer.currentLayer = -1
+	if err = f.beginInsertedPage(pageNum, ps); err == nil {
+		f.endInsertedPage()
+	}
+	if curPage >= pageNum {
+		curPage++
+	}
+	f.selectPage(curPage)
+	f.layer.currentLayer = layer
+	f.x, f.y = x, y
+	if err == nil && ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	f.SetError(err)
+	return
+}
+
+// DeletePage removes the one-based page pageNum from the document; the
+// following pages are renumbered as MovePage() does. Links, bookmarks and
+// anchors that pointed to the deleted page point to the top of the page that
+// takes its place, or of the previous page if it was the last one. The page
+// being written, whose footer is not drawn yet, cannot be deleted, and
+// neither can the only page of the document.
+func (f *Fpdf) DeletePage(pageNum int) (err error) {
+	if f.checkPageNum(pageNum) != nil {
+		return f.err
+	}
+	if pageNum == f.openPage {
+		f.err = fmt.Errorf("page %d is being written and cannot be deleted", pageNum)
+		return f.err
+	}
+	if len(f.pages) == 2 {
+		// Links and bookmarks would be left without a page to point to
+		f.err = fmt.Errorf("the only page of the document cannot be deleted")
+		return f.err
+	}
+	target := pageNum + 1
+	if target == len(f.pages) {
+		target = pageNum - 1
+	}
+	for j := 1; j < len(f.links); j++ {
+		if f.links[j].page == pageNum {
+			f.links[j] = intLinkType{target, 0}
+		}
+	}
+	for j := range f.outlines {
+		if f.outlines[j].p == pageNum {
+			f.outlines[j].p, f.outlines[j].y = target, 0
+		}
+	}
+	order := make([]int, 0, len(f.pages)-1)
+	for j := 0; j < len(f.pages); j++ {
+		if j != pageNum {
+			order = append(order, j)
+		}
+	}
+	f.permutePages(order)
+	f.selectPage(f.page)
+	return
+}
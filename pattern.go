@@ -0,0 +1,218 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PatternID identifies a tiling pattern of the document, as returned by
+// AddTilingPattern() and AddHatchPattern()
+type PatternID int
+
+// HatchStyle is the motif of a pattern added with AddHatchPattern()
+type HatchStyle int
+
+const (
+	// HatchDiagonal is made of parallel lines rising from left to right
+	HatchDiagonal HatchStyle = iota
+	// HatchCross is made of horizontal and vertical lines
+	HatchCross
+	// HatchDots is made of dots laid out on a square grid
+	HatchDots
+)
+
+// patternType is a tiling pattern, the cell of which is drawn as a template,
+// or the shading pattern of a gradient set by SetFillGradient()
+type patternType struct {
+	tpl          Template // drawing of a cell
+	xStep, yStep float64  // spacing of the cells in points
+	shading      int      // gradient of a shading pattern, 1-based
+	objNum       int      // object number of the pattern
+}
+
+// AddTilingPattern adds a pattern that repeats a drawing, the cell, over the
+// area it fills or strokes, and returns its identifier for SetFillPattern()
+// and SetDrawPattern(). The cell is w wide and h high and is drawn by fn as a
+// template of that size is, with its upper left corner at (0, 0). Cells are
+// repeated every xStep horizontally and every yStep vertically, starting from
+// the lower left corner of the page, so that areas filled with the same
+// pattern join seamlessly. All values are expressed in the unit of measure
+// specified in New().
+//
+// The cell may use images, fonts and the patterns added before it, but not
+// templates.
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) AddTilingPattern(w, h, xStep, yStep float64, fn func(*Tpl)) PatternID {
+	if f.err != nil {
+		return 0
+	}
+	if w <= 0 || h <= 0 || xStep <= 0 || yStep <= 0 {
+		f.err = fmt.Errorf("invalid tiling pattern: %.2f x %.2f cell repeated every %.2f, %.2f", w, h, xStep, yStep)
+		return 0
+	}
+	tpl, err := newTpl(PointType{0, 0}, SizeType{w, h}, "P", f.unitStr, f.fontDirStr, fn, f)
+	if err != nil {
+		f.err = err
+		return 0
+	}
+	if len(tpl.Templates()) > 0 {
+		f.err = fmt.Errorf("templates cannot be used in the cell of a tiling pattern")
+		return 0
+	}
+	f.addTemplateImages(tpl)
+	f.patterns = append(f.patterns, patternType{tpl: tpl, xStep: xStep * f.k, yStep: yStep * f.k})
+	return PatternID(len(f.patterns) - 1)
+}
+
+// AddHatchPattern adds a tiling pattern of lines or dots drawn with the
+// current draw color on a transparent background, and returns its identifier
+// for SetFillPattern() and SetDrawPattern(). spacing is the distance between
+// the lines or the dots and lineWidth is the width of the lines or the
+// diameter of the dots, both expressed in the unit of measure specified in
+// New().
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) AddHatchPattern(style HatchStyle, spacing, lineWidth float64) PatternID {
+	if f.err != nil {
+		return 0
+	}
+	s := spacing
+	switch style {
+	case HatchDiagonal:
+		// Lines at 45 degrees spaced by s cross the cell edges every s√2; the
+		// lines of the neighboring cells fill the corners
+		d := s * math.Sqrt2
+		return f.AddTilingPattern(d, d, d, d, func(tpl *Tpl) {
+			tpl.SetLineWidth(lineWidth)
+			for _, x := range []float64{-d, 0, d} {
+				tpl.Line(x, d, x+d, 0)
+			}
+		})
+	case HatchCross:
+		return f.AddTilingPattern(s, s, s, s, func(tpl *Tpl) {
+			tpl.SetLineWidth(lineWidth)
+			tpl.Line(s/2, 0, s/2, s)
+			tpl.Line(0, s/2, s, s/2)
+		})
+	case HatchDots:
+		// A line of no length with round caps is painted as a dot
+		return f.AddTilingPattern(s, s, s, s, func(tpl *Tpl) {
+			tpl.SetLineWidth(lineWidth)
+			tpl.SetLineCapStyle("round")
+			tpl.Line(s/2, s/2, s/2, s/2)
+		})
+	}
+	f.err = fmt.Errorf("unknown hatch style: %d", style)
+	return 0
+}
+
+func (f *Fpdf) patternOk(id PatternID) bool {
+	if f.err != nil {
+		return false
+	}
+	if id < 1 || int(id) >= len(f.patterns) {
+		f.err = fmt.Errorf("tiling pattern %d is not defined", id)
+		return false
+	}
+	return true
+}
+
+// SetFillPattern sets the tiling pattern identified by id, as returned by
+// AddTilingPattern() or AddHatchPattern(), as the current fill color. It is
+// used by all the shapes that are filled, and by the cell backgrounds, until
+// another fill color is set.
+func (f *Fpdf) SetFillPattern(id PatternID) {
+	if f.patternOk(id) {
+		f.color.fill.mode = colorModePattern
+		f.color.fill.str = sprintf("/Pattern cs /P%d scn", id)
+		f.colorFlag = f.color.fill.str != f.color.text.str
+		if f.page > 0 {
+			f.out(f.color.fill.str)
+		}
+	}
+}
+
+// SetDrawPattern sets the tiling pattern identified by id, as returned by
+// AddTilingPattern() or AddHatchPattern(), as the current draw color. It is
+// used by all the lines and outlines that are drawn until another draw color
+// is set.
+func (f *Fpdf) SetDrawPattern(id PatternID) {
+	if f.patternOk(id) {
+		f.color.draw.mode = colorModePattern
+		f.color.draw.str = sprintf("/Pattern CS /P%d SCN", id)
+		if f.page > 0 {
+			f.out(f.color.draw.str)
+		}
+	}
+}
+
+// putPatterns writes the tiling patterns, with the content and the resources
+// of their cell, and the shading patterns of the gradients
+func (f *Fpdf) putPatterns() {
+	// Each pattern is one object: their numbers are known beforehand for the
+	// patterns to refer to one another
+	for j := 1; j < len(f.patterns); j++ {
+		f.patterns[j].objNum = f.n + j
+	}
+	for j := 1; j < len(f.patterns); j++ {
+		pt := f.patterns[j]
+		if pt.shading > 0 {
+			f.newobj()
+			f.outf("<</Type /Pattern /PatternType 2 /Shading %d 0 R>>", f.gradientList[pt.shading].objNum)
+			f.out("endobj")
+			continue
+		}
+		_, size := pt.tpl.Size()
+		content := pt.tpl.Bytes()
+		filter := ""
+		if f.compress {
+			content = sliceCompress(content)
+			filter = "/Filter /FlateDecode "
+		}
+		f.newobj()
+		f.out("<</Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1")
+		f.outf("/BBox [0 0 %.5f %.5f] /XStep %.5f /YStep %.5f", size.Wd*f.k, size.Ht*f.k, pt.xStep, pt.yStep)
+		f.out("/Resources <</ProcSet [/PDF /Text /ImageB /ImageC /ImageI]")
+		f.templateFontCatalog()
+		f.putnumberedresourcedict()
+		images := pt.tpl.Images()
+		if len(images) > 0 {
+			keyList := make([]string, 0, len(images))
+			for key := range images {
+				keyList = append(keyList, key)
+			}
+			sort.Strings(keyList)
+			written := make(map[string]bool)
+			f.out("/XObject <<")
+			for _, key := range keyList {
+				if i := images[key].i; !written[i] {
+					f.outf("/I%s %d 0 R", i, f.imageObjNum(i))
+					written[i] = true
+				}
+			}
+			f.out(">>")
+		}
+		f.out(">>")
+		f.outf("%s/Length %d>>", filter, len(content))
+		f.putstream(content)
+		f.out("endobj")
+	}
+}
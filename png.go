@@ -53,9 +53,14 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 	}
 	w := f.readBeInt32(buf)
 	h := f.readBeInt32(buf)
+	if w <= 0 || h <= 0 {
+		f.err = fmt.Errorf("invalid image size in PNG buffer: %d x %d", w, h)
+		return
+	}
 	bpc := f.readByte(buf)
-	if bpc > 8 {
-		f.err = fmt.Errorf("16-bit depth not supported in PNG file")
+	if bpc > 8 && f.pdfVersion < "1.5" {
+		// 16-bit samples are kept as they are
+		f.pdfVersion = "1.5"
 	}
 	ct := f.readByte(buf)
 	var colspace string
@@ -72,8 +77,13 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 		f.err = fmt.Errorf("'unknown filter method in PNG buffer")
 		return
 	}
-	if f.readByte(buf) != 0 {
-		f.err = fmt.Errorf("interlacing not supported in PNG buffer")
+	interlaced := false
+	switch f.readByte(buf) {
+	case 0:
+	case 1:
+		interlaced = true
+	default:
+		f.err = fmt.Errorf("unknown interlace method in PNG buffer")
 		return
 	}
 	_ = buf.Next(4)
@@ -81,6 +91,7 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 	// Scan chunks looking for palette, transparency and image data
 	pal := make([]byte, 0, 32)
 	var trns []int
+	var icc []byte
 	data := make([]byte, 0, 32)
 	loop := true
 	for loop {
@@ -96,10 +107,18 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 			// dbg("tRNS")
 			// Read transparency info
 			t := buf.Next(n)
-			switch ct {
-			case 0:
+			if (ct == 0 && len(t) < 2) || (ct == 2 && len(t) < 6) {
+				f.err = fmt.Errorf("truncated transparency chunk in PNG buffer")
+				return
+			}
+			switch {
+			case ct == 0 && bpc > 8:
+				trns = []int{int(t[0])<<8 | int(t[1])}
+			case ct == 2 && bpc > 8:
+				trns = []int{int(t[0])<<8 | int(t[1]), int(t[2])<<8 | int(t[3]), int(t[4])<<8 | int(t[5])}
+			case ct == 0:
 				trns = []int{int(t[1])} // ord(substr($t,1,1)));
-			case 2:
+			case ct == 2:
 				trns = []int{int(t[1]), int(t[3]), int(t[5])} // array(ord(substr($t,1,1)), ord(substr($t,3,1)), ord(substr($t,5,1)));
 			default:
 				pos := strings.Index(string(t), "\x00")
@@ -113,6 +132,16 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 			// Read image data block
 			data = append(data, buf.Next(n)...)
 			_ = buf.Next(4)
+		case "iCCP":
+			// Read the ICC profile, compressed after its name; a profile that
+			// cannot be decompressed is ignored
+			t := buf.Next(n)
+			if pos := bytes.IndexByte(t, 0); pos >= 0 && pos+2 <= len(t) && t[pos+1] == 0 {
+				if profile, err := sliceUncompress(t[pos+2:]); err == nil {
+					icc = profile
+				}
+			}
+			_ = buf.Next(4)
 		case "IEND":
 			// dbg("IEND")
 			loop = false
@@ -157,57 +186,185 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 	info.dp = dp
 	info.pal = pal
 	info.trns = trns
+	info.setICC(icc)
 	// dbg("ct [%d]", ct)
-	if ct >= 4 {
-		// Separate alpha and color channels
-		var err error
-		data, err = sliceUncompress(data)
+	if !interlaced && ct < 4 {
+		info.data = data
+		return
+	}
+	var err error
+	data, err = sliceUncompress(data)
+	if err != nil {
+		f.err = err
+		return
+	}
+	if interlaced {
+		// The passes are merged into rows that need no prediction
+		data, err = pngDeinterlace(data, int(w), int(h), pngChannels(ct)*int(bpc))
 		if err != nil {
 			f.err = err
 			return
 		}
+	}
+	if ct >= 4 {
+		// Separate alpha and color channels, of one or two bytes
 		var color, alpha bytes.Buffer
-		if ct == 4 {
-			// Gray image
-			width := int(w)
-			height := int(h)
-			length := 2 * width
-			var pos, elPos int
-			for i := 0; i < height; i++ {
-				pos = (1 + length) * i
-				color.WriteByte(data[pos])
-				alpha.WriteByte(data[pos])
-				elPos = pos + 1
-				for k := 0; k < width; k++ {
-					color.WriteByte(data[elPos])
-					alpha.WriteByte(data[elPos+1])
-					elPos += 2
-				}
-			}
-		} else {
+		sz := int(bpc) / 8
+		colorLen := sz
+		if ct == 6 {
 			// RGB image
-			width := int(w)
-			height := int(h)
-			length := 4 * width
-			var pos, elPos int
-			for i := 0; i < height; i++ {
-				pos = (1 + length) * i
-				color.WriteByte(data[pos])
-				alpha.WriteByte(data[pos])
-				elPos = pos + 1
-				for k := 0; k < width; k++ {
-					color.Write(data[elPos : elPos+3])
-					alpha.WriteByte(data[elPos+3])
-					elPos += 4
-				}
+			colorLen = 3 * sz
+		}
+		width := int(w)
+		height := int(h)
+		length := (colorLen + sz) * width
+		if len(data) < height*(1+length) {
+			f.err = fmt.Errorf("truncated PNG image data")
+			return
+		}
+		var pos, elPos int
+		for i := 0; i < height; i++ {
+			pos = (1 + length) * i
+			color.WriteByte(data[pos])
+			alpha.WriteByte(data[pos])
+			elPos = pos + 1
+			for k := 0; k < width; k++ {
+				color.Write(data[elPos : elPos+colorLen])
+				alpha.Write(data[elPos+colorLen : elPos+colorLen+sz])
+				elPos += colorLen + sz
 			}
 		}
-		data = sliceCompress(color.Bytes())
+		data = color.Bytes()
 		info.smask = sliceCompress(alpha.Bytes())
 		if f.pdfVersion < "1.4" {
 			f.pdfVersion = "1.4"
 		}
 	}
+	data = sliceCompress(data)
 	info.data = data
 	return
 }
+
+// pngChannels returns the number of samples of a pixel of the PNG color type
+// ct
+func pngChannels(ct byte) int {
+	switch ct {
+	case 2:
+		return 3
+	case 4:
+		return 2
+	case 6:
+		return 4
+	}
+	return 1
+}
+
+// pngAdam7 lists the first column and row, and the column and row steps, of
+// the pixels of each pass of an interlaced PNG image
+var pngAdam7 = [7][4]int{{0, 0, 8, 8}, {4, 0, 8, 8}, {0, 4, 4, 8}, {2, 0, 4, 4}, {0, 2, 2, 4}, {1, 0, 2, 2}, {0, 1, 1, 2}}
+
+// pngDeinterlace returns the rows of the uncompressed data of an interlaced
+// PNG image of w by h pixels of bpp bits, each one preceded by the filter
+// type 0 so that the PNG predictors of PDF decode them as they are
+func pngDeinterlace(data []byte, w, h, bpp int) ([]byte, error) {
+	// Check the size of the passes before allocating the rows, whose
+	// dimensions come from the header
+	size := 0
+	for _, pass := range pngAdam7 {
+		x0, y0, dx, dy := pass[0], pass[1], pass[2], pass[3]
+		if pw, ph := (w-x0+dx-1)/dx, (h-y0+dy-1)/dy; pw > 0 && ph > 0 {
+			size += ph * (1 + (pw*bpp+7)/8)
+		}
+		if size > len(data) {
+			return nil, fmt.Errorf("truncated interlaced PNG data")
+		}
+	}
+	rowLen := (w*bpp + 7) / 8
+	out := make([]byte, h*(1+rowLen))
+	pos := 0
+	for _, pass := range pngAdam7 {
+		x0, y0, dx, dy := pass[0], pass[1], pass[2], pass[3]
+		pw, ph := (w-x0+dx-1)/dx, (h-y0+dy-1)/dy
+		if pw <= 0 || ph <= 0 {
+			continue
+		}
+		passLen := (pw*bpp + 7) / 8
+		rows := data[pos : pos+ph*(1+passLen)]
+		pos += ph * (1 + passLen)
+		if err := pngUnfilter(rows, passLen, (bpp+7)/8); err != nil {
+			return nil, err
+		}
+		for j := 0; j < ph; j++ {
+			src := rows[j*(1+passLen)+1 : (j+1)*(1+passLen)]
+			dst := out[(y0+j*dy)*(1+rowLen)+1 : (y0+j*dy+1)*(1+rowLen)]
+			for i := 0; i < pw; i++ {
+				x := x0 + i*dx
+				if bpp >= 8 {
+					sz := bpp / 8
+					copy(dst[x*sz:(x+1)*sz], src[i*sz:(i+1)*sz])
+					continue
+				}
+				// Pixels of less than a byte
+				mask := byte(1)<<uint(bpp) - 1
+				val := src[i*bpp/8] >> uint(8-bpp-i*bpp%8) & mask
+				dst[x*bpp/8] |= val << uint(8-bpp-x*bpp%8)
+			}
+		}
+	}
+	return out, nil
+}
+
+// pngUnfilter reverses in place the prediction of rows of rowLen bytes, each
+// preceded by its filter type, for pixels of bpp bytes
+func pngUnfilter(rows []byte, rowLen, bpp int) error {
+	prev := make([]byte, rowLen)
+	for pos := 0; pos < len(rows); pos += 1 + rowLen {
+		filter, row := rows[pos], rows[pos+1:pos+1+rowLen]
+		for x := range row {
+			var left, upLeft byte
+			if x >= bpp {
+				left, upLeft = row[x-bpp], prev[x-bpp]
+			}
+			up := prev[x]
+			switch filter {
+			case 0:
+			case 1:
+				row[x] += left
+			case 2:
+				row[x] += up
+			case 3:
+				row[x] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[x] += pngPaeth(left, up, upLeft)
+			default:
+				return fmt.Errorf("unknown filter type %d in PNG data", filter)
+			}
+		}
+		rows[pos] = 0
+		prev = row
+	}
+	return nil
+}
+
+// pngPaeth returns the Paeth predictor of a byte from its left, upper and
+// upper left neighbours
+func pngPaeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := p-int(a), p-int(b), p-int(c)
+	if pa < 0 {
+		pa = -pa
+	}
+	if pb < 0 {
+		pb = -pb
+	}
+	if pc < 0 {
+		pc = -pc
+	}
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
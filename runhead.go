@@ -0,0 +1,347 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RunningSlot is the content of the left, center or right slot of a running
+// header or footer: an optional image, such as a logo, followed by styled
+// text. The text of the runs can hold the fields {page}, {nb}, {chapter},
+// {section}, {title} and {date}, described with SetRunningHeader().
+type RunningSlot struct {
+	Image       string    // Name of an image file, or of a registered image, drawn before the text
+	ImageHeight float64   // Height of the image in user units, the height of the band if zero
+	Runs        []TextRun // Text of the slot, on one line
+}
+
+// RunningBand is the content of a running header or footer for a kind of
+// page.
+type RunningBand struct {
+	Left, Center, Right RunningSlot
+	Rule                BorderSide // Rule between the band and the body of the page, none if its width is zero
+}
+
+// RunningSpec defines a running header or footer set with
+// SetRunningHeader() or SetRunningFooter().
+type RunningSpec struct {
+	Band       RunningBand  // Band of the pages
+	First      *RunningBand // Band of the first page of the document, or of each section, Band if nil
+	Even       *RunningBand // Band of the even numbered pages, Band if nil
+	FontFamily string       // Font family of the text, current family if empty
+	FontStyle  string       // Font style of the runs without their own style
+	FontSize   float64      // Font size in points of the runs without their own size, current size if zero
+	Color      *Color       // Color of the runs without their own color, current text color if nil
+	Height     float64      // Height of the band in user units, 1.2 times the largest font size if zero
+	Offset     float64      // Distance between the edge of the page and the band in user units, band centered in the margin if zero
+	RuleGap    float64      // Room between the band and its rule in user units, a quarter of the height of the band if zero
+	DateFormat string       // Layout of the {date} field as in time.Format(), "2006-01-02" if empty
+}
+
+// runningMarkType is a heading that running headers and footers can show
+type runningMarkType struct {
+	page  int
+	level int
+	text  string
+}
+
+// SetRunningHeader sets the header drawn on every page from now on, in
+// addition to the one of the header function, if any. A nil spec removes it.
+// Unlike SetHeader(), the margins are left unchanged: the band is centered
+// in the top margin unless the spec sets an offset.
+//
+// The header is drawn as each page is finished, so that the fields in its
+// text reflect the whole page:
+//
+//	{page}     the page number, as displayed by viewers within sections
+//	{nb}       the number of pages of the document
+//	{chapter}  the last level 0 Title() or Bookmark() up to this page
+//	{section}  the last level 1 Title() or Bookmark() of that chapter
+//	{title}    the title of the document set with SetTitle()
+//	{date}     the creation date of the document, today if not set
+//
+// SetRunningMark() sets a chapter or section without a title or a bookmark.
+//
+// The {page} and {nb} fields are replaced when the document is output. Until
+// then, they take the room of the label of the page and of the number of
+// pages so far; RelayoutPageRefs() lays the document out a second time with
+// the final number of pages.
+func (f *Fpdf) SetRunningHeader(spec *RunningSpec) {
+	f.runningHeader = spec
+	f.setRunningAliases(spec)
+}
+
+// SetRunningFooter sets the footer drawn on every page from now on, in
+// addition to the one of the footer function, if any. A nil spec removes it.
+// The band is centered in the bottom margin unless the spec sets an offset.
+// See SetRunningHeader() for the fields the text can hold.
+func (f *Fpdf) SetRunningFooter(spec *RunningSpec) {
+	f.runningFooter = spec
+	f.setRunningAliases(spec)
+}
+
+// setRunningAliases enables the aliases that the {page} and {nb} fields are
+// written with
+func (f *Fpdf) setRunningAliases(spec *RunningSpec) {
+	if spec == nil {
+		return
+	}
+	if f.aliasPageNoStr == "" {
+		f.AliasPageNo("")
+	}
+	if f.aliasNbPagesStr == "" {
+		f.AliasNbPages("")
+	}
+}
+
+// SetRunningMark sets the chapter, for level 0, or the section, for level 1,
+// shown by the {chapter} and {section} fields of the running headers and
+// footers from the current page on. Title() and Bookmark() call it.
+func (f *Fpdf) SetRunningMark(txtStr string, level int) {
+	if f.page == 0 {
+		return
+	}
+	f.runningMarks = append(f.runningMarks, runningMarkType{page: f.page, level: level, text: txtStr})
+}
+
+// currentMarks returns the chapter and the section of the one-based page
+// pageNum
+func (f *Fpdf) currentMarks(pageNum int) (chapter, section string) {
+	for _, m := range f.runningMarks {
+		if m.page > pageNum {
+			continue
+		}
+		switch m.level {
+		case 0:
+			chapter, section = m.text, ""
+		case 1:
+			section = m.text
+		}
+	}
+	return
+}
+
+// band returns the band of spec for the current page
+func (spec *RunningSpec) band(f *Fpdf) *RunningBand {
+	first, number := 1, f.page
+	if sec, _ := f.sectionOf(f.page); sec != nil {
+		first, number = sec.firstPage, sec.number(f.page)
+	}
+	switch {
+	case f.page == first && spec.First != nil:
+		return spec.First
+	case number%2 == 0 && spec.Even != nil:
+		return spec.Even
+	}
+	return &spec.Band
+}
+
+// runningText replaces the fields of txtStr, {page} with pageStr and {nb}
+// with nbStr
+func (f *Fpdf) runningText(txtStr string, spec *RunningSpec, pageStr, nbStr string) string {
+	if !strings.Contains(txtStr, "{") {
+		return txtStr
+	}
+	chapter, section := f.currentMarks(f.page)
+	layout := spec.DateFormat
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	return strings.NewReplacer(
+		"{page}", pageStr,
+		"{nb}", nbStr,
+		"{chapter}", chapter,
+		"{section}", section,
+		"{title}", f.titleText,
+		"{date}", timeOrNow(f.creationDate).Format(layout),
+	).Replace(txtStr)
+}
+
+// runningSlotType is a slot laid out for the current page
+type runningSlotType struct {
+	slot   *RunningSlot
+	runs   []TextRun // runs with their fields replaced and their style resolved
+	runWds []float64 // widths of the runs once the aliases are replaced
+	imgWd  float64
+	imgHt  float64
+	width  float64
+	size   float64 // largest font size of the runs in user units
+}
+
+// layoutRunningSlot measures slot for the current page. The {page} and {nb}
+// fields are written with the aliases replaced when the document is output,
+// so they are measured with the label of the page and the number of pages
+// expected: the pages so far, or the pages found by the first pass of
+// RelayoutPageRefs().
+func (f *Fpdf) layoutRunningSlot(slot *RunningSlot, spec *RunningSpec, familyStr string, sizePt float64) (ls runningSlotType, err error) {
+	ls.slot = slot
+	pageStr := f.pageLabel(f.page)
+	nbStr := strconv.Itoa(f.PageCount())
+	if f.pageCountHint > f.PageCount() {
+		nbStr = strconv.Itoa(f.pageCountHint)
+	}
+	for _, run := range slot.Runs {
+		if run.FontStyle == "" {
+			run.FontStyle = spec.FontStyle
+		}
+		if run.FontSize == 0 {
+			run.FontSize = sizePt
+		}
+		if run.Color == nil {
+			run.Color = spec.Color
+		}
+		if err = f.SetFont(familyStr, run.FontStyle, run.FontSize); err != nil {
+			return
+		}
+		wd := f.GetStringWidth(f.runningText(run.Text, spec, pageStr, nbStr))
+		run.Text = f.runningText(run.Text, spec, f.aliasPageNoStr, f.aliasNbPagesStr)
+		ls.width += wd
+		ls.size = math.Max(ls.size, f.fontSize)
+		ls.runs = append(ls.runs, run)
+		ls.runWds = append(ls.runWds, wd)
+	}
+	return
+}
+
+// putRunningBand draws the band of spec on the current page, at the top of
+// the page for a header, at the bottom for a footer
+func (f *Fpdf) putRunningBand(spec *RunningSpec, header bool) (err error) {
+	band := spec.band(f)
+	ps := f.pageState()
+	familyStr := spec.FontFamily
+	if familyStr == "" {
+		familyStr = ps.familyStr
+	}
+	sizePt := spec.FontSize
+	if sizePt == 0 {
+		sizePt = ps.fontSizePt
+	}
+	slots := []*RunningSlot{&band.Left, &band.Center, &band.Right}
+	layouts := make([]runningSlotType, len(slots))
+	for j, slot := range slots {
+		if len(slot.Runs) > 0 && familyStr == "" {
+			return fmt.Errorf("a font must be set to write running headers and footers")
+		}
+		if layouts[j], err = f.layoutRunningSlot(slot, spec, familyStr, sizePt); err != nil {
+			return
+		}
+	}
+	// Height of the band, from its text and its images
+	ht := spec.Height
+	if ht == 0 {
+		ht = 1.2 * sizePt / f.k
+		for _, ls := range layouts {
+			ht = math.Max(ht, 1.2*ls.size)
+		}
+	}
+	gap := 0.3 * sizePt / f.k
+	for j := range layouts {
+		ls := &layouts[j]
+		if ls.slot.Image == "" {
+			continue
+		}
+		var info *ImageInfoType
+		if info, err = f.RegisterImageOptions(ls.slot.Image, ImageOptions{}); err != nil {
+			return
+		}
+		ls.imgHt = ls.slot.ImageHeight
+		if ls.imgHt == 0 {
+			ls.imgHt = ht
+		}
+		ls.imgWd = ls.imgHt * info.Width() / info.Height()
+		ls.width += ls.imgWd
+		if len(ls.runs) > 0 {
+			ls.width += gap
+		}
+		if spec.Height == 0 {
+			ht = math.Max(ht, ls.imgHt)
+		}
+	}
+	// Position of the band
+	var y float64
+	switch {
+	case header && spec.Offset > 0:
+		y = spec.Offset
+	case header:
+		y = (f.tMargin - ht) / 2
+	case spec.Offset > 0:
+		y = f.h - spec.Offset - ht
+	default:
+		y = f.h - (f.bMargin+ht)/2
+	}
+	left, right := f.lMargin, f.w-f.rMargin
+	for j, ls := range layouts {
+		x := left
+		switch j {
+		case 1:
+			x = (left + right - ls.width) / 2
+		case 2:
+			x = right - ls.width
+		}
+		if ls.imgWd > 0 {
+			err = f.ImageOptions(ls.slot.Image, x, y+(ht-ls.imgHt)/2, ls.imgWd, ls.imgHt, false, ImageOptions{}, 0, "")
+			if err != nil {
+				return
+			}
+			x += ls.imgWd + gap
+		}
+		baseline := y + .5*ht + .3*ls.size
+		for k, run := range ls.runs {
+			if err = f.SetFont(familyStr, run.FontStyle, run.FontSize); err != nil {
+				return
+			}
+			if run.Color != nil {
+				run.Color.ToTextColor(f)
+			}
+			f.Text(x, baseline, run.Text)
+			x += ls.runWds[k]
+			f.color.text = ps.text
+			f.colorFlag = ps.colorFlag
+		}
+	}
+	if band.Rule.Width > 0 {
+		ruleGap := spec.RuleGap
+		if ruleGap == 0 {
+			ruleGap = ht / 4
+		}
+		// A double rule doubles towards the body of the page
+		if header {
+			f.drawCellBox(left, y+ht+ruleGap, right-left, 0, &CellStyle{Top: band.Rule})
+		} else {
+			f.drawCellBox(left, y-ruleGap, right-left, 0, &CellStyle{Bottom: band.Rule})
+		}
+	}
+	if ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	return
+}
+
+// putRunningBands draws the running header and footer, if any, on the
+// current page
+func (f *Fpdf) putRunningBands() {
+	if f.runningHeader != nil {
+		f.SetError(f.putRunningBand(f.runningHeader, true))
+	}
+	if f.runningFooter != nil {
+		f.SetError(f.putRunningBand(f.runningFooter, false))
+	}
+}
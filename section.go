@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page number styles of SectionOptions, named after the /PageLabels styles of
+// the PDF specification
+const (
+	NumberDecimal    = "D" // 1, 2, 3...
+	NumberUpperRoman = "R" // I, II, III...
+	NumberLowerRoman = "r" // i, ii, iii...
+	NumberUpperAlpha = "A" // A, B, C... AA, BB...
+	NumberLowerAlpha = "a" // a, b, c... aa, bb...
+	NumberNone       = "-" // prefix only
+)
+
+// SectionOptions defines the pages of a section started by BeginSection().
+type SectionOptions struct {
+	OrientationStr string   // Orientation of the pages ("P" or "L"), default orientation if empty
+	Size           SizeType // Size of the pages in user units, default size if zero
+	LeftMargin     float64  // Left margin in user units, current margin if zero
+	TopMargin      float64  // Top margin in user units, current margin if zero
+	RightMargin    float64  // Right margin in user units, current margin if zero
+	BottomMargin   float64  // Bottom margin in user units, current margin if zero
+	Header         func()   // Header of the pages, none if nil
+	FirstHeader    func()   // Header of the first page, Header if nil
+	EvenHeader     func()   // Header of the even numbered pages, Header if nil
+	Footer         func()   // Footer of the pages, none if nil
+	FirstFooter    func()   // Footer of the first page, Footer if nil
+	EvenFooter     func()   // Footer of the even numbered pages, Footer if nil
+	NumberStyle    string   // Style of the page numbers, NumberDecimal if empty
+	NumberPrefix   string   // Text written before the page numbers, such as "A-"
+	FirstNumber    int      // Number of the first page, 1 if zero
+}
+
+type sectionType struct {
+	opts      SectionOptions
+	firstPage int // one-based number of the first page of the section
+}
+
+// BeginSection starts a new section on a new page. Each section has its own
+// page size and orientation, margins, header and footer functions and page
+// numbering. The header and footer functions replace the ones set with
+// SetHeaderFunc() and SetFooterFunc(); the first page of the section and its
+// even numbered pages can have their own variants.
+//
+// The page number of a page is its number within its section, formatted with
+// the number style and prefix of the section, as in "iii" or "A-2". It is the
+// number shown by PDF viewers, which is written in the /PageLabels of the
+// document, and the replacement of the alias set with AliasPageNo(). The
+// alias set with AliasSectionNbPages() is replaced by the number of pages of
+// the section, so that a footer can read "Page {pn} of {snb}".
+func (f *Fpdf) BeginSection(opts SectionOptions) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	switch opts.NumberStyle {
+	case "":
+		opts.NumberStyle = NumberDecimal
+	case NumberDecimal, NumberUpperRoman, NumberLowerRoman, NumberUpperAlpha, NumberLowerAlpha, NumberNone:
+	default:
+		f.err = fmt.Errorf("unknown page number style %q", opts.NumberStyle)
+		return f.err
+	}
+	if opts.FirstNumber < 1 {
+		opts.FirstNumber = 1
+	}
+	if opts.OrientationStr == "" {
+		opts.OrientationStr = f.defOrientation
+	}
+	if opts.Size.Wd == 0 || opts.Size.Ht == 0 {
+		opts.Size = f.defPageSize
+	}
+	if f.aliasPageNoStr == "" {
+		f.AliasPageNo("")
+	}
+	if f.aliasSectNbStr == "" {
+		f.AliasSectionNbPages("")
+	}
+	f.nextSection = &sectionType{opts: opts}
+	return f.AddPageFormat(opts.OrientationStr, opts.Size)
+}
+
+// AliasSectionNbPages defines an alias for the number of pages of the current
+// section. It will be substituted as the document is closed. An empty string
+// is replaced with the default alias "{snb}". BeginSection() sets the default
+// alias if none was defined.
+func (f *Fpdf) AliasSectionNbPages(aliasStr string) {
+	if aliasStr == "" {
+		aliasStr = "{snb}"
+	}
+	f.aliasSectNbStr = aliasStr
+}
+
+// startsection makes the section set by BeginSection() the current one. It is
+// called by AddPageFormat() between the end of the previous page and the
+// beginning of the first page of the section.
+func (f *Fpdf) startsection() {
+	sec := f.nextSection
+	f.nextSection = nil
+	sec.firstPage = f.page + 1
+	f.sections = append(f.sections, sec)
+	opts := sec.opts
+	left, top, right, bottom := f.GetMargins()
+	if opts.LeftMargin != 0 {
+		left = opts.LeftMargin
+	}
+	if opts.TopMargin != 0 {
+		top = opts.TopMargin
+	}
+	if opts.RightMargin != 0 {
+		right = opts.RightMargin
+	}
+	if opts.BottomMargin != 0 {
+		bottom = opts.BottomMargin
+	}
+	f.SetMargins(left, top, right)
+	f.SetAutoPageBreak(f.autoPageBreak, bottom)
+	f.headerFnc = func() {
+		if fnc := sec.variant(f.page, opts.Header, opts.FirstHeader, opts.EvenHeader); fnc != nil {
+			fnc()
+		}
+	}
+	f.footerFnc = func() {
+		if fnc := sec.variant(f.page, opts.Footer, opts.FirstFooter, opts.EvenFooter); fnc != nil {
+			fnc()
+		}
+	}
+	f.footerFncLpi = nil
+}
+
+// variant returns the header or footer function of the section for the
+// one-based page pageNum
+func (sec *sectionType) variant(pageNum int, fnc, first, even func()) func() {
+	switch {
+	case pageNum == sec.firstPage && first != nil:
+		return first
+	case sec.number(pageNum)%2 == 0 && even != nil:
+		return even
+	}
+	return fnc
+}
+
+// number returns the page number of pageNum within the section
+func (sec *sectionType) number(pageNum int) int {
+	return sec.opts.FirstNumber + pageNum - sec.firstPage
+}
+
+// sectionOf returns the section of the one-based page pageNum and the page
+// that follows its last page, or nil if the page precedes the first section
+func (f *Fpdf) sectionOf(pageNum int) (sec *sectionType, end int) {
+	end = len(f.pages)
+	for j := len(f.sections) - 1; j >= 0; j-- {
+		if f.sections[j].firstPage <= pageNum {
+			return f.sections[j], end
+		}
+		end = f.sections[j].firstPage
+	}
+	return nil, end
+}
+
+// formatPageNumber returns n written in the page number style styleStr
+func formatPageNumber(n int, styleStr string) string {
+	switch styleStr {
+	case NumberUpperRoman:
+		return strings.ToUpper(romanNumber(n))
+	case NumberLowerRoman:
+		return romanNumber(n)
+	case NumberUpperAlpha:
+		return strings.ToUpper(alphaNumber(n))
+	case NumberLowerAlpha:
+		return alphaNumber(n)
+	case NumberNone:
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// romanNumber returns n in lower case roman numerals
+func romanNumber(n int) string {
+	var b strings.Builder
+	for _, r := range []struct {
+		value  int
+		digits string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+		{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	} {
+		for ; n >= r.value; n -= r.value {
+			b.WriteString(r.digits)
+		}
+	}
+	return b.String()
+}
+
+// alphaNumber returns n in lower case letters the way PDF viewers do: a to z,
+// then aa to zz, and so on
+func alphaNumber(n int) string {
+	if n < 1 {
+		return ""
+	}
+	return strings.Repeat(string(rune('a'+(n-1)%26)), (n-1)/26+1)
+}
+
+// putpagelabels writes the /PageLabels entry of the catalog
+func (f *Fpdf) putpagelabels() {
+	if len(f.sections) == 0 {
+		return
+	}
+	var s fmtBuffer
+	s.printf("/PageLabels <</Nums [")
+	if f.sections[0].firstPage > 1 {
+		s.printf("0 <</S /D>> ")
+	}
+	for _, sec := range f.sections {
+		s.printf("%d <<", sec.firstPage-1)
+		if sec.opts.NumberStyle != NumberNone {
+			s.printf("/S /%s ", sec.opts.NumberStyle)
+		}
+		if sec.opts.NumberPrefix != "" {
+			s.printf("/P %s ", f.textstring(sec.opts.NumberPrefix))
+		}
+		if sec.opts.FirstNumber != 1 {
+			s.printf("/St %d", sec.opts.FirstNumber)
+		}
+		s.printf(">> ")
+	}
+	s.printf("]>>")
+	f.out(s.String())
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// softMaskType is an image whose luminosity masks the drawing that follows
+// ClipImage(), or the gray gradient of the opacity of a Gradient
+type softMaskType struct {
+	image   string     // SHA-1 checksum of the image
+	draw    string     // operators that draw the image
+	shading int        // gradient drawn instead of an image, 1-based
+	bbox    [4]float64 // placement of the image in points
+	objNum  int        // object number of the ExtGState
+}
+
+// ClipImage begins a clipping operation in which the opacity of rendering is
+// set by the luminosity of an image, from transparent at black to opaque at
+// white, which gives soft edges unlike the other clipping operations.
+// Rendering outside the image is hidden. The image, whose name and options
+// are those of ImageOptions(), is placed as with ImageOptions() but is not
+// drawn. Call ClipEnd() to restore unclipped operations.
+//
+// The ClipImage() example demonstrates this method.
+func (f *Fpdf) ClipImage(imageNameStr string, x, y, w, h float64, options ImageOptions) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	info, err := f.RegisterImageOptions(imageNameStr, options)
+	if err != nil {
+		return
+	}
+	w, h = f.imageExtent(info, w, h)
+	if x < 0 && !options.AllowNegativePosition {
+		x = f.x
+	}
+	if len(f.softMasks) == 0 {
+		f.softMasks = make([]softMaskType, 1)
+	}
+	bottom := (f.h - (y + h)) * f.k
+	f.softMasks = append(f.softMasks, softMaskType{
+		image: info.i,
+		draw:  f.imageDo(info, x, y, w, h),
+		bbox:  [4]float64{x * f.k, bottom, (x + w) * f.k, bottom + h*f.k},
+	})
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	f.clipNest++
+	f.outf("q /SM%d gs", len(f.softMasks)-1)
+	return
+}
+
+// registerMaskedImage registers the image imgName with the soft mask of
+// options, after registering it without mask with register
+func (f *Fpdf) registerMaskedImage(imgName string, options ImageOptions, register func(options ImageOptions) (*ImageInfoType, error)) (info *ImageInfoType, err error) {
+	maskName := options.MaskImage
+	key := options.imageKey(imgName)
+	options.MaskImage = ""
+	baseKey := options.imageKey(imgName)
+	_, registered := f.images[baseKey]
+	base, err := register(options)
+	if err != nil {
+		return
+	}
+	if !registered {
+		// The image without mask is not written unless placed too
+		delete(f.images, baseKey)
+	}
+	mask, err := f.maskImage(maskName, base.ornt)
+	if err != nil {
+		f.err = err
+		return
+	}
+	masked := *base
+	masked.smask = nil
+	masked.mask = mask.i
+	if masked.i, err = generateImageID(&masked); err != nil {
+		f.err = err
+		return
+	}
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	f.images[key] = &masked
+	return &masked, nil
+}
+
+// maskImage returns the image maskName, registered or in a file, or the gray
+// image made from it, to be used as a soft mask. A soft mask covers the pixels
+// of the image it masks as they are stored, so the mask is turned the way the
+// pixels of an image with the EXIF orientation ornt are.
+func (f *Fpdf) maskImage(maskName string, ornt int) (*ImageInfoType, error) {
+	info, err := f.RegisterImageOptions(maskName, ImageOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if ornt < 1 {
+		ornt = 1
+	}
+	if info.cs == "DeviceGray" && len(info.smask) == 0 && len(info.trns) == 0 && len(info.icc) == 0 && info.ornt <= 1 && ornt == 1 {
+		return info, nil
+	}
+	key := maskName + "#gray"
+	if ornt > 1 {
+		key += strconv.Itoa(ornt)
+	}
+	if gray, ok := f.images[key]; ok {
+		return gray, nil
+	}
+	px, err := info.pixels()
+	if err != nil {
+		return nil, err
+	}
+	if px == nil {
+		return nil, fmt.Errorf("image %s cannot be used as a soft mask", maskName)
+	}
+	if info.ornt > 1 {
+		px = px.orient(info.ornt)
+	}
+	// The quarter turns are the only orientations that are not their own
+	// inverse
+	switch ornt {
+	case 6:
+		px = px.orient(8)
+	case 8:
+		px = px.orient(6)
+	default:
+		if ornt > 1 {
+			px = px.orient(ornt)
+		}
+	}
+	// The alpha channel of the mask, if any, is dropped
+	data, _ := px.gray().split()
+	gray := f.newImageInfo()
+	gray.w, gray.h = float64(px.w), float64(px.h)
+	gray.cs = "DeviceGray"
+	gray.bpc = 8
+	if err = f.encodeImage(gray, data, nil, 1, GoImageOptions{}); err != nil {
+		return nil, err
+	}
+	if gray.i, err = generateImageID(gray); err != nil {
+		return nil, err
+	}
+	f.images[key] = gray
+	return gray, nil
+}
+
+// imageObjNum returns the object number of the image with the SHA-1 checksum
+// i, 0 if it is not written yet
+func (f *Fpdf) imageObjNum(i string) int {
+	for _, info := range f.images {
+		if info.i == i && info.n > 0 {
+			return info.n
+		}
+	}
+	return 0
+}
+
+// putSoftMasks writes the ExtGState of each image of ClipImage() and each
+// opacity of DrawGradient(), with the transparency group that draws it
+func (f *Fpdf) putSoftMasks() {
+	for j := 1; j < len(f.softMasks); j++ {
+		sm := &f.softMasks[j]
+		f.newobj()
+		content := []byte(sm.draw)
+		if sm.shading > 0 {
+			content = []byte(sprintf("/Sh%d sh", sm.shading))
+		}
+		filter := ""
+		if f.compress {
+			content = sliceCompress(content)
+			filter = "/Filter /FlateDecode "
+		}
+		f.outf("<</Type /XObject /Subtype /Form /BBox [%.5f %.5f %.5f %.5f]", sm.bbox[0], sm.bbox[1], sm.bbox[2], sm.bbox[3])
+		f.out("/Group <</S /Transparency /CS /DeviceGray>>")
+		if sm.shading > 0 {
+			f.outf("/Resources <</Shading <</Sh%d %d 0 R>>>>", sm.shading, f.gradientList[sm.shading].objNum)
+		} else {
+			f.outf("/Resources <</XObject <</I%s %d 0 R>>>>", sm.image, f.imageObjNum(sm.image))
+		}
+		f.outf("%s/Length %d>>", filter, len(content))
+		f.putstream(content)
+		f.out("endobj")
+		f.newobj()
+		sm.objNum = f.n
+		f.outf("<</Type /ExtGState /SMask <</Type /Mask /S /Luminosity /G %d 0 R>>>>", f.n-1)
+		f.out("endobj")
+	}
+}
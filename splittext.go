@@ -25,7 +25,9 @@ func (f *Fpdf) SplitText(txt string, w float64) (lines []string) {
 	l := 0
 	for i < nb {
 		c := s[i]
-		l += cw[c]
+		if !isPageRefMark(c) {
+			l += cw[c]
+		}
 		if unicode.IsSpace(c) || isChinese(c) {
 			sep = i
 		}
@@ -51,3 +53,16 @@ func (f *Fpdf) SplitText(txt string, w float64) (lines []string) {
 	}
 	return lines
 }
+
+// splitCellText splits txt into lines that fit in a cell of width w, taking
+// the cell margins into account. It works with both UTF-8 and codepage-based
+// fonts.
+func (f *Fpdf) splitCellText(txt string, w float64) (lines []string) {
+	if f.isCurrentUTF8 {
+		return f.SplitText(txt, w-2*f.cMargin)
+	}
+	for _, line := range f.SplitLines([]byte(txt), w) {
+		lines = append(lines, string(line))
+	}
+	return
+}
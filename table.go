@@ -0,0 +1,723 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TableSpec defines the layout of a table written by a TableBuilder. Zero
+// values are replaced by defaults.
+type TableSpec struct {
+	Width        float64       // Width of the table in user units, the room left up to the right margin if zero
+	Columns      []TableColumn // Columns of the table, auto-fitted if missing
+	HeaderRows   int           // Number of leading rows forming the header, repeated at the top of each page
+	LineHeight   float64       // Height of a text line in user units, 1.2 times the font size if zero
+	Padding      float64       // Padding of the cells in user units, the cell margin if zero; unused with a theme
+	Border       string        // Borders of the cells as in CellFormat(), "1" if empty, "0" for none; unused with a theme
+	HeaderFill   *Color        // Background of the header cells, none if nil
+	KeepTogether bool          // Start the table on a new page rather than split it, when it fits on one page
+	Theme        *TableTheme   // Styles of the rows, the ones set by Padding and Border if nil
+}
+
+// TableColumn defines a column of a table
+type TableColumn struct {
+	Width float64 // Width of the column in user units, fitted to the content if zero
+	Align string  // Alignment of the cells as in CellFormat(), "LM" if empty
+}
+
+// TableCell is a cell of a table. Zero values are replaced by the defaults
+// of the table.
+type TableCell struct {
+	Text      string        // Text of the cell, wrapped to the width of the cell; "\n" breaks lines
+	ColSpan   int           // Number of columns taken by the cell, 1 if zero
+	RowSpan   int           // Number of rows taken by the cell, 1 if zero
+	Align     string        // Horizontal ("L", "C", "R") and vertical ("T", "M", "B") alignment, column alignment if empty
+	Padding   float64       // Padding in user units, padding of the table if zero, none if negative
+	Fill      *Color        // Background, none if nil
+	TextColor *Color        // Text color, current text color if nil
+	FontStyle string        // Font style ("B", "I"...), regular or bold in header rows if empty
+	Style     *CellStyle    // Style of the cell replacing the style of its row, which the fields above still override
+	Content   []CellContent // Blocks stacked below the text: images, styled text, lists, drawings or nested tables
+}
+
+// TableRow is a row of a table
+type TableRow struct {
+	Cells        []TableCell
+	MinHeight    float64 // Minimum height of the row in user units
+	KeepWithNext bool    // Never break the page between this row and the next one
+}
+
+// TableBuilder collects the rows of a table and writes it with Write().
+type TableBuilder struct {
+	f    *Fpdf
+	spec TableSpec
+	rows []TableRow
+}
+
+// NewTableBuilder returns a builder for a table laid out as specified by
+// spec.
+func (f *Fpdf) NewTableBuilder(spec TableSpec) *TableBuilder {
+	return &TableBuilder{f: f, spec: spec}
+}
+
+// AddRow adds a row made of cells with the specified texts.
+func (tb *TableBuilder) AddRow(texts ...string) *TableBuilder {
+	cells := make([]TableCell, len(texts))
+	for j, txt := range texts {
+		cells[j].Text = txt
+	}
+	return tb.AddTableRow(TableRow{Cells: cells})
+}
+
+// AddCells adds a row made of the specified cells.
+func (tb *TableBuilder) AddCells(cells ...TableCell) *TableBuilder {
+	return tb.AddTableRow(TableRow{Cells: cells})
+}
+
+// AddTableRow adds the specified row.
+func (tb *TableBuilder) AddTableRow(row TableRow) *TableBuilder {
+	tb.rows = append(tb.rows, row)
+	return tb
+}
+
+// KeepWithNext prevents a page break between the last row added and the next
+// one.
+func (tb *TableBuilder) KeepWithNext() *TableBuilder {
+	if n := len(tb.rows); n > 0 {
+		tb.rows[n-1].KeepWithNext = true
+	}
+	return tb
+}
+
+// Write writes the table at the current position and moves the current
+// position below it, at the left of the table.
+//
+// Cell texts are wrapped and each row is as high as its tallest cell. When
+// the table does not fit on the current page, it is broken between rows,
+// never inside a row, a group of rows joined by a row span or rows kept
+// together with KeepWithNext(), and the header rows are repeated at the top
+// of the next page. Only a row taller than a page is split, between the
+// lines of text and the content blocks of its cells; a content block taller
+// than a page, or rows joined by a row span taller than a page, are an
+// error. Rows kept together that are taller than a page are broken between
+// them.
+func (tb *TableBuilder) Write() (err error) {
+	f := tb.f
+	if f.err != nil {
+		return f.err
+	}
+	t, err := f.layoutTable(tb.spec, tb.rows)
+	if err == nil {
+		err = f.drawTable(t)
+	}
+	f.SetError(err)
+	return
+}
+
+// tableCellType is a cell placed in the grid of a table
+type tableCellType struct {
+	TableCell
+	row, col int
+	style    CellStyle
+	lines    []string
+	blocks   []float64 // height of each content block
+	height   float64   // height of the content, padding included
+}
+
+// tableType is a table laid out by layoutTable()
+type tableType struct {
+	spec    TableSpec
+	x       float64
+	widths  []float64
+	heights []float64          // height of each row
+	cells   [][]*tableCellType // cells starting in each row
+	keep    []bool             // no page break after each row
+	line    BorderSide         // cell border without a theme
+}
+
+// cellStyle returns the style of cell c of row r: the style of the cell, of
+// its row in the theme or made of the padding and borders of the table, as
+// overridden by the fields of the cell
+func (t *tableType) cellStyle(c *TableCell, r int) (s CellStyle) {
+	header := t.spec.HeaderRows
+	switch {
+	case c.Style != nil:
+		s = *c.Style
+	case t.spec.Theme != nil:
+		s = t.spec.Theme.RowStyle(r, len(t.cells), header)
+	default:
+		pad := t.spec.Padding
+		s.Padding = CellPadding{pad, pad, pad, pad}
+		border := strings.ToUpper(t.spec.Border)
+		if border == "1" {
+			border = "LTRB"
+		}
+		for _, side := range []struct {
+			b   *BorderSide
+			str string
+		}{{&s.Left, "L"}, {&s.Top, "T"}, {&s.Right, "R"}, {&s.Bottom, "B"}} {
+			if strings.Contains(border, side.str) {
+				*side.b = t.line
+			}
+		}
+		if r < header {
+			s.FontStyle = "B"
+		}
+	}
+	if r < header && t.spec.HeaderFill != nil {
+		s.Fill = t.spec.HeaderFill
+	}
+	switch {
+	case c.Padding < 0:
+		s.Padding = CellPadding{}
+	case c.Padding > 0:
+		s.Padding = CellPadding{c.Padding, c.Padding, c.Padding, c.Padding}
+	}
+	if c.Fill != nil {
+		s.Fill = c.Fill
+	}
+	if c.TextColor != nil {
+		s.TextColor = c.TextColor
+	}
+	if c.FontStyle != "" {
+		s.FontStyle = c.FontStyle
+	}
+	return
+}
+
+// layoutTable places the cells of rows in a grid, computes the width of the
+// columns and the height of the rows.
+func (f *Fpdf) layoutTable(spec TableSpec, rows []TableRow) (t *tableType, err error) {
+	if f.page == 0 || f.fontFamily == "" {
+		return nil, fmt.Errorf("a page and a font must be set before writing a table")
+	}
+	if spec.LineHeight == 0 {
+		spec.LineHeight = 1.2 * f.fontSize
+	}
+	if spec.Padding == 0 {
+		spec.Padding = f.cMargin
+	}
+	if spec.Border == "" {
+		spec.Border = "1"
+	}
+	t = &tableType{spec: spec, x: f.x}
+	t.line = BorderSide{Width: f.lineWidth, Color: NewColor().FromDrawColor(f)}
+	if spec.Width == 0 {
+		spec.Width = f.w - f.rMargin - f.x
+		t.spec.Width = spec.Width
+	}
+
+	// Place the cells, skipping the slots taken by row spans from above
+	var taken []map[int]bool
+	cols := len(spec.Columns)
+	t.cells = make([][]*tableCellType, len(rows))
+	t.keep = make([]bool, len(rows))
+	for r, row := range rows {
+		for len(taken) <= r {
+			taken = append(taken, make(map[int]bool))
+		}
+		t.keep[r] = row.KeepWithNext
+		col := 0
+		for _, cell := range row.Cells {
+			if cell.ColSpan < 1 {
+				cell.ColSpan = 1
+			}
+			if cell.RowSpan < 1 {
+				cell.RowSpan = 1
+			}
+			if cell.RowSpan > len(rows)-r {
+				cell.RowSpan = len(rows) - r
+			}
+			for taken[r][col] {
+				col++
+			}
+			c := &tableCellType{TableCell: cell, row: r, col: col}
+			t.cells[r] = append(t.cells[r], c)
+			for j := r; j < r+cell.RowSpan; j++ {
+				for len(taken) <= j {
+					taken = append(taken, make(map[int]bool))
+				}
+				for k := col; k < col+cell.ColSpan; k++ {
+					taken[j][k] = true
+				}
+			}
+			col += cell.ColSpan
+			if col > cols {
+				cols = col
+			}
+		}
+	}
+	if cols == 0 {
+		return nil, fmt.Errorf("table has no column")
+	}
+	for r := range t.cells {
+		for _, c := range t.cells[r] {
+			c.style = t.cellStyle(&c.TableCell, r)
+		}
+	}
+
+	ps := f.pageState()
+	cMargin := f.cMargin
+	defer func() {
+		f.cMargin = cMargin
+		if ps.familyStr != "" {
+			if fontErr := f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt); err == nil {
+				err = fontErr
+			}
+		}
+	}()
+	f.cMargin = 0
+	t.widths = f.tableWidths(t, cols)
+
+	// Wrap the texts and size the rows, then enlarge the last row spanned by
+	// the cells taller than the rows they span
+	t.heights = make([]float64, len(rows))
+	for r, row := range rows {
+		t.heights[r] = row.MinHeight
+	}
+	for r := range t.cells {
+		for _, c := range t.cells[r] {
+			if err = f.SetFont(ps.familyStr, c.style.FontStyle, ps.fontSizePt); err != nil {
+				return
+			}
+			pad := c.style.Padding
+			w := t.spanWidth(c) - pad.Left - pad.Right
+			if c.Text != "" || len(c.Content) == 0 {
+				c.lines = f.splitCellText(c.Text, w)
+			}
+			c.height = float64(len(c.lines))*spec.LineHeight + pad.Top + pad.Bottom
+			for _, b := range c.Content {
+				var h float64
+				if h, err = b.ContentHeight(f, w); err != nil {
+					return
+				}
+				c.blocks = append(c.blocks, h)
+				c.height += h
+			}
+			if c.RowSpan == 1 && c.height > t.heights[r] {
+				t.heights[r] = c.height
+			}
+		}
+	}
+	for r := range t.cells {
+		for _, c := range t.cells[r] {
+			if c.RowSpan > 1 {
+				if h := t.spanHeight(c); c.height > h {
+					t.heights[r+c.RowSpan-1] += c.height - h
+				}
+			}
+		}
+	}
+	return
+}
+
+// tableWidths returns the widths of the columns. The columns without an
+// explicit width share the room left in proportion to their content.
+func (f *Fpdf) tableWidths(t *tableType, cols int) []float64 {
+	widths := make([]float64, cols)
+	natural := make([]float64, cols)
+	minimal := make([]float64, cols)
+	room := t.spec.Width
+	for j := 0; j < cols; j++ {
+		if j < len(t.spec.Columns) && t.spec.Columns[j].Width > 0 {
+			widths[j] = t.spec.Columns[j].Width
+			room -= widths[j]
+		}
+	}
+	ps := f.pageState()
+	for r := range t.cells {
+		for _, c := range t.cells[r] {
+			if c.ColSpan > 1 || widths[c.col] > 0 {
+				continue
+			}
+			f.SetFont(ps.familyStr, c.style.FontStyle, ps.fontSizePt)
+			pad := c.style.Padding.Left + c.style.Padding.Right
+			for _, line := range strings.Split(c.Text, "\n") {
+				natural[c.col] = math.Max(natural[c.col], f.GetStringWidth(line)+pad)
+				for _, word := range strings.Fields(line) {
+					minimal[c.col] = math.Max(minimal[c.col], f.GetStringWidth(word)+pad)
+				}
+			}
+			for _, b := range c.Content {
+				if mw, ok := b.(contentMinWidth); ok {
+					natural[c.col] = math.Max(natural[c.col], mw.minWidth()+pad)
+					minimal[c.col] = math.Max(minimal[c.col], mw.minWidth()+pad)
+				}
+			}
+		}
+	}
+	var auto []int
+	var sumNatural, sumMinimal float64
+	for j := 0; j < cols; j++ {
+		if widths[j] == 0 {
+			auto = append(auto, j)
+			// Empty columns still get some room
+			natural[j] = math.Max(natural[j], 2*f.fontSize)
+			minimal[j] = math.Max(minimal[j], f.fontSize)
+			sumNatural += natural[j]
+			sumMinimal += minimal[j]
+		}
+	}
+	for _, j := range auto {
+		switch {
+		case room <= 0:
+			widths[j] = minimal[j]
+		case sumNatural <= room:
+			widths[j] = natural[j] * room / sumNatural
+		case sumMinimal >= room:
+			widths[j] = minimal[j] * room / sumMinimal
+		default:
+			widths[j] = minimal[j] + (room-sumMinimal)*(natural[j]-minimal[j])/(sumNatural-sumMinimal)
+		}
+	}
+	return widths
+}
+
+// spanWidth returns the width of the columns spanned by c
+func (t *tableType) spanWidth(c *tableCellType) (w float64) {
+	for j := c.col; j < c.col+c.ColSpan && j < len(t.widths); j++ {
+		w += t.widths[j]
+	}
+	return
+}
+
+// spanHeight returns the height of the rows spanned by c
+func (t *tableType) spanHeight(c *tableCellType) (h float64) {
+	for j := c.row; j < c.row+c.RowSpan; j++ {
+		h += t.heights[j]
+	}
+	return
+}
+
+// blockEnd returns the row that follows the block of rows beginning with
+// row r. A block is never split across pages.
+func (t *tableType) blockEnd(r int) int {
+	end := r + 1
+	for j := r; j < end; j++ {
+		for _, c := range t.cells[j] {
+			if j+c.RowSpan > end {
+				end = j + c.RowSpan
+			}
+		}
+		if t.keep[j] && j+1 < len(t.cells) && j+1 >= end {
+			end = j + 2
+		}
+	}
+	return end
+}
+
+// rowsHeight returns the height of rows from to to, excluded
+func (t *tableType) rowsHeight(from, to int) (h float64) {
+	for j := from; j < to; j++ {
+		h += t.heights[j]
+	}
+	return
+}
+
+// drawTable writes a table laid out by layoutTable()
+func (f *Fpdf) drawTable(t *tableType) (err error) {
+	ps := f.pageState()
+	fill := NewColor().FromFillColor(f)
+	text := NewColor().FromTextColor(f)
+	cMargin := f.cMargin
+	acceptPageBreak := f.acceptPageBreak
+	f.acceptPageBreak = func() bool {
+		return false
+	}
+	defer func() {
+		f.acceptPageBreak = acceptPageBreak
+		f.cMargin = cMargin
+		fill.ToFillColor(f)
+		text.ToTextColor(f)
+		if ps.familyStr != "" {
+			if fontErr := f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt); err == nil {
+				err = fontErr
+			}
+		}
+		f.x = t.x
+	}()
+
+	header := t.spec.HeaderRows
+	if header > len(t.cells) {
+		header = len(t.cells)
+	}
+	// Rows can be moved to the next page unless they are the first ones
+	// written on the page
+	onPage := 0
+	if f.y > f.tMargin+0.01 {
+		onPage = -1
+	}
+	newPage := func() error {
+		if !acceptPageBreak() {
+			return nil
+		}
+		onPage = 0
+		return f.AddPageFormat(f.curOrientation, f.curPageSize)
+	}
+	if t.spec.KeepTogether && onPage != 0 {
+		h := t.rowsHeight(0, len(t.cells))
+		if f.y+h > f.pageBreakTrigger && f.tMargin+h <= f.pageBreakTrigger {
+			if err = newPage(); err != nil {
+				return
+			}
+		}
+	}
+
+	// breakPage starts a new page for row r, with the header repeated
+	breakPage := func(r int) (err error) {
+		if err = newPage(); err == nil && r >= header && header > 0 {
+			err = f.drawTableRows(t, 0, header)
+		}
+		return
+	}
+	for r := 0; r < len(t.cells); {
+		end := t.blockEnd(r)
+		if r == 0 && header > 0 {
+			// The header is kept with the first block of rows below it
+			end = header
+			if header < len(t.cells) {
+				end = t.blockEnd(header)
+			}
+		}
+		// A block taller than a page is drawn row by row, and a row taller
+		// than a page is split
+		room := f.pageBreakTrigger - f.tMargin
+		if r >= header {
+			room -= t.rowsHeight(0, header)
+		}
+		if end-r > 1 && t.rowsHeight(r, end) > room {
+			if end, err = t.splitBlock(r, end); err != nil {
+				return
+			}
+		}
+		if f.y+t.rowsHeight(r, end) > f.pageBreakTrigger && onPage != 0 && t.rowsHeight(r, end) <= room {
+			if err = breakPage(r); err != nil {
+				return
+			}
+		}
+		if end-r == 1 && f.y+t.heights[r] > f.pageBreakTrigger {
+			err = f.drawSplitRow(t, r, onPage == 0, breakPage)
+		} else {
+			err = f.drawTableRows(t, r, end)
+		}
+		if err != nil {
+			return
+		}
+		onPage++
+		r = end
+	}
+	return
+}
+
+// splitBlock returns the end of the part of the block of rows from r to end
+// that is drawn first when the block is taller than a page: the header
+// alone, or row r alone when the block is made of rows kept together.
+// Rows joined by a row span cannot be split.
+func (t *tableType) splitBlock(r, end int) (int, error) {
+	if header := t.spec.HeaderRows; r == 0 && header > 0 && header < end {
+		return header, nil
+	}
+	for j := r; j < end; j++ {
+		for _, c := range t.cells[j] {
+			if c.RowSpan > 1 {
+				return 0, fmt.Errorf("table rows %d to %d, joined by a row span, are taller than the page", r+1, end)
+			}
+		}
+	}
+	return r + 1, nil
+}
+
+// drawSplitRow writes row r of t, taller than the room left on the page,
+// over as many pages as needed, starting each new page with breakPage().
+// The cells are split between their lines of text and their content
+// blocks, which are drawn from the top of each part of the row. fresh
+// reports whether the current page holds no row of t yet.
+func (f *Fpdf) drawSplitRow(t *tableType, r int, fresh bool, breakPage func(int) error) (err error) {
+	cells := t.cells[r]
+	next := make([]int, len(cells)) // first part of each cell still to draw
+	take := make([]int, len(cells))
+	room := f.pageBreakTrigger - f.y
+	drawn := 0.0
+	for {
+		height, done, progress := 0.0, true, false
+		for i, c := range cells {
+			pad := c.style.Padding
+			used, k := 0.0, next[i]
+			for ; k < c.parts(); k++ {
+				h := t.partHeight(c, k)
+				if used+h > room-pad.Top-pad.Bottom+0.01 {
+					break
+				}
+				used += h
+			}
+			take[i] = k
+			done = done && k == c.parts()
+			progress = progress || k > next[i]
+			height = math.Max(height, used+pad.Top+pad.Bottom)
+		}
+		if done {
+			// The last part of the row keeps what is left of its height
+			height = math.Max(height, math.Min(t.heights[r]-drawn, room))
+			return f.drawCellParts(t, r, next, take, height)
+		}
+		if progress {
+			if err = f.drawCellParts(t, r, next, take, height); err != nil {
+				return
+			}
+			copy(next, take)
+			drawn += height
+		} else if fresh {
+			return fmt.Errorf("content of table row %d taller than the page", r+1)
+		}
+		page := f.page
+		if err = breakPage(r); err != nil {
+			return
+		}
+		room = f.pageBreakTrigger - f.y
+		if f.page == page {
+			// Page breaks are refused: the rest of the row overflows the page
+			room = math.Inf(1)
+		}
+		fresh = true
+	}
+}
+
+// parts returns the number of lines of text and content blocks of c
+func (c *tableCellType) parts() int {
+	return len(c.lines) + len(c.blocks)
+}
+
+// partHeight returns the height of the line of text or content block k of c
+func (t *tableType) partHeight(c *tableCellType, k int) float64 {
+	if k < len(c.lines) {
+		return t.spec.LineHeight
+	}
+	return c.blocks[k-len(c.lines)]
+}
+
+// drawCellParts writes the parts of the cells of row r of t from from to
+// to, excluded, in boxes of height h at the current position, and moves the
+// current position below them.
+func (f *Fpdf) drawCellParts(t *tableType, r int, from, to []int, h float64) (err error) {
+	top := f.y
+	for i, c := range t.cells[r] {
+		x := t.x
+		for j := 0; j < c.col; j++ {
+			x += t.widths[j]
+		}
+		w := t.spanWidth(c)
+		f.drawCellBox(x, top, w, h, &c.style)
+		if err = f.drawCellContent(t, c, x, top+c.style.Padding.Top, w, from[i], to[i]); err != nil {
+			return
+		}
+	}
+	f.SetXY(t.x, top+h)
+	return
+}
+
+// drawTableRows writes the rows of t from to to, excluded, at the current
+// position and moves the current position below them.
+func (f *Fpdf) drawTableRows(t *tableType, from, to int) (err error) {
+	top := f.y
+	f.cMargin = 0
+	for r := from; r < to; r++ {
+		for _, c := range t.cells[r] {
+			x := t.x
+			for j := 0; j < c.col; j++ {
+				x += t.widths[j]
+			}
+			y := top + t.rowsHeight(from, r)
+			w, h := t.spanWidth(c), t.spanHeight(c)
+			f.drawCellBox(x, y, w, h, &c.style)
+			if c.parts() == 0 {
+				continue
+			}
+			pad := c.style.Padding
+			textHeight := 0.0
+			for k := 0; k < c.parts(); k++ {
+				textHeight += t.partHeight(c, k)
+			}
+			ty := y + pad.Top
+			switch {
+			case strings.Contains(c.align(t), "B"):
+				ty = y + h - pad.Bottom - textHeight
+			case strings.Contains(c.align(t), "T"):
+			default:
+				ty = y + pad.Top + (h-pad.Top-pad.Bottom-textHeight)/2
+			}
+			if err = f.drawCellContent(t, c, x, ty, w, 0, c.parts()); err != nil {
+				return
+			}
+		}
+	}
+	f.SetXY(t.x, top+t.rowsHeight(from, to))
+	return
+}
+
+// align returns the alignment of c, as in CellFormat()
+func (c *tableCellType) align(t *tableType) string {
+	align := c.Align
+	if align == "" && c.col < len(t.spec.Columns) {
+		align = t.spec.Columns[c.col].Align
+	}
+	if align == "" {
+		align = "LM"
+	}
+	return align
+}
+
+// drawCellContent writes the parts of c from from to to, excluded, the lines
+// of its text followed by its content blocks, from y down in the cell at x
+// of width w
+func (f *Fpdf) drawCellContent(t *tableType, c *tableCellType, x, y, w float64, from, to int) (err error) {
+	if from >= to {
+		return
+	}
+	ps := f.pageState()
+	text := NewColor().FromTextColor(f)
+	hAlign := "L"
+	switch align := c.align(t); {
+	case strings.Contains(align, "C"):
+		hAlign = "C"
+	case strings.Contains(align, "R"):
+		hAlign = "R"
+	}
+	pad := c.style.Padding
+	if err = f.SetFont(ps.familyStr, c.style.FontStyle, ps.fontSizePt); err != nil {
+		return
+	}
+	if c.style.TextColor != nil {
+		c.style.TextColor.ToTextColor(f)
+	}
+	for k := from; k < to; k++ {
+		if k < len(c.lines) {
+			f.SetXY(x+pad.Left, y)
+			err = f.CellFormat(w-pad.Left-pad.Right, t.spec.LineHeight, c.lines[k], "", 0, hAlign, false, 0, "")
+		} else {
+			err = c.Content[k-len(c.lines)].DrawContent(f, x+pad.Left, y, w-pad.Left-pad.Right)
+		}
+		if err != nil {
+			return
+		}
+		y += t.partHeight(c, k)
+	}
+	text.ToTextColor(f)
+	return
+}
@@ -0,0 +1,341 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"math"
+	"strings"
+)
+
+// CellContent is a block of content stacked below the text of a table cell,
+// in TableCell.Content. The table measures the height of each block before
+// placing the row that holds it, so that rows are split across pages only
+// when they are taller than a page, and then between blocks.
+// CellText, CellImage, CellList, CellDrawing and TableBuilder, for nested
+// tables, are content blocks; applications can write their own.
+type CellContent interface {
+	// ContentHeight returns the height of the block laid out in width w
+	ContentHeight(f *Fpdf, w float64) (h float64, err error)
+	// DrawContent draws the block at x and y, in width w
+	DrawContent(f *Fpdf, x, y, w float64) error
+}
+
+// contentMinWidth is implemented by the content blocks that need a minimal
+// width, which auto-fitted columns take into account
+type contentMinWidth interface {
+	minWidth() float64
+}
+
+// TextRun is a piece of text written with its own style
+type TextRun struct {
+	Text      string  // Text of the run; "\n" breaks lines
+	FontStyle string  // Font style ("B", "I", "U"...), regular if empty
+	FontSize  float64 // Font size in points, current size if zero
+	Color     *Color  // Text color, current text color if nil
+}
+
+// CellText is a content block of styled text runs wrapped to the width of
+// the cell.
+type CellText struct {
+	Runs       []TextRun
+	Align      string  // Horizontal alignment ("L", "C" or "R"), left if empty
+	LineHeight float64 // Height of the lines in user units, 1.2 times their largest font size if zero
+}
+
+// textSegmentType is a part of a run placed on a line
+type textSegmentType struct {
+	run  *TextRun
+	text string
+	x    float64 // offset from the start of the line
+}
+
+// textLineType is a line of laid out runs
+type textLineType struct {
+	segments []textSegmentType
+	width    float64
+	height   float64
+	size     float64 // largest font size of the line in user units
+}
+
+// useRun selects the font of run
+func (f *Fpdf) useRun(run *TextRun, ps pageStateType) error {
+	size := run.FontSize
+	if size == 0 {
+		size = ps.fontSizePt
+	}
+	return f.SetFont(ps.familyStr, run.FontStyle, size)
+}
+
+// layout wraps the runs of ct to width w
+func (ct *CellText) layout(f *Fpdf, w float64) (lines []textLineType, err error) {
+	ps := f.pageState()
+	defer func() {
+		if fontErr := f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt); err == nil {
+			err = fontErr
+		}
+	}()
+	line := textLineType{}
+	endLine := func() {
+		if line.size == 0 {
+			// Empty line, as high as the current font
+			line.size = ps.fontSizePt / f.k
+		}
+		line.height = ct.LineHeight
+		if line.height == 0 {
+			line.height = 1.2 * line.size
+		}
+		lines = append(lines, line)
+		line = textLineType{}
+	}
+	for j := range ct.Runs {
+		run := &ct.Runs[j]
+		if err = f.useRun(run, ps); err != nil {
+			return
+		}
+		space := f.GetStringWidth(" ")
+		for p, para := range strings.Split(run.Text, "\n") {
+			if p > 0 {
+				endLine()
+			}
+			for k, word := range strings.Split(para, " ") {
+				// Runs are joined without space, words of a run with one
+				sep := 0.0
+				if k > 0 && len(line.segments) > 0 {
+					sep = space
+				}
+				wd := f.GetStringWidth(word)
+				if len(line.segments) > 0 && line.width+sep+wd > w && word != "" {
+					endLine()
+					sep = 0
+				}
+				n := len(line.segments)
+				if n > 0 && line.segments[n-1].run == run {
+					if sep > 0 {
+						line.segments[n-1].text += " "
+					}
+					line.segments[n-1].text += word
+				} else if word != "" || sep > 0 {
+					text := word
+					if sep > 0 {
+						text = " " + word
+					}
+					line.segments = append(line.segments, textSegmentType{run: run, text: text, x: line.width})
+				}
+				line.width += sep + wd
+				line.size = math.Max(line.size, f.fontSize)
+			}
+		}
+	}
+	if len(line.segments) > 0 || len(lines) == 0 {
+		endLine()
+	}
+	return
+}
+
+// ContentHeight implements CellContent.
+func (ct *CellText) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	lines, err := ct.layout(f, w)
+	for _, line := range lines {
+		h += line.height
+	}
+	return
+}
+
+// DrawContent implements CellContent.
+func (ct *CellText) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	lines, err := ct.layout(f, w)
+	if err != nil {
+		return
+	}
+	ps := f.pageState()
+	for _, line := range lines {
+		dx := 0.0
+		switch {
+		case strings.Contains(ct.Align, "C"):
+			dx = (w - line.width) / 2
+		case strings.Contains(ct.Align, "R"):
+			dx = w - line.width
+		}
+		baseline := y + .5*line.height + .3*line.size
+		for _, seg := range line.segments {
+			if err = f.useRun(seg.run, ps); err != nil {
+				return
+			}
+			if seg.run.Color != nil {
+				seg.run.Color.ToTextColor(f)
+			}
+			f.Text(x+dx+seg.x, baseline, seg.text)
+			f.color.text = ps.text
+			f.colorFlag = ps.colorFlag
+		}
+		y += line.height
+	}
+	return f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+}
+
+// CellImage is a content block holding an image.
+type CellImage struct {
+	Name    string       // Name of the image file or of an image registered with RegisterImageOptionsReader()
+	Options ImageOptions // Options of the image, as in ImageOptions()
+	Width   float64      // Width of the image in user units, the width of the cell if zero and Height is zero too
+	Height  float64      // Height of the image in user units, keeping the aspect ratio if zero
+	Align   string       // Horizontal alignment ("L", "C" or "R"), left if empty
+}
+
+// size returns the size of the image in width w
+func (ci *CellImage) size(f *Fpdf, w float64) (wd, ht float64, err error) {
+	info, err := f.RegisterImageOptions(ci.Name, ci.Options)
+	if err != nil {
+		return
+	}
+	wd, ht = ci.Width, ci.Height
+	switch {
+	case wd == 0 && ht == 0:
+		wd = w
+		ht = wd * info.Height() / info.Width()
+	case wd == 0:
+		wd = ht * info.Width() / info.Height()
+	case ht == 0:
+		ht = wd * info.Height() / info.Width()
+	}
+	return
+}
+
+func (ci *CellImage) minWidth() float64 {
+	return ci.Width
+}
+
+// ContentHeight implements CellContent.
+func (ci *CellImage) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	_, h, err = ci.size(f, w)
+	return
+}
+
+// DrawContent implements CellContent.
+func (ci *CellImage) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	wd, ht, err := ci.size(f, w)
+	if err != nil {
+		return
+	}
+	switch {
+	case strings.Contains(ci.Align, "C"):
+		x += (w - wd) / 2
+	case strings.Contains(ci.Align, "R"):
+		x += w - wd
+	}
+	return f.ImageOptions(ci.Name, x, y, wd, ht, false, ci.Options, 0, "")
+}
+
+// CellList is a content block holding a list written by BulletedListXY().
+type CellList struct {
+	Items      []string
+	Level      int     // Level of the list, selecting the bullet
+	LineHeight float64 // Height of the items in user units, 1.2 times the font size if zero
+}
+
+// lineHeight returns the height of the items
+func (cl *CellList) lineHeight(f *Fpdf) float64 {
+	if cl.LineHeight < f.fontSize {
+		return 1.2 * f.fontSize
+	}
+	return cl.LineHeight
+}
+
+// ContentHeight implements CellContent.
+func (cl *CellList) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	return float64(len(cl.Items)) * cl.lineHeight(f), nil
+}
+
+// DrawContent implements CellContent.
+func (cl *CellList) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	lh := cl.lineHeight(f)
+	// BulletedListXY() writes its first item on the baseline y
+	f.BulletedListXY(x, y+.5*lh+.3*f.fontSize, lh, cl.Items, cl.Level)
+	return f.err
+}
+
+// CellDrawing is a content block drawn by a function, for instance with the
+// vector drawing methods of Fpdf.
+type CellDrawing struct {
+	Width  float64                                 // Width of the drawing in user units, the width of the cell if zero
+	Height float64                                 // Height of the drawing in user units
+	Draw   func(f *Fpdf, x, y, w, h float64) error // Draws in the box at x and y, w wide and h high
+}
+
+func (cd *CellDrawing) minWidth() float64 {
+	return cd.Width
+}
+
+// ContentHeight implements CellContent.
+func (cd *CellDrawing) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	return cd.Height, nil
+}
+
+// DrawContent implements CellContent.
+func (cd *CellDrawing) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	if cd.Draw == nil {
+		return
+	}
+	if cd.Width > 0 {
+		w = cd.Width
+	}
+	ps := f.pageState()
+	err = cd.Draw(f, x, y, w, cd.Height)
+	if err == nil && ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	return
+}
+
+// nestedTable lays out the table of tb in width w
+func (tb *TableBuilder) nestedTable(w float64) (t *tableType, err error) {
+	spec := tb.spec
+	if spec.Width == 0 || spec.Width > w {
+		spec.Width = w
+	}
+	return tb.f.layoutTable(spec, tb.rows)
+}
+
+// ContentHeight implements CellContent, making a table a block that can be
+// nested in the cell of another table.
+func (tb *TableBuilder) ContentHeight(f *Fpdf, w float64) (h float64, err error) {
+	t, err := tb.nestedTable(w)
+	if err != nil {
+		return
+	}
+	return t.rowsHeight(0, len(t.cells)), nil
+}
+
+// DrawContent implements CellContent. The nested table is never split.
+func (tb *TableBuilder) DrawContent(f *Fpdf, x, y, w float64) (err error) {
+	x0, y0 := f.x, f.y
+	f.x = x
+	t, err := tb.nestedTable(w)
+	if err != nil {
+		return
+	}
+	ps := f.pageState()
+	cMargin := f.cMargin
+	f.y = y
+	err = f.drawTableRows(t, 0, len(t.cells))
+	f.cMargin = cMargin
+	f.x, f.y = x0, y0
+	if err == nil && ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	return
+}
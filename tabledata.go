@@ -0,0 +1,588 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CellFormatter returns the text of a table cell from its value. Values come
+// from struct fields or map entries, or are strings for CSV data.
+type CellFormatter func(value interface{}) string
+
+// TableDataOptions defines how TableFromStructs() and TableFromCSV() turn
+// data into a table.
+//
+// Columns are named after the struct fields, the map keys or the CSV header.
+// They are defined with the syntax of the pdf struct tags, as in
+//
+//	Amount float64 `pdf:"Amount (EUR),align=R,format=%.2f,width=30,total"`
+//
+// where the first item is the title of the column, the field name if empty,
+// and the other ones are optional: align is the alignment of the cells as in
+// CellFormat(), format is a fmt verb or a time layout, which can contain
+// commas, width is the width of the column in user units and total adds the
+// column to Totals. A "-" tag hides the field.
+type TableDataOptions struct {
+	Spec          TableSpec                // Layout of the table, with one header row if HeaderRows is zero; the column settings found in the data complete Spec.Columns
+	Columns       []string                 // Names of the columns to write, in that order; all of them if empty
+	Tags          map[string]string        // Definitions of the columns by name, replacing their struct tags; the way to define the columns of maps and CSV data
+	Formatters    map[string]CellFormatter // Formatters of the columns by name, taking precedence over the format of their definition
+	Totals        []string                 // Names of the columns summed up in the total row and the subtotal rows
+	TotalLabel    string                   // Text of the first cell of the total row, "Total" if empty
+	GroupBy       string                   // Name of the column grouping consecutive rows with the same value, each group ending with a subtotal row
+	SubtotalLabel string                   // Text of the first cell of the subtotal rows, %s being replaced with the value of the group, "Subtotal %s" if empty
+}
+
+// dataColumnType is a column of a table made from data
+type dataColumnType struct {
+	name, title string
+	align       string
+	format      string
+	width       float64
+	total       bool
+	numeric     bool // all values are numbers
+	index       []int
+	formatter   CellFormatter
+}
+
+// parseDataTag applies the column definition tag to c
+func (c *dataColumnType) parseDataTag(tag string) (err error) {
+	items := strings.Split(tag, ",")
+	if items[0] != "" {
+		c.title = items[0]
+	}
+	last := ""
+	for _, item := range items[1:] {
+		key, value := item, ""
+		if pos := strings.IndexByte(item, '='); pos >= 0 {
+			key, value = item[:pos], item[pos+1:]
+		} else if last == "format" && item != "total" {
+			// Formats such as "Jan 2, 2006" contain commas
+			c.format += "," + item
+			continue
+		}
+		last = strings.TrimSpace(key)
+		switch last {
+		case "align":
+			c.align = value
+		case "format":
+			c.format = value
+		case "width":
+			if c.width, err = strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("invalid width in definition of column %q: %s", c.name, value)
+			}
+		case "total":
+			c.total = true
+		case "":
+		default:
+			return fmt.Errorf("unknown option %q in definition of column %q", key, c.name)
+		}
+	}
+	return
+}
+
+// text returns the text of a cell of column c holding value
+func (c *dataColumnType) text(value interface{}) string {
+	if c.formatter != nil {
+		return c.formatter(value)
+	}
+	return formatDataValue(value, c.format)
+}
+
+// TableFromStructs returns a builder for a table made from rows, a slice of
+// structs, of pointers to structs or of maps with string keys. The builder
+// can receive more rows before the table is written with its Write() method.
+//
+// The table has a header row with the titles of the columns and a row per
+// element of rows. The columns of structs are their exported fields, in
+// order of declaration, defined by their pdf tags; the columns of maps are
+// their keys in alphabetical order. Columns of numbers are right aligned by
+// default. See TableDataOptions for the other settings.
+func (f *Fpdf) TableFromStructs(rows interface{}, opts TableDataOptions) (tb *TableBuilder, err error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		err = fmt.Errorf("TableFromStructs needs a slice, not %T", rows)
+		f.SetError(err)
+		return
+	}
+	elemType := rv.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	var cols []*dataColumnType
+	switch {
+	case elemType.Kind() == reflect.Struct:
+		for _, field := range reflect.VisibleFields(elemType) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if field.PkgPath != "" || field.Anonymous && embedded.Kind() == reflect.Struct {
+				continue
+			}
+			tag := field.Tag.Get("pdf")
+			if tag == "-" {
+				continue
+			}
+			c := &dataColumnType{name: field.Name, title: field.Name, index: field.Index}
+			if err = c.parseDataTag(tag); err != nil {
+				f.SetError(err)
+				return
+			}
+			c.numeric = isNumberKind(field.Type.Kind())
+			cols = append(cols, c)
+		}
+	case elemType.Kind() == reflect.Map && elemType.Key().Kind() == reflect.String:
+		names := make(map[string]bool)
+		for j := 0; j < rv.Len(); j++ {
+			for _, key := range reflect.Indirect(rv.Index(j)).MapKeys() {
+				names[key.String()] = true
+			}
+		}
+		for name := range names {
+			cols = append(cols, &dataColumnType{name: name, title: name})
+		}
+		sort.Slice(cols, func(i, j int) bool {
+			return cols[i].name < cols[j].name
+		})
+	default:
+		err = fmt.Errorf("TableFromStructs needs structs or maps with string keys, not %s", elemType)
+		f.SetError(err)
+		return
+	}
+
+	values := make([][]interface{}, rv.Len())
+	for j := range values {
+		elem := reflect.Indirect(rv.Index(j))
+		values[j] = make([]interface{}, len(cols))
+		if !elem.IsValid() {
+			continue
+		}
+		for k, c := range cols {
+			var v reflect.Value
+			if c.index != nil {
+				v, err = elem.FieldByIndexErr(c.index)
+				if err != nil {
+					// Field of a nil embedded pointer
+					err = nil
+					continue
+				}
+			} else {
+				v = elem.MapIndex(reflect.ValueOf(c.name).Convert(elem.Type().Key()))
+			}
+			if v.IsValid() && v.CanInterface() {
+				values[j][k] = v.Interface()
+			}
+		}
+	}
+	if elemType.Kind() == reflect.Map {
+		// Map columns are numeric when all their values are numbers
+		for k, c := range cols {
+			c.numeric = true
+			for _, row := range values {
+				if row[k] != nil && !isNumberKind(reflect.ValueOf(row[k]).Kind()) {
+					c.numeric = false
+					break
+				}
+			}
+		}
+	}
+	return f.dataTable(cols, values, opts)
+}
+
+// TableFromCSV returns a builder for a table made from the CSV data read
+// from r, the first record being the names of the columns. The builder can
+// receive more rows before the table is written with its Write() method.
+//
+// The table has a header row with the titles of the columns and a row per
+// record. The values are strings, which the formatters and the formats of
+// the columns parse as numbers or dates as needed. Columns whose values are
+// all numbers are right aligned by default. See TableDataOptions for the
+// other settings.
+func (f *Fpdf) TableFromCSV(r io.Reader, opts TableDataOptions) (tb *TableBuilder, err error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	rd := csv.NewReader(r)
+	rd.FieldsPerRecord = -1
+	records, err := rd.ReadAll()
+	if err == nil && len(records) == 0 {
+		err = fmt.Errorf("CSV data has no header")
+	}
+	if err != nil {
+		f.SetError(err)
+		return
+	}
+	cols := make([]*dataColumnType, len(records[0]))
+	for k, name := range records[0] {
+		cols[k] = &dataColumnType{name: name, title: name, numeric: true}
+	}
+	values := make([][]interface{}, len(records)-1)
+	for j, record := range records[1:] {
+		values[j] = make([]interface{}, len(cols))
+		for k := range cols {
+			s := ""
+			if k < len(record) {
+				s = record[k]
+			}
+			values[j][k] = s
+			if _, ok := dataNumber(s); !ok && strings.TrimSpace(s) != "" {
+				cols[k].numeric = false
+			}
+		}
+	}
+	return f.dataTable(cols, values, opts)
+}
+
+// dataTable returns a builder for a table of the values of columns cols
+func (f *Fpdf) dataTable(cols []*dataColumnType, values [][]interface{}, opts TableDataOptions) (tb *TableBuilder, err error) {
+	defer func() {
+		if err != nil {
+			tb = nil
+			f.SetError(err)
+		}
+	}()
+	byName := make(map[string]int)
+	for k, c := range cols {
+		byName[c.name] = k
+	}
+	column := func(name string) (int, error) {
+		k, ok := byName[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown table column %q", name)
+		}
+		return k, nil
+	}
+
+	// Select the columns
+	order := make([]int, len(cols))
+	for k := range order {
+		order[k] = k
+	}
+	if len(opts.Columns) > 0 {
+		order = order[:0]
+		for _, name := range opts.Columns {
+			k, err := column(name)
+			if err != nil {
+				return nil, err
+			}
+			order = append(order, k)
+		}
+	}
+	for name, tag := range opts.Tags {
+		k, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		if err = cols[k].parseDataTag(tag); err != nil {
+			return nil, err
+		}
+	}
+	for name, formatter := range opts.Formatters {
+		k, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		cols[k].formatter = formatter
+	}
+	for _, name := range opts.Totals {
+		k, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		cols[k].total = true
+	}
+	group := -1
+	if opts.GroupBy != "" {
+		if group, err = column(opts.GroupBy); err != nil {
+			return nil, err
+		}
+	}
+	hasTotals := false
+	for _, k := range order {
+		hasTotals = hasTotals || cols[k].total
+	}
+	if opts.TotalLabel == "" {
+		opts.TotalLabel = "Total"
+	}
+	if opts.SubtotalLabel == "" {
+		opts.SubtotalLabel = "Subtotal %s"
+	}
+
+	spec := opts.Spec
+	if spec.HeaderRows == 0 {
+		spec.HeaderRows = 1
+	}
+	columns := make([]TableColumn, len(order))
+	for j, k := range order {
+		c := cols[k]
+		columns[j] = TableColumn{Width: c.width, Align: c.align}
+		if c.align == "" && c.numeric {
+			columns[j].Align = "RM"
+		}
+		if j < len(spec.Columns) {
+			if spec.Columns[j].Width != 0 {
+				columns[j].Width = spec.Columns[j].Width
+			}
+			if spec.Columns[j].Align != "" {
+				columns[j].Align = spec.Columns[j].Align
+			}
+		}
+	}
+	spec.Columns = columns
+	tb = f.NewTableBuilder(spec)
+
+	titles := make([]string, len(order))
+	for j, k := range order {
+		titles[j] = cols[k].title
+	}
+	tb.AddRow(titles...)
+
+	// sumRow adds a row with the sums of the total columns
+	sumRow := func(label string, sums []float64) {
+		cells := make([]TableCell, len(order))
+		for j, k := range order {
+			cells[j].FontStyle = "B"
+			if cols[k].total {
+				cells[j].Text = cols[k].text(sums[k])
+			} else if j == 0 {
+				cells[j].Text = label
+			}
+		}
+		tb.KeepWithNext()
+		tb.AddCells(cells...)
+	}
+	sums := make([]float64, len(cols))
+	groupSums := make([]float64, len(cols))
+	groupValue, groupRows := "", 0
+	for _, row := range values {
+		if group >= 0 {
+			value := cols[group].text(row[group])
+			if groupRows > 0 && value != groupValue && hasTotals {
+				sumRow(strings.Replace(opts.SubtotalLabel, "%s", groupValue, -1), groupSums)
+				groupSums = make([]float64, len(cols))
+			}
+			if value != groupValue {
+				groupRows = 0
+			}
+			groupValue = value
+			groupRows++
+		}
+		texts := make([]string, len(order))
+		for j, k := range order {
+			texts[j] = cols[k].text(row[k])
+		}
+		for k, c := range cols {
+			if x, ok := dataNumber(row[k]); ok && c.total {
+				sums[k] += x
+				groupSums[k] += x
+			}
+		}
+		tb.AddRow(texts...)
+	}
+	if hasTotals {
+		if group >= 0 && groupRows > 0 {
+			sumRow(strings.Replace(opts.SubtotalLabel, "%s", groupValue, -1), groupSums)
+		}
+		sumRow(opts.TotalLabel, sums)
+	}
+	return
+}
+
+// isNumberKind reports whether k is the kind of an integer or a floating
+// point number
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// dataNumber returns value as a number. Strings are parsed.
+func dataNumber(value interface{}) (x float64, ok bool) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case !rv.IsValid():
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	case rv.Kind() == reflect.String:
+		x, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		return x, err == nil
+	}
+	return
+}
+
+// dateLayouts are the layouts of the dates parsed from strings
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006/01/02", "02/01/2006"}
+
+// dataDate returns value as a date. Strings are parsed.
+func dataDate(value interface{}) (t time.Time, ok bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return
+}
+
+// formatDataValue returns the text of value written with format, a fmt verb
+// or a time layout. Numbers written with a numeric verb and dates written
+// with a layout are parsed from strings.
+func formatDataValue(value interface{}, format string) string {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ""
+	}
+	if format == "" {
+		if t, ok := value.(time.Time); ok {
+			return t.Format("2006-01-02")
+		}
+		return fmt.Sprint(reflect.Indirect(rv).Interface())
+	}
+	if !strings.HasPrefix(format, "%") {
+		if t, ok := dataDate(value); ok {
+			return t.Format(format)
+		}
+		return fmt.Sprint(reflect.Indirect(rv).Interface())
+	}
+	switch format[len(format)-1] {
+	case 'd':
+		if x, ok := dataNumber(value); ok {
+			return fmt.Sprintf(format, int64(math.Round(x)))
+		}
+		return fmt.Sprint(value)
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		if x, ok := dataNumber(value); ok {
+			return fmt.Sprintf(format, x)
+		}
+		return fmt.Sprint(value)
+	}
+	return fmt.Sprintf(format, reflect.Indirect(rv).Interface())
+}
+
+// FormatNumber returns a formatter that writes numbers with decimals digits
+// after decimalSep and their thousands grouped with thousandsSep, as in
+// "1,234.50". Other values are written as is.
+func FormatNumber(decimals int, thousandsSep, decimalSep string) CellFormatter {
+	return func(value interface{}) string {
+		x, ok := dataNumber(value)
+		if !ok {
+			return formatDataValue(value, "")
+		}
+		return groupedNumber(x, decimals, thousandsSep, decimalSep)
+	}
+}
+
+// FormatCurrency returns a formatter that writes amounts with decimals
+// digits and symbol, as in "$1,234.50" or "-$12.00". A symbol that begins
+// with a space is written after the amount, as in "1,234.50 €". Other values
+// are written as is.
+func FormatCurrency(symbol string, decimals int) CellFormatter {
+	return func(value interface{}) string {
+		x, ok := dataNumber(value)
+		if !ok {
+			return formatDataValue(value, "")
+		}
+		digits := groupedNumber(math.Abs(x), decimals, ",", ".")
+		s := symbol + digits
+		if strings.HasPrefix(symbol, " ") {
+			s = digits + symbol
+		}
+		if x < 0 && digits != groupedNumber(0, decimals, ",", ".") {
+			s = "-" + s
+		}
+		return s
+	}
+}
+
+// FormatPercent returns a formatter that writes ratios as percentages with
+// decimals digits, 0.125 being written "12.5%" with one decimal. Other
+// values are written as is.
+func FormatPercent(decimals int) CellFormatter {
+	return func(value interface{}) string {
+		x, ok := dataNumber(value)
+		if !ok {
+			return formatDataValue(value, "")
+		}
+		return strconv.FormatFloat(100*x, 'f', decimals, 64) + "%"
+	}
+}
+
+// FormatDate returns a formatter that writes dates with the time layout
+// layout. Dates are time.Time values or strings in RFC 3339 or "2006-01-02"
+// format. Other values are written as is.
+func FormatDate(layout string) CellFormatter {
+	return func(value interface{}) string {
+		if t, ok := dataDate(value); ok {
+			return t.Format(layout)
+		}
+		return formatDataValue(value, "")
+	}
+}
+
+// groupedNumber writes x with decimals digits after decimalSep and its
+// thousands grouped with thousandsSep
+func groupedNumber(x float64, decimals int, thousandsSep, decimalSep string) string {
+	s := strconv.FormatFloat(math.Abs(x), 'f', decimals, 64)
+	intPart, fracPart := s, ""
+	if pos := strings.IndexByte(s, '.'); pos >= 0 {
+		intPart, fracPart = s[:pos], s[pos+1:]
+	}
+	var b strings.Builder
+	if x < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for j, c := range intPart {
+		if j > 0 && (len(intPart)-j)%3 == 0 {
+			b.WriteString(thousandsSep)
+		}
+		b.WriteRune(c)
+	}
+	if fracPart != "" {
+		b.WriteString(decimalSep)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
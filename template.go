@@ -48,7 +48,9 @@ func CreateTemplate(corner PointType, size SizeType, unitStr, fontDirStr string,
 	return CreateTpl(corner, size, orientationStr, unitStr, fontDirStr, fn)
 }
 
-// CreateTpl creates a template not attached to any document
+// CreateTpl creates a template not attached to any document. Since gradients,
+// patterns and the clipping of ClipImage() are resources of a document, such a
+// template cannot use them.
 func CreateTpl(corner PointType, size SizeType, orientationStr, unitStr, fontDirStr string, fn func(*Tpl)) (Template, error) {
 	return newTpl(corner, size, orientationStr, unitStr, fontDirStr, fn, nil)
 }
@@ -85,6 +87,22 @@ func (f *Fpdf) UseTemplateScaled(t Template, corner PointType, size SizeType) {
 		f.templates[tt.ID()] = tt
 	}
 
+	f.addTemplateImages(t)
+
+	// template data
+	_, templateSize := t.Size()
+	scaleX := size.Wd / templateSize.Wd
+	scaleY := size.Ht / templateSize.Ht
+	tx := corner.X * f.k
+	ty := (f.curPageSize.Ht - corner.Y - size.Ht) * f.k
+
+	f.outf("q %.4f 0 0 %.4f %.4f %.4f cm", scaleX, scaleY, tx, ty) // Translate
+	f.outf("/TPL%s Do Q", t.ID())
+}
+
+// addTemplateImages adds the images used by t to the document, unless already
+// present
+func (f *Fpdf) addTemplateImages(t Template) {
 	// Create a list of existing image SHA-1 hashes.
 	existingImages := map[string]bool{}
 	for _, image := range f.images {
@@ -99,16 +117,6 @@ func (f *Fpdf) UseTemplateScaled(t Template, corner PointType, size SizeType) {
 		name = sprintf("t%s-%s", t.ID(), name)
 		f.images[name] = ti
 	}
-
-	// template data
-	_, templateSize := t.Size()
-	scaleX := size.Wd / templateSize.Wd
-	scaleY := size.Ht / templateSize.Ht
-	tx := corner.X * f.k
-	ty := (f.curPageSize.Ht - corner.Y - size.Ht) * f.k
-
-	f.outf("q %.4f 0 0 %.4f %.4f %.4f cm", scaleX, scaleY, tx, ty) // Translate
-	f.outf("/TPL%s Do Q", t.ID())
 }
 
 // Template is an object that can be written to, then used and re-used any number of times within a document.
@@ -137,9 +145,13 @@ func (f *Fpdf) templateFontCatalog() {
 	if f.catalogSort {
 		sort.Strings(keyList)
 	}
+	written := make(map[string]bool)
 	for _, key = range keyList {
 		font = f.fonts[key]
-		f.outf("/F%s %d 0 R", font.i, font.N)
+		if !written[font.i] {
+			f.outf("/F%s %d 0 R", font.i, font.N)
+			written[font.i] = true
+		}
 	}
 	f.out(">>")
 }
@@ -151,6 +163,13 @@ func (f *Fpdf) putTemplates() {
 		filter = "/Filter /FlateDecode "
 	}
 
+	// Templates taken from another document refer to its own copy of the
+	// images: their objects are found by content
+	imageObjs := make(map[string]int, len(f.images))
+	for _, info := range f.images {
+		imageObjs[info.i] = info.n
+	}
+
 	templates := sortTemplates(f.templates, f.catalogSort)
 	var t Template
 	for _, t = range templates {
@@ -171,6 +190,7 @@ func (f *Fpdf) putTemplates() {
 		f.out("<</ProcSet [/PDF /Text /ImageB /ImageC /ImageI]")
 
 		f.templateFontCatalog()
+		f.putnumberedresourcedict()
 
 		tImages := t.Images()
 		tTemplates := t.Templates()
@@ -186,10 +206,14 @@ func (f *Fpdf) putTemplates() {
 				if gl.catalogSort {
 					sort.Strings(keyList)
 				}
+				written := make(map[string]bool)
 				for _, key = range keyList {
 					// for _, ti := range tImages {
 					ti = tImages[key]
-					f.outf("/I%s %d 0 R", ti.i, ti.n)
+					if !written[ti.i] {
+						f.outf("/I%s %d 0 R", ti.i, imageObjs[ti.i])
+						written[ti.i] = true
+					}
 				}
 			}
 			for _, tt := range tTemplates {
@@ -39,6 +39,13 @@ func newTpl(corner PointType, size SizeType, orientationStr, unitStr, fontDirStr
 	}
 	tpl.Fpdf.AddPage()
 	fn(&tpl)
+	if copyFrom != nil {
+		copyFrom.gradientList = tpl.Fpdf.gradientList
+		copyFrom.softMasks = tpl.Fpdf.softMasks
+		copyFrom.patterns = tpl.Fpdf.patterns
+	} else if len(tpl.Fpdf.gradientList) > 1 || len(tpl.Fpdf.softMasks) > 1 || len(tpl.Fpdf.patterns) > 1 {
+		err = fmt.Errorf("a template not attached to a document cannot use gradients, patterns or image clipping")
+	}
 
 	bytes := make([][]byte, len(tpl.Fpdf.pages))
 	// skip the first page as it will always be empty
@@ -292,6 +299,11 @@ func (t *Tpl) loadParamsFromFpdf(f *Fpdf) {
 	t.Fpdf.color.draw = f.color.draw
 	t.Fpdf.color.fill = f.color.fill
 	t.Fpdf.color.text = f.color.text
+	// The shadings, soft masks and patterns are resources of the document,
+	// the ones added by the template are handed back by newTpl()
+	t.Fpdf.gradientList = f.gradientList
+	t.Fpdf.softMasks = f.softMasks
+	t.Fpdf.patterns = f.patterns
 
 	t.Fpdf.fonts = f.fonts
 	t.Fpdf.currentFont = f.currentFont
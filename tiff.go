@@ -0,0 +1,655 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// TIFF tags read by parsetiff()
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffPhotometric     = 262
+	tiffFillOrder       = 266
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffXResolution     = 282
+	tiffPlanarConfig    = 284
+	tiffT4Options       = 292
+	tiffResolutionUnit  = 296
+	tiffPredictor       = 317
+	tiffColorMap        = 320
+	tiffTileWidth       = 322
+	tiffTileLength      = 323
+	tiffTileOffsets     = 324
+	tiffTileByteCounts  = 325
+	tiffExtraSamples    = 338
+)
+
+// tiffReader reads the image file directories of a TIFF file
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+	dirs  []uint32 // offsets of the directories, one per page
+}
+
+// tiffDirType holds the values of the tags of an image file directory
+type tiffDirType struct {
+	ints      map[uint16][]uint32
+	rationals map[uint16][]float64
+}
+
+// newTiffReader checks the header of the TIFF data and lists its directories
+func newTiffReader(data []byte) (t *tiffReader, err error) {
+	t = &tiffReader{data: data}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("TIFF data is too short")
+	}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a TIFF buffer")
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, fmt.Errorf("unsupported TIFF version %d", t.order.Uint16(data[2:]))
+	}
+	seen := make(map[uint32]bool)
+	for off := t.order.Uint32(data[4:]); off != 0; {
+		if seen[off] || int(off)+2 > len(data) {
+			return nil, fmt.Errorf("invalid directory offset in TIFF data")
+		}
+		seen[off] = true
+		t.dirs = append(t.dirs, off)
+		next := int(off) + 2 + 12*int(t.order.Uint16(data[off:]))
+		if next+4 > len(data) {
+			return nil, fmt.Errorf("truncated directory in TIFF data")
+		}
+		off = t.order.Uint32(data[next:])
+	}
+	if len(t.dirs) == 0 {
+		return nil, fmt.Errorf("TIFF data has no image")
+	}
+	return
+}
+
+// directory reads the directory of the one-based page pageNum
+func (t *tiffReader) directory(pageNum int) (dir tiffDirType, err error) {
+	if pageNum < 1 || pageNum > len(t.dirs) {
+		err = fmt.Errorf("TIFF image has no page %d", pageNum)
+		return
+	}
+	dir.ints = make(map[uint16][]uint32)
+	dir.rationals = make(map[uint16][]float64)
+	off := int(t.dirs[pageNum-1])
+	count := int(t.order.Uint16(t.data[off:]))
+	// Sizes of the field types, the unsupported ones being zero
+	sizes := [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 0, 0, 4}
+	for j := 0; j < count; j++ {
+		entry := t.data[off+2+12*j:]
+		tag, typ, n := t.order.Uint16(entry), int(t.order.Uint16(entry[2:])), int(t.order.Uint32(entry[4:]))
+		if typ >= len(sizes) || sizes[typ] == 0 {
+			continue
+		}
+		size := sizes[typ] * n
+		value := entry[8:12]
+		if size > 4 {
+			pos := int(t.order.Uint32(entry[8:]))
+			if n > len(t.data) || pos < 0 || pos+size > len(t.data) {
+				err = fmt.Errorf("invalid value of TIFF tag %d", tag)
+				return
+			}
+			value = t.data[pos : pos+size]
+		}
+		switch typ {
+		case 1, 6, 7:
+			for k := 0; k < n; k++ {
+				dir.ints[tag] = append(dir.ints[tag], uint32(value[k]))
+			}
+		case 3, 8:
+			for k := 0; k < n; k++ {
+				dir.ints[tag] = append(dir.ints[tag], uint32(t.order.Uint16(value[2*k:])))
+			}
+		case 4, 9, 13:
+			for k := 0; k < n; k++ {
+				dir.ints[tag] = append(dir.ints[tag], t.order.Uint32(value[4*k:]))
+			}
+		case 5, 10:
+			for k := 0; k < n; k++ {
+				num, den := t.order.Uint32(value[8*k:]), t.order.Uint32(value[8*k+4:])
+				if den != 0 {
+					dir.rationals[tag] = append(dir.rationals[tag], float64(num)/float64(den))
+				}
+			}
+		}
+	}
+	return
+}
+
+// get returns the first value of tag, or def if the tag is missing
+func (dir *tiffDirType) get(tag uint16, def uint32) uint32 {
+	if v := dir.ints[tag]; len(v) > 0 {
+		return v[0]
+	}
+	return def
+}
+
+// ImageTIFFPages returns the number of pages of the TIFF image file fileStr,
+// or zero if it cannot be read, in which case the error of the document is
+// set. Set the Page field of ImageOptions to register a page other than the
+// first one.
+func (f *Fpdf) ImageTIFFPages(fileStr string) int {
+	if f.err != nil {
+		return 0
+	}
+	data, err := os.ReadFile(fileStr)
+	if err == nil {
+		var t *tiffReader
+		if t, err = newTiffReader(data); err == nil {
+			return len(t.dirs)
+		}
+	}
+	f.err = err
+	return 0
+}
+
+// parsetiff extracts info from the one-based page pageNum of TIFF data
+func (f *Fpdf) parsetiff(r io.Reader, pageNum int, readdpi bool) (info *ImageInfoType) {
+	info = f.newImageInfo()
+	buf, err := bufferFromReader(r)
+	if err == nil {
+		err = f.decodetiff(info, buf.Bytes(), pageNum, readdpi)
+	}
+	if err != nil {
+		f.err = err
+	}
+	return
+}
+
+// decodetiff fills info with the page pageNum of data
+func (f *Fpdf) decodetiff(info *ImageInfoType, data []byte, pageNum int, readdpi bool) error {
+	if pageNum == 0 {
+		pageNum = 1
+	}
+	t, err := newTiffReader(data)
+	if err != nil {
+		return err
+	}
+	dir, err := t.directory(pageNum)
+	if err != nil {
+		return err
+	}
+	w, h := int(dir.get(tiffImageWidth, 0)), int(dir.get(tiffImageLength, 0))
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("TIFF image has no size")
+	}
+	info.w, info.h = float64(w), float64(h)
+	if xres := dir.rationals[tiffXResolution]; readdpi && len(xres) > 0 && xres[0] > 0 {
+		switch dir.get(tiffResolutionUnit, 2) {
+		case 2:
+			info.dpi = xres[0]
+		case 3:
+			info.dpi = xres[0] * 2.54
+		}
+	}
+	bps := int(dir.get(tiffBitsPerSample, 1))
+	spp := int(dir.get(tiffSamplesPerPixel, 1))
+	for _, v := range dir.ints[tiffBitsPerSample] {
+		if int(v) != bps {
+			return fmt.Errorf("TIFF image with samples of different sizes not supported")
+		}
+	}
+	switch bps {
+	case 1, 2, 4, 8, 16:
+	default:
+		return fmt.Errorf("%d-bit samples not supported in TIFF image", bps)
+	}
+	segments, err := t.segments(&dir)
+	if err != nil {
+		return err
+	}
+	photometric := dir.get(tiffPhotometric, 0)
+	compression := dir.get(tiffCompression, 1)
+	switch compression {
+	case 2, 3, 4:
+		return tiffCCITT(info, &dir, segments, compression, photometric)
+	}
+	raster, err := t.raster(&dir, segments, w, h, bps, spp)
+	if err != nil {
+		return err
+	}
+
+	// Color channels
+	colors := 1
+	switch photometric {
+	case 0, 1:
+		info.cs = "DeviceGray"
+	case 2:
+		info.cs = "DeviceRGB"
+		colors = 3
+	case 3:
+		info.cs = "Indexed"
+		cmap := dir.ints[tiffColorMap]
+		n := 1 << uint(bps)
+		if len(cmap) < 3*n {
+			return fmt.Errorf("missing color map in TIFF image")
+		}
+		info.pal = make([]byte, 3*n)
+		for j := 0; j < n; j++ {
+			info.pal[3*j] = byte(cmap[j] >> 8)
+			info.pal[3*j+1] = byte(cmap[n+j] >> 8)
+			info.pal[3*j+2] = byte(cmap[2*n+j] >> 8)
+		}
+	case 5:
+		info.cs = "DeviceCMYK"
+		colors = 4
+	default:
+		return fmt.Errorf("TIFF photometric interpretation %d not supported", photometric)
+	}
+	if spp < colors {
+		return fmt.Errorf("missing color samples in TIFF image")
+	}
+	info.bpc = bps
+	rowLen := (w*colors*bps + 7) / 8
+	var color, alpha []byte
+	if spp == colors {
+		color = raster
+	} else {
+		if bps < 8 {
+			return fmt.Errorf("TIFF image with %d-bit extra samples not supported", bps)
+		}
+		// Separate the color channels from the alpha channel, if any, and drop
+		// the other extra samples
+		sz := bps / 8
+		extra := dir.get(tiffExtraSamples, 0)
+		color = make([]byte, 0, h*rowLen)
+		if extra == 1 || extra == 2 {
+			alpha = make([]byte, 0, h*w*sz)
+		}
+		for pos := 0; pos < len(raster); pos += spp * sz {
+			px := raster[pos : pos+spp*sz]
+			if extra == 1 {
+				tiffUnpremultiply(px, colors, sz)
+			}
+			color = append(color, px[:colors*sz]...)
+			if alpha != nil {
+				alpha = append(alpha, px[colors*sz:(colors+1)*sz]...)
+			}
+		}
+	}
+	// PDF gray is black at zero
+	if photometric == 0 {
+		for j := range color {
+			color[j] = ^color[j]
+		}
+	}
+	info.f = "FlateDecode"
+	info.dp = sprintf("/Predictor 15 /Colors %d /BitsPerComponent %d /Columns %d", colors, bps, w)
+	if info.cs == "Indexed" {
+		info.dp = sprintf("/Predictor 15 /Colors 1 /BitsPerComponent %d /Columns %d", bps, w)
+	}
+	info.data = sliceCompress(predictorRows(color, rowLen))
+	if alpha != nil {
+		info.smask = sliceCompress(predictorRows(alpha, w*bps/8))
+		if f.pdfVersion < "1.4" {
+			f.pdfVersion = "1.4"
+		}
+	}
+	if bps > 8 && f.pdfVersion < "1.5" {
+		f.pdfVersion = "1.5"
+	}
+	return nil
+}
+
+// segments returns the strips, or the tiles, of the image of dir
+func (t *tiffReader) segments(dir *tiffDirType) (segments [][]byte, err error) {
+	offsets, counts := dir.ints[tiffStripOffsets], dir.ints[tiffStripByteCounts]
+	if _, ok := dir.ints[tiffTileOffsets]; ok {
+		offsets, counts = dir.ints[tiffTileOffsets], dir.ints[tiffTileByteCounts]
+	}
+	if len(offsets) == 0 || len(offsets) != len(counts) {
+		return nil, fmt.Errorf("missing image data in TIFF image")
+	}
+	for j, off := range offsets {
+		end := int64(off) + int64(counts[j])
+		if end > int64(len(t.data)) {
+			return nil, fmt.Errorf("truncated TIFF image data")
+		}
+		segments = append(segments, t.data[off:end])
+	}
+	return
+}
+
+// raster decompresses the segments of the image of dir and returns its rows
+// of pixels, with interleaved samples in big-endian order
+func (t *tiffReader) raster(dir *tiffDirType, segments [][]byte, w, h, bps, spp int) ([]byte, error) {
+	planes, planeSpp := 1, spp
+	if dir.get(tiffPlanarConfig, 1) == 2 && spp > 1 {
+		if bps < 8 {
+			return nil, fmt.Errorf("planar TIFF image with %d-bit samples not supported", bps)
+		}
+		planes, planeSpp = spp, 1
+	}
+	predictor := dir.get(tiffPredictor, 1)
+	if predictor != 1 && (predictor != 2 || bps < 8) {
+		return nil, fmt.Errorf("TIFF predictor %d not supported for %d-bit samples", predictor, bps)
+	}
+	// Width and height of the segments
+	segWd, segHt := w, int(dir.get(tiffRowsPerStrip, uint32(h)))
+	tiled := len(dir.ints[tiffTileOffsets]) > 0
+	if tiled {
+		segWd, segHt = int(dir.get(tiffTileWidth, 0)), int(dir.get(tiffTileLength, 0))
+	}
+	if segWd <= 0 || segHt <= 0 {
+		return nil, fmt.Errorf("invalid strip or tile size in TIFF image")
+	}
+	if segHt > h {
+		segHt = h
+	}
+	across, down := (w+segWd-1)/segWd, (h+segHt-1)/segHt
+	if len(segments) < planes*across*down {
+		return nil, fmt.Errorf("missing strips or tiles in TIFF image")
+	}
+	rowLen := (w*planeSpp*bps + 7) / 8
+	segRowLen := (segWd*planeSpp*bps + 7) / 8
+	planeData := make([][]byte, planes)
+	for p := range planeData {
+		plane := make([]byte, h*rowLen)
+		for j := 0; j < across*down; j++ {
+			seg, err := tiffDecompress(segments[p*across*down+j], dir.get(tiffCompression, 1))
+			if err != nil {
+				return nil, err
+			}
+			if bps == 16 && t.order == binary.LittleEndian {
+				for k := 0; k+1 < len(seg); k += 2 {
+					seg[k], seg[k+1] = seg[k+1], seg[k]
+				}
+			}
+			x0, y0 := (j%across)*segWd, (j/across)*segHt
+			for r := 0; r < segHt && y0+r < h; r++ {
+				if (r+1)*segRowLen > len(seg) {
+					break
+				}
+				row := seg[r*segRowLen : (r+1)*segRowLen]
+				if predictor == 2 {
+					tiffUndifference(row, planeSpp, bps)
+				}
+				start := x0 * planeSpp * bps / 8
+				copy(plane[(y0+r)*rowLen+start:(y0+r+1)*rowLen], row)
+			}
+		}
+		planeData[p] = plane
+	}
+	if planes == 1 {
+		return planeData[0], nil
+	}
+	// Interleave the samples of the planes
+	sz := bps / 8
+	raster := make([]byte, 0, h*w*spp*sz)
+	for pos := 0; pos < h*w*sz; pos += sz {
+		for _, plane := range planeData {
+			raster = append(raster, plane[pos:pos+sz]...)
+		}
+	}
+	return raster, nil
+}
+
+// tiffDecompress returns the uncompressed bytes of a strip or a tile
+func tiffDecompress(seg []byte, compression uint32) ([]byte, error) {
+	switch compression {
+	case 1:
+		return append([]byte(nil), seg...), nil
+	case 5:
+		return tiffLZW(seg)
+	case 8, 32946:
+		r, err := zlib.NewReader(bytes.NewReader(seg))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(r)
+		return buf.Bytes(), err
+	case 32773:
+		return tiffPackBits(seg), nil
+	}
+	return nil, fmt.Errorf("TIFF compression %d not supported", compression)
+}
+
+// tiffLZW decodes the LZW data of a TIFF image, whose codes grow one code
+// early
+func tiffLZW(src []byte) ([]byte, error) {
+	if len(src) > 1 && src[0] == 0 && src[1]&1 == 1 {
+		return nil, fmt.Errorf("old-style TIFF LZW compression not supported")
+	}
+	var out []byte
+	table := make([][]byte, 4096)
+	width, next := 9, 258
+	var bits uint32
+	var count uint
+	var prev []byte
+	for pos := 0; ; {
+		for count < uint(width) {
+			if pos >= len(src) {
+				return out, nil
+			}
+			bits = bits<<8 | uint32(src[pos])
+			pos++
+			count += 8
+		}
+		code := int(bits>>(count-uint(width))) & (1<<uint(width) - 1)
+		count -= uint(width)
+		var entry []byte
+		switch {
+		case code == 256:
+			width, next, prev = 9, 258, nil
+			continue
+		case code == 257:
+			return out, nil
+		case code < 256:
+			entry = []byte{byte(code)}
+		case code < next && table[code] != nil:
+			entry = table[code]
+		case code == next && prev != nil:
+			entry = append(append([]byte(nil), prev...), prev[0])
+		default:
+			return nil, fmt.Errorf("invalid LZW code in TIFF image")
+		}
+		out = append(out, entry...)
+		if prev != nil && next < len(table) {
+			table[next] = append(append(make([]byte, 0, len(prev)+1), prev...), entry[0])
+			next++
+			if next+1 >= 1<<uint(width) && width < 12 {
+				width++
+			}
+		}
+		prev = entry
+	}
+}
+
+// tiffPackBits decodes PackBits data
+func tiffPackBits(src []byte) (out []byte) {
+	for pos := 0; pos < len(src); {
+		n := int(int8(src[pos]))
+		pos++
+		switch {
+		case n >= 0:
+			if pos+n+1 > len(src) {
+				return append(out, src[pos:]...)
+			}
+			out = append(out, src[pos:pos+n+1]...)
+			pos += n + 1
+		case n != -128 && pos < len(src):
+			for k := 0; k < 1-n; k++ {
+				out = append(out, src[pos])
+			}
+			pos++
+		}
+	}
+	return
+}
+
+// tiffUndifference reverses the horizontal differencing of a row of pixels
+// of spp samples of bps bits, 16-bit samples being in big-endian order
+func tiffUndifference(row []byte, spp, bps int) {
+	if bps == 8 {
+		for j := spp; j < len(row); j++ {
+			row[j] += row[j-spp]
+		}
+		return
+	}
+	for j := 2 * spp; j+1 < len(row); j += 2 {
+		v := binary.BigEndian.Uint16(row[j:]) + binary.BigEndian.Uint16(row[j-2*spp:])
+		binary.BigEndian.PutUint16(row[j:], v)
+	}
+}
+
+// tiffUnpremultiply divides the color samples of the pixel px by its alpha
+// sample, which follows them
+func tiffUnpremultiply(px []byte, colors, sz int) {
+	if sz == 1 {
+		a := int(px[colors])
+		for j := 0; j < colors && a > 0; j++ {
+			if v := int(px[j]) * 255 / a; v < 255 {
+				px[j] = byte(v)
+			} else {
+				px[j] = 255
+			}
+		}
+		return
+	}
+	a := int(binary.BigEndian.Uint16(px[2*colors:]))
+	for j := 0; j < colors && a > 0; j++ {
+		if v := int(binary.BigEndian.Uint16(px[2*j:])) * 65535 / a; v < 65535 {
+			binary.BigEndian.PutUint16(px[2*j:], uint16(v))
+		} else {
+			binary.BigEndian.PutUint16(px[2*j:], 65535)
+		}
+	}
+}
+
+// tiffCCITT fills info with the bilevel image of segments, kept as they are
+// with the CCITTFaxDecode filter unless the image is coded in two dimensions
+// in several strips or tiles
+func tiffCCITT(info *ImageInfoType, dir *tiffDirType, segments [][]byte, compression, photometric uint32) error {
+	if dir.get(tiffBitsPerSample, 1) != 1 || dir.get(tiffSamplesPerPixel, 1) != 1 {
+		return fmt.Errorf("CCITT compressed TIFF image is not bilevel")
+	}
+	k, align := 0, true
+	switch compression {
+	case 3:
+		t4 := dir.get(tiffT4Options, 0)
+		if t4&1 != 0 {
+			k = 1
+		}
+		align = t4&4 != 0
+	case 4:
+		k, align = -1, false
+	}
+	var data []byte
+	for _, seg := range segments {
+		data = append(data, seg...)
+	}
+	if dir.get(tiffFillOrder, 1) == 2 {
+		for j, b := range data {
+			b = b>>4 | b<<4
+			b = b>>2&0x33 | b<<2&0xcc
+			data[j] = b>>1&0x55 | b<<1&0xaa
+		}
+	}
+	info.cs = "DeviceGray"
+	info.bpc = 1
+	if len(segments) > 1 && k != 0 {
+		// Each strip starts a new two-dimensional coding, which the filter
+		// cannot follow: the strips are decoded into one raster
+		return tiffCCITTRaster(info, dir, data, segments, k, photometric)
+	}
+	info.f = "CCITTFaxDecode"
+	info.dp = sprintf("/K %d /Columns %d /Rows %d /BlackIs1 %t /EncodedByteAlign %t",
+		k, int(info.w), int(info.h), photometric == 1, align)
+	info.data = data
+	return nil
+}
+
+// tiffCCITTRaster fills info with the bilevel image of segments, coded in two
+// dimensions, decoded into one raster. data holds the bytes of the segments
+// one after the other, in fill order 1.
+func tiffCCITTRaster(info *ImageInfoType, dir *tiffDirType, data []byte, segments [][]byte, k int, photometric uint32) error {
+	w, h := int(info.w), int(info.h)
+	segWd, segHt := w, int(dir.get(tiffRowsPerStrip, uint32(h)))
+	tiled := len(dir.ints[tiffTileOffsets]) > 0
+	if tiled {
+		segWd, segHt = int(dir.get(tiffTileWidth, 0)), int(dir.get(tiffTileLength, 0))
+	}
+	if segWd <= 0 || segHt <= 0 {
+		return fmt.Errorf("invalid strip or tile size in TIFF image")
+	}
+	if segHt > h {
+		segHt = h
+	}
+	across, down := (w+segWd-1)/segWd, (h+segHt-1)/segHt
+	if len(segments) < across*down {
+		return fmt.Errorf("missing strips or tiles in TIFF image")
+	}
+	// Rows of one bit per pixel, set for the pixels coded as white, or as
+	// black in a BlackIsZero image
+	rowLen := (w + 7) / 8
+	var set byte
+	if photometric == 1 {
+		set = 1
+	}
+	bits := make([]byte, h*rowLen)
+	pos := 0
+	for j := 0; j < across*down; j++ {
+		seg := data[pos : pos+len(segments[j])]
+		pos += len(segments[j])
+		x0, y0 := (j%across)*segWd, (j/across)*segHt
+		rows := segHt
+		if !tiled && y0+rows > h {
+			// The last strip may be shorter
+			rows = h - y0
+		}
+		pix, err := decodeCCITT(seg, segWd, rows, k)
+		if err != nil {
+			return fmt.Errorf("CCITT strip or tile %d of TIFF image: %v", j+1, err)
+		}
+		for y := 0; y < rows && y0+y < h; y++ {
+			row := bits[(y0+y)*rowLen:]
+			for x := 0; x < segWd && x0+x < w; x++ {
+				if pix[y*segWd+x] == set {
+					row[(x0+x)/8] |= 0x80 >> uint((x0+x)%8)
+				}
+			}
+		}
+	}
+	info.f = "FlateDecode"
+	info.dp = sprintf("/Predictor 15 /Colors 1 /BitsPerComponent 1 /Columns %d", w)
+	info.data = sliceCompress(predictorRows(bits, rowLen))
+	return nil
+}
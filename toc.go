@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type tocEntryType struct {
+	text  string
+	level int
+	link  int // internal link to the position of the entry
+}
+
+// TOCStyle defines the appearance of the table of contents written by
+// InsertTOC(). Zero values are replaced by defaults.
+type TOCStyle struct {
+	Title         string   // Heading written above the entries, none if empty
+	FontFamily    string   // Font family of the entries, current family if empty
+	FontSize      float64  // Font size of the entries in points, current size if zero
+	TitleFontSize float64  // Font size of the heading in points, 1.5 times FontSize if zero
+	LineHeight    float64  // Height of an entry line in user units, 1.5 times the font size if zero
+	Indent        float64  // Indentation per level in user units, 2 times the font size if zero
+	Leader        string   // Leader repeated up to the page number, "." if empty
+	LevelStyles   []string // Font style ("B", "I", "BI"...) of each level, regular if missing
+}
+
+// AddTOCEntry records an entry of the table of contents pointing to the
+// current position. txtStr is the text of the entry and level its depth; 0 is
+// the top level, 1 is just below, and so on. The table itself is written by
+// InsertTOC() once all the entries have been recorded.
+func (f *Fpdf) AddTOCEntry(txtStr string, level int) {
+	if level < 0 {
+		level = 0
+	}
+	link := f.AddLink()
+	f.SetLink(link, -1, -1)
+	f.toc = append(f.toc, tocEntryType{text: txtStr, level: level, link: link})
+}
+
+// InsertTOC writes the table of contents on new pages inserted before the
+// one-based page atPage. A value of PageCount()+1 appends the table at the end
+// of the document. The header and footer functions are called for each
+// inserted page.
+//
+// Each entry recorded with AddTOCEntry() is written with a dot leader and its
+// right aligned page number, and is a link to its position. Since the table is
+// inserted after the fact, the pages that follow, together with the links and
+// bookmarks that point to them, are renumbered. For this reason this method is
+// usually called once the whole document has been written, just before
+// Output().
+func (f *Fpdf) InsertTOC(atPage int, style TOCStyle) (err error) {
+	if f.err != nil {
+		return f.err
+	}
+	if f.page == 0 || atPage < 1 || atPage > f.PageCount()+1 {
+		f.err = fmt.Errorf("cannot insert table of contents at page %d", atPage)
+		return f.err
+	}
+	if style.FontFamily == "" {
+		style.FontFamily = f.fontFamily
+	}
+	if style.FontSize == 0 {
+		style.FontSize = f.fontSizePt
+	}
+	if style.TitleFontSize == 0 {
+		style.TitleFontSize = 1.5 * style.FontSize
+	}
+	if style.LineHeight == 0 {
+		style.LineHeight = 1.5 * style.FontSize / f.k
+	}
+	if style.Indent == 0 {
+		style.Indent = 2 * style.FontSize / f.k
+	}
+	if style.Leader == "" {
+		style.Leader = "."
+	}
+	levelStyle := func(level int) string {
+		if level < len(style.LevelStyles) {
+			return style.LevelStyles[level]
+		}
+		return ""
+	}
+
+	// Save the state of the page being written
+	ps := f.pageState()
+	geo := f.pageGeometry()
+	curPage, x, y := f.page, f.x, f.y
+	layer := f.layer.currentLayer
+	f.layer.currentLayer = -1
+	acceptPageBreak := f.acceptPageBreak
+	f.acceptPageBreak = func() bool {
+		return false
+	}
+
+	// The page numbers are only known once the whole table has been laid
+	// out, so the rows are recorded and completed afterwards
+	type tocRowType struct {
+		page    int
+		y       float64
+		textEnd float64
+		entry   tocEntryType
+	}
+	var rows []tocRowType
+	count := 0
+	newPage := func() error {
+		f.EndLayer()
+		err := f.beginInsertedPage(atPage+count, ps)
+		count++
+		return err
+	}
+
+	err = newPage()
+	if err == nil && style.Title != "" {
+		err = f.SetFont(style.FontFamily, "B", style.TitleFontSize)
+		if err == nil {
+			err = f.CellFormat(0, 2*style.TitleFontSize/f.k, style.Title, "", 1, "L", false, 0, "")
+		}
+	}
+	for j := 0; j < len(f.toc) && err == nil; j++ {
+		e := f.toc[j]
+		err = f.SetFont(style.FontFamily, levelStyle(e.level), style.FontSize)
+		if err != nil {
+			break
+		}
+		x0 := f.lMargin + float64(e.level)*style.Indent
+		numWd := f.GetStringWidth(strings.Repeat("0", len(strconv.Itoa(f.PageCount()))+1))
+		wd := f.w - f.rMargin - x0 - numWd - 2*f.cMargin
+		lines := f.splitCellText(e.text, wd)
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		if f.y+float64(len(lines))*style.LineHeight > f.pageBreakTrigger {
+			if err = newPage(); err != nil {
+				break
+			}
+			err = f.SetFont(style.FontFamily, levelStyle(e.level), style.FontSize)
+		}
+		var row tocRowType
+		for _, line := range lines {
+			if err != nil {
+				break
+			}
+			// Only the last line of an entry receives the page number
+			row = tocRowType{f.page, f.y, x0 + f.cMargin + f.GetStringWidth(line), e}
+			f.newLink(x0, f.y, f.w-f.rMargin-x0, style.LineHeight, e.link, "")
+			f.SetX(x0)
+			err = f.CellFormat(wd, style.LineHeight, line, "", 1, "L", false, 0, "")
+		}
+		rows = append(rows, row)
+	}
+
+	// Write the page numbers and the leaders, then the footers
+	f.EndLayer()
+	for pageNum := atPage; pageNum < atPage+count && err == nil; pageNum++ {
+		f.selectPage(pageNum)
+		for _, r := range rows {
+			if r.page != pageNum || err != nil {
+				continue
+			}
+			err = f.SetFont(style.FontFamily, levelStyle(r.entry.level), style.FontSize)
+			label := f.pageLabel(f.links[r.entry.link].page)
+			baseline := r.y + .5*style.LineHeight + .3*f.fontSize
+			numX := f.w - f.rMargin - f.cMargin - f.GetStringWidth(label)
+			f.Text(numX, baseline, label)
+			leaderWd := f.GetStringWidth(style.Leader)
+			if leaderWd > 0 {
+				n := int(math.Floor((numX - f.cMargin - r.textEnd - f.cMargin) / leaderWd))
+				if n > 0 {
+					f.Text(numX-f.cMargin-float64(n)*leaderWd, baseline, strings.Repeat(style.Leader, n))
+				}
+			}
+		}
+		f.endInsertedPage()
+	}
+
+	// Back to the page being written
+	f.acceptPageBreak = acceptPageBreak
+	if curPage >= atPage {
+		curPage += count
+	}
+	f.page = curPage
+	f.setPageGeometry(geo)
+	f.layer.currentLayer = layer
+	f.x, f.y = x, y
+	if err == nil && ps.familyStr != "" {
+		err = f.SetFont(ps.familyStr, ps.styleStr, ps.fontSizePt)
+	}
+	f.SetError(err)
+	return
+}
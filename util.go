@@ -90,17 +90,29 @@ func sliceCompress(data []byte) []byte {
 	return buf.Bytes()
 }
 
+// predictorRows returns the rows of rowLen bytes of data, each preceded by the
+// PNG filter type 0 expected by the predictor of PDF
+func predictorRows(data []byte, rowLen int) []byte {
+	out := make([]byte, 0, len(data)+len(data)/rowLen)
+	for pos := 0; pos+rowLen <= len(data); pos += rowLen {
+		out = append(out, 0)
+		out = append(out, data[pos:pos+rowLen]...)
+	}
+	return out
+}
+
 // sliceUncompress returns an uncompressed copy of the specified zlib-compressed byte array
 func sliceUncompress(data []byte) (outData []byte, err error) {
 	inBuf := bytes.NewReader(data)
 	r, err := zlib.NewReader(inBuf)
+	if err != nil {
+		return
+	}
 	defer r.Close()
+	var outBuf bytes.Buffer
+	_, err = outBuf.ReadFrom(r)
 	if err == nil {
-		var outBuf bytes.Buffer
-		_, err = outBuf.ReadFrom(r)
-		if err == nil {
-			outData = outBuf.Bytes()
-		}
+		outData = outBuf.Bytes()
 	}
 	return
 }
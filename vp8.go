@@ -0,0 +1,1567 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"errors"
+	"image"
+)
+
+// WebP lossy (VP8 key frame) decoding, as specified in RFC 6386
+
+const (
+	vp8DC = iota
+	vp8V
+	vp8H
+	vp8TM
+	vp8BPred
+)
+
+// 4x4 luma prediction modes, in the order of the probability tables
+const (
+	vp8BDC = iota
+	vp8BTM
+	vp8BVE
+	vp8BHE
+	vp8BRD
+	vp8BVR
+	vp8BLD
+	vp8BVL
+	vp8BHD
+	vp8BHU
+	vp8NumBModes
+)
+
+// vp8WS is the stride of the work area of a macroblock, with its left column
+// and its top row including the four pixels above right
+const vp8WS = 32
+
+var errVP8Data = errors.New("invalid or truncated WebP lossy data")
+
+// vp8BoolDecoder is the boolean entropy decoder of section 7
+type vp8BoolDecoder struct {
+	data     []byte
+	pos      int
+	value    uint32
+	rng      uint32
+	bitCount int
+}
+
+func newVP8BoolDecoder(data []byte) *vp8BoolDecoder {
+	d := &vp8BoolDecoder{data: data, rng: 255}
+	for j := 0; j < 2; j++ {
+		d.value <<= 8
+		if d.pos < len(data) {
+			d.value |= uint32(data[d.pos])
+			d.pos++
+		}
+	}
+	return d
+}
+
+// readBool returns the next bool, of probability prob/256 of being false
+func (d *vp8BoolDecoder) readBool(prob uint8) bool {
+	split := 1 + ((d.rng - 1) * uint32(prob) >> 8)
+	bigSplit := split << 8
+	ret := d.value >= bigSplit
+	if ret {
+		d.rng -= split
+		d.value -= bigSplit
+	} else {
+		d.rng = split
+	}
+	for d.rng < 128 {
+		d.value <<= 1
+		d.rng <<= 1
+		d.bitCount++
+		if d.bitCount == 8 {
+			d.bitCount = 0
+			if d.pos < len(d.data) {
+				d.value |= uint32(d.data[d.pos])
+				d.pos++
+			}
+		}
+	}
+	return ret
+}
+
+func (d *vp8BoolDecoder) readBit(prob uint8) int {
+	if d.readBool(prob) {
+		return 1
+	}
+	return 0
+}
+
+func (d *vp8BoolDecoder) readLiteral(n int) (v int) {
+	for ; n > 0; n-- {
+		v = v<<1 | d.readBit(128)
+	}
+	return
+}
+
+// readOptionalSigned returns the signed value of n bits following a flag,
+// zero if the flag is not set
+func (d *vp8BoolDecoder) readOptionalSigned(n int) int {
+	if !d.readBool(128) {
+		return 0
+	}
+	v := d.readLiteral(n)
+	if d.readBool(128) {
+		return -v
+	}
+	return v
+}
+
+// vp8Quant holds the DC and AC dequantization factors of a segment
+type vp8Quant struct {
+	y1, y2, uv [2]int
+}
+
+// vp8MBFilter holds the loop filter parameters of a macroblock
+type vp8MBFilter struct {
+	limit, ilevel, hevThresh int
+	inner                    bool
+}
+
+// vp8Decoder holds the state of the decoding of a key frame
+type vp8Decoder struct {
+	fp           *vp8BoolDecoder
+	parts        []*vp8BoolDecoder
+	mbW, mbH     int
+	segUpdateMap bool
+	segProbs     [3]uint8
+	segEnabled   bool
+	segAbsolute  bool
+	segQuant     [4]int
+	segFilter    [4]int
+	simple       bool
+	level        int
+	sharpness    int
+	lfDeltas     bool
+	refDelta     int
+	modeDelta    int
+	quant        [4]vp8Quant
+	coeffProbs   [4][8][3][11]uint8
+	skipProb     int
+	y, cb, cr    []byte
+	yStride      int
+	cStride      int
+	aboveModes   []uint8 // bottom 4x4 modes of the macroblocks above
+	leftModes    [4]uint8
+	aboveNz      []uint8 // 4 luma, 2+2 chroma and Y2 flags of non-zero coefficients
+	leftNz       [9]uint8
+	filters      []vp8MBFilter
+	coeffs       [25 * 16]int
+	yws, uws     [17 * vp8WS]uint8
+	vws          [17 * vp8WS]uint8
+}
+
+// decodeVP8 decodes the key frame of the VP8 chunk data
+func decodeVP8(data []byte) (*image.YCbCr, error) {
+	if len(data) < 10 {
+		return nil, errVP8Data
+	}
+	tag := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+	if tag&1 != 0 {
+		return nil, errors.New("WebP lossy image is not a key frame")
+	}
+	if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+		return nil, errVP8Data
+	}
+	w := (int(data[6]) | int(data[7])<<8) & 0x3fff
+	h := (int(data[8]) | int(data[9])<<8) & 0x3fff
+	firstSize := tag >> 5
+	data = data[10:]
+	if w == 0 || h == 0 || firstSize > len(data) {
+		return nil, errVP8Data
+	}
+	d := &vp8Decoder{
+		fp:  newVP8BoolDecoder(data[:firstSize]),
+		mbW: (w + 15) / 16,
+		mbH: (h + 15) / 16,
+	}
+	if err := d.header(data[firstSize:]); err != nil {
+		return nil, err
+	}
+	d.yStride, d.cStride = 16*d.mbW, 8*d.mbW
+	d.y = make([]byte, d.yStride*16*d.mbH)
+	d.cb = make([]byte, d.cStride*8*d.mbH)
+	d.cr = make([]byte, d.cStride*8*d.mbH)
+	d.aboveModes = make([]uint8, 4*d.mbW)
+	d.aboveNz = make([]uint8, 9*d.mbW)
+	d.filters = make([]vp8MBFilter, d.mbW*d.mbH)
+	for mby := 0; mby < d.mbH; mby++ {
+		d.leftModes = [4]uint8{}
+		d.leftNz = [9]uint8{}
+		part := d.parts[mby%len(d.parts)]
+		for mbx := 0; mbx < d.mbW; mbx++ {
+			d.macroblock(part, mbx, mby)
+		}
+	}
+	if d.level > 0 {
+		d.loopFilter()
+	}
+	return &image.YCbCr{
+		Y:              d.y,
+		Cb:             d.cb,
+		Cr:             d.cr,
+		YStride:        d.yStride,
+		CStride:        d.cStride,
+		SubsampleRatio: image.YCbCrSubsampleRatio420,
+		Rect:           image.Rect(0, 0, w, h),
+	}, nil
+}
+
+// header reads the frame header of the first partition and sets up the
+// token partitions found in data
+func (d *vp8Decoder) header(data []byte) error {
+	fp := d.fp
+	fp.readLiteral(2) // color space and clamping type
+	d.segEnabled = fp.readBool(128)
+	if d.segEnabled {
+		d.segUpdateMap = fp.readBool(128)
+		if fp.readBool(128) {
+			d.segAbsolute = fp.readBool(128)
+			for s := range d.segQuant {
+				d.segQuant[s] = fp.readOptionalSigned(7)
+			}
+			for s := range d.segFilter {
+				d.segFilter[s] = fp.readOptionalSigned(6)
+			}
+		}
+		if d.segUpdateMap {
+			for j := range d.segProbs {
+				d.segProbs[j] = 255
+				if fp.readBool(128) {
+					d.segProbs[j] = uint8(fp.readLiteral(8))
+				}
+			}
+		}
+	}
+	d.simple = fp.readBool(128)
+	d.level = fp.readLiteral(6)
+	d.sharpness = fp.readLiteral(3)
+	d.lfDeltas = fp.readBool(128)
+	if d.lfDeltas && fp.readBool(128) {
+		// Only the deltas of intra frames and of B_PRED macroblocks apply
+		for j := 0; j < 4; j++ {
+			if v := fp.readOptionalSigned(6); j == 0 {
+				d.refDelta = v
+			}
+		}
+		for j := 0; j < 4; j++ {
+			if v := fp.readOptionalSigned(6); j == 0 {
+				d.modeDelta = v
+			}
+		}
+	}
+	count := 1 << uint(fp.readLiteral(2))
+	if len(data) < 3*(count-1) {
+		return errVP8Data
+	}
+	sizes, data := data[:3*(count-1)], data[3*(count-1):]
+	for j := 0; j < count; j++ {
+		size := len(data)
+		if j < count-1 {
+			size = int(sizes[3*j]) | int(sizes[3*j+1])<<8 | int(sizes[3*j+2])<<16
+			if size > len(data) {
+				return errVP8Data
+			}
+		}
+		d.parts = append(d.parts, newVP8BoolDecoder(data[:size]))
+		data = data[size:]
+	}
+	d.quantizers()
+	fp.readBool(128) // refresh entropy probs
+	d.coeffProbs = vp8DefaultCoeffProbs
+	for i := range d.coeffProbs {
+		for j := range d.coeffProbs[i] {
+			for k := range d.coeffProbs[i][j] {
+				for l := range d.coeffProbs[i][j][k] {
+					if fp.readBool(vp8CoeffUpdateProbs[i][j][k][l]) {
+						d.coeffProbs[i][j][k][l] = uint8(fp.readLiteral(8))
+					}
+				}
+			}
+		}
+	}
+	d.skipProb = -1
+	if fp.readBool(128) {
+		d.skipProb = fp.readLiteral(8)
+	}
+	return nil
+}
+
+// quantizers reads the quantizer indices and sets the dequantization factors
+// of each segment
+func (d *vp8Decoder) quantizers() {
+	fp := d.fp
+	base := fp.readLiteral(7)
+	y1DC := fp.readOptionalSigned(4)
+	y2DC := fp.readOptionalSigned(4)
+	y2AC := fp.readOptionalSigned(4)
+	uvDC := fp.readOptionalSigned(4)
+	uvAC := fp.readOptionalSigned(4)
+	idx := func(q, max int) int {
+		if q < 0 {
+			return 0
+		}
+		if q > max {
+			return max
+		}
+		return q
+	}
+	for s := range d.quant {
+		q := base
+		if d.segEnabled {
+			q = d.segQuant[s]
+			if !d.segAbsolute {
+				q += base
+			}
+		}
+		q = idx(q, 127)
+		qt := &d.quant[s]
+		qt.y1 = [2]int{vp8DCQuant[idx(q+y1DC, 127)], vp8ACQuant[q]}
+		qt.y2 = [2]int{2 * vp8DCQuant[idx(q+y2DC, 127)], vp8ACQuant[idx(q+y2AC, 127)] * 155 / 100}
+		if qt.y2[1] < 8 {
+			qt.y2[1] = 8
+		}
+		qt.uv = [2]int{vp8DCQuant[idx(q+uvDC, 117)], vp8ACQuant[idx(q+uvAC, 127)]}
+	}
+}
+
+// macroblock decodes the macroblock at column mbx and row mby
+func (d *vp8Decoder) macroblock(part *vp8BoolDecoder, mbx, mby int) {
+	fp := d.fp
+	seg := 0
+	if d.segUpdateMap {
+		if !fp.readBool(d.segProbs[0]) {
+			seg = fp.readBit(d.segProbs[1])
+		} else {
+			seg = 2 + fp.readBit(d.segProbs[2])
+		}
+	}
+	skip := d.skipProb >= 0 && fp.readBool(uint8(d.skipProb))
+
+	// Prediction modes
+	var ymode, uvmode int
+	var bmodes [16]uint8
+	above := d.aboveModes[4*mbx : 4*mbx+4]
+	if !fp.readBool(145) {
+		ymode = vp8BPred
+		for by := 0; by < 4; by++ {
+			for bx := 0; bx < 4; bx++ {
+				m := d.bmode(&vp8BModeProbs[above[bx]][d.leftModes[by]])
+				bmodes[4*by+bx] = m
+				above[bx] = m
+				d.leftModes[by] = m
+			}
+		}
+	} else {
+		var m uint8
+		switch {
+		case !fp.readBool(156):
+			ymode, m = vp8DC, vp8BDC
+			if fp.readBool(163) {
+				ymode, m = vp8V, vp8BVE
+			}
+		case !fp.readBool(128):
+			ymode, m = vp8H, vp8BHE
+		default:
+			ymode, m = vp8TM, vp8BTM
+		}
+		for j := 0; j < 4; j++ {
+			above[j] = m
+			d.leftModes[j] = m
+		}
+	}
+	switch {
+	case !fp.readBool(142):
+		uvmode = vp8DC
+	case !fp.readBool(114):
+		uvmode = vp8V
+	case !fp.readBool(183):
+		uvmode = vp8H
+	default:
+		uvmode = vp8TM
+	}
+
+	// Residuals
+	bpred := ymode == vp8BPred
+	d.coeffs = [25 * 16]int{}
+	nz := d.aboveNz[9*mbx : 9*mbx+9]
+	coded := false
+	if skip {
+		for j := 0; j < 8; j++ {
+			nz[j], d.leftNz[j] = 0, 0
+		}
+		if !bpred {
+			nz[8], d.leftNz[8] = 0, 0
+		}
+	} else {
+		coded = d.residuals(part, seg, bpred, nz)
+	}
+
+	d.reconstruct(mbx, mby, ymode, uvmode, &bmodes)
+	d.filterParams(mbx, mby, seg, bpred, coded)
+}
+
+// bmode reads a 4x4 luma prediction mode with the probabilities probs
+func (d *vp8Decoder) bmode(probs *[9]uint8) uint8 {
+	fp := d.fp
+	switch {
+	case !fp.readBool(probs[0]):
+		return vp8BDC
+	case !fp.readBool(probs[1]):
+		return vp8BTM
+	case !fp.readBool(probs[2]):
+		return vp8BVE
+	case !fp.readBool(probs[3]):
+		switch {
+		case !fp.readBool(probs[4]):
+			return vp8BHE
+		case !fp.readBool(probs[5]):
+			return vp8BRD
+		}
+		return vp8BVR
+	case !fp.readBool(probs[6]):
+		return vp8BLD
+	case !fp.readBool(probs[7]):
+		return vp8BVL
+	case !fp.readBool(probs[8]):
+		return vp8BHD
+	}
+	return vp8BHU
+}
+
+// residuals reads the coefficients of the macroblock and reports whether
+// any of them is not zero
+func (d *vp8Decoder) residuals(part *vp8BoolDecoder, seg int, bpred bool, nz []uint8) bool {
+	q := &d.quant[seg]
+	plane, first := 3, 0
+	if !bpred {
+		var y2 [16]int
+		ctx := nz[8] + d.leftNz[8]
+		nz[8] = d.coeffsBlock(part, 1, ctx, q.y2, 0, y2[:])
+		d.leftNz[8] = nz[8]
+		vp8InverseWHT(&y2, d.coeffs[:])
+		plane, first = 0, 1
+	}
+	for by := 0; by < 4; by++ {
+		for bx := 0; bx < 4; bx++ {
+			b := 16 * (4*by + bx)
+			nz[bx] = d.coeffsBlock(part, plane, nz[bx]+d.leftNz[by], q.y1, first, d.coeffs[b:b+16])
+			d.leftNz[by] = nz[bx]
+		}
+	}
+	for c := 0; c < 2; c++ {
+		for by := 0; by < 2; by++ {
+			for bx := 0; bx < 2; bx++ {
+				b := 16 * (16 + 4*c + 2*by + bx)
+				a, l := &nz[4+2*c+bx], &d.leftNz[4+2*c+by]
+				*a = d.coeffsBlock(part, 2, *a+*l, q.uv, 0, d.coeffs[b:b+16])
+				*l = *a
+			}
+		}
+	}
+	for _, v := range d.coeffs {
+		if v != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// coeffsBlock reads the tokens of a block of plane type plane from position
+// first, with the context ctx, into the dequantized coefficients out. It
+// returns 1 if the block has tokens other than the end of block.
+func (d *vp8Decoder) coeffsBlock(r *vp8BoolDecoder, plane int, ctx uint8, dq [2]int, first int, out []int) uint8 {
+	probs := &d.coeffProbs[plane]
+	n := first
+	p := &probs[vp8Bands[n]][ctx]
+	if !r.readBool(p[0]) {
+		return 0
+	}
+	for {
+		for !r.readBool(p[1]) {
+			n++
+			if n == 16 {
+				return 1
+			}
+			p = &probs[vp8Bands[n]][0]
+		}
+		var v int
+		next := 2
+		switch {
+		case !r.readBool(p[2]):
+			v, next = 1, 1
+		case !r.readBool(p[3]):
+			if !r.readBool(p[4]) {
+				v = 2
+			} else {
+				v = 3 + r.readBit(p[5])
+			}
+		case !r.readBool(p[6]):
+			if !r.readBool(p[7]) {
+				v = 5 + r.readBit(159)
+			} else {
+				v = 7 + 2*r.readBit(165) + r.readBit(145)
+			}
+		default:
+			b1 := r.readBit(p[8])
+			cat := 2*b1 + r.readBit(p[9+b1])
+			for _, prob := range vp8CatProbs[cat] {
+				v = 2*v + r.readBit(prob)
+			}
+			v += 3 + 8<<uint(cat)
+		}
+		if r.readBool(128) {
+			v = -v
+		}
+		if n > 0 {
+			v *= dq[1]
+		} else {
+			v *= dq[0]
+		}
+		out[vp8Zigzag[n]] = v
+		n++
+		if n == 16 {
+			return 1
+		}
+		p = &probs[vp8Bands[n]][next]
+		if !r.readBool(p[0]) {
+			return 1
+		}
+	}
+}
+
+// vp8InverseWHT transforms the Y2 coefficients in into the DC coefficients of
+// the 16 luma blocks of out
+func vp8InverseWHT(in *[16]int, out []int) {
+	var tmp [16]int
+	for i := 0; i < 4; i++ {
+		a1 := in[i] + in[12+i]
+		b1 := in[4+i] + in[8+i]
+		c1 := in[4+i] - in[8+i]
+		d1 := in[i] - in[12+i]
+		tmp[i] = a1 + b1
+		tmp[4+i] = c1 + d1
+		tmp[8+i] = a1 - b1
+		tmp[12+i] = d1 - c1
+	}
+	for i := 0; i < 4; i++ {
+		a1 := tmp[4*i] + tmp[4*i+3]
+		b1 := tmp[4*i+1] + tmp[4*i+2]
+		c1 := tmp[4*i+1] - tmp[4*i+2]
+		d1 := tmp[4*i] - tmp[4*i+3]
+		out[16*(4*i)] = (a1 + b1 + 3) >> 3
+		out[16*(4*i+1)] = (c1 + d1 + 3) >> 3
+		out[16*(4*i+2)] = (a1 - b1 + 3) >> 3
+		out[16*(4*i+3)] = (d1 - c1 + 3) >> 3
+	}
+}
+
+// vp8AddIDCT adds the inverse DCT of the coefficients in to the 4x4 block at
+// pos of the work area ws
+func vp8AddIDCT(in []int, ws []uint8, pos int) {
+	const c1, s1 = 20091, 35468
+	var tmp [16]int
+	for i := 0; i < 4; i++ {
+		a := in[i] + in[8+i]
+		b := in[i] - in[8+i]
+		c := (in[4+i] * s1 >> 16) - (in[12+i] + (in[12+i] * c1 >> 16))
+		d := (in[4+i] + (in[4+i] * c1 >> 16)) + (in[12+i] * s1 >> 16)
+		tmp[i] = a + d
+		tmp[12+i] = a - d
+		tmp[4+i] = b + c
+		tmp[8+i] = b - c
+	}
+	for i := 0; i < 4; i++ {
+		row := tmp[4*i : 4*i+4]
+		a := row[0] + row[2]
+		b := row[0] - row[2]
+		c := (row[1] * s1 >> 16) - (row[3] + (row[3] * c1 >> 16))
+		d := (row[1] + (row[1] * c1 >> 16)) + (row[3] * s1 >> 16)
+		p := pos + i*vp8WS
+		ws[p] = vp8Clamp255(int(ws[p]) + (a+d+4)>>3)
+		ws[p+1] = vp8Clamp255(int(ws[p+1]) + (b+c+4)>>3)
+		ws[p+2] = vp8Clamp255(int(ws[p+2]) + (b-c+4)>>3)
+		ws[p+3] = vp8Clamp255(int(ws[p+3]) + (a-d+4)>>3)
+	}
+}
+
+func vp8Clamp255(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// loadWork fills the top row and left column of the work area ws of a
+// macroblock of size pixels at column mbx and row mby of the plane pix
+func (d *vp8Decoder) loadWork(ws []uint8, pix []byte, stride, size, mbx, mby int) {
+	x0, y0 := size*mbx, size*mby
+	for r := 0; r < size; r++ {
+		if mbx == 0 {
+			ws[(r+1)*vp8WS] = 129
+		} else {
+			ws[(r+1)*vp8WS] = pix[(y0+r)*stride+x0-1]
+		}
+	}
+	switch {
+	case mby == 0:
+		for c := 0; c <= size+4; c++ {
+			ws[c] = 127
+		}
+	default:
+		top := (y0 - 1) * stride
+		if mbx == 0 {
+			ws[0] = 129
+		} else {
+			ws[0] = pix[top+x0-1]
+		}
+		copy(ws[1:1+size], pix[top+x0:top+x0+size])
+		for c := 0; c < 4; c++ {
+			if mbx < d.mbW-1 {
+				ws[1+size+c] = pix[top+x0+size+c]
+			} else {
+				ws[1+size+c] = pix[top+x0+size-1]
+			}
+		}
+	}
+}
+
+// reconstruct predicts the macroblock at column mbx and row mby, adds its
+// residuals and stores it in the planes
+func (d *vp8Decoder) reconstruct(mbx, mby, ymode, uvmode int, bmodes *[16]uint8) {
+	ws := d.yws[:]
+	d.loadWork(ws, d.y, d.yStride, 16, mbx, mby)
+	const origin = vp8WS + 1
+	if ymode == vp8BPred {
+		// The pixels above right of the blocks of the last column are the
+		// ones above right of the macroblock
+		for r := 4; r < 16; r += 4 {
+			copy(ws[r*vp8WS+17:r*vp8WS+21], ws[17:21])
+		}
+		for b := 0; b < 16; b++ {
+			pos := origin + (b/4)*4*vp8WS + (b%4)*4
+			vp8Predict4(ws, pos, bmodes[b])
+			vp8AddIDCT(d.coeffs[16*b:16*b+16], ws, pos)
+		}
+	} else {
+		vp8PredictBlock(ws, origin, 16, ymode, mbx, mby)
+		for b := 0; b < 16; b++ {
+			vp8AddIDCT(d.coeffs[16*b:16*b+16], ws, origin+(b/4)*4*vp8WS+(b%4)*4)
+		}
+	}
+	for r := 0; r < 16; r++ {
+		copy(d.y[(16*mby+r)*d.yStride+16*mbx:], ws[origin+r*vp8WS:origin+r*vp8WS+16])
+	}
+	for c, plane := range [2][]byte{d.cb, d.cr} {
+		ws := d.uws[:]
+		if c == 1 {
+			ws = d.vws[:]
+		}
+		d.loadWork(ws, plane, d.cStride, 8, mbx, mby)
+		vp8PredictBlock(ws, origin, 8, uvmode, mbx, mby)
+		for b := 0; b < 4; b++ {
+			k := 16 * (16 + 4*c + b)
+			vp8AddIDCT(d.coeffs[k:k+16], ws, origin+(b/2)*4*vp8WS+(b%2)*4)
+		}
+		for r := 0; r < 8; r++ {
+			copy(plane[(8*mby+r)*d.cStride+8*mbx:], ws[origin+r*vp8WS:origin+r*vp8WS+8])
+		}
+	}
+}
+
+// vp8PredictBlock predicts the size by size block at pos of the work area ws
+// with the whole macroblock mode, the DC prediction using only the edges
+// inside the frame
+func vp8PredictBlock(ws []uint8, pos, size, mode, mbx, mby int) {
+	switch mode {
+	case vp8DC:
+		sum, count := 0, 0
+		if mby > 0 {
+			for c := 0; c < size; c++ {
+				sum += int(ws[pos-vp8WS+c])
+			}
+			count += size
+		}
+		if mbx > 0 {
+			for r := 0; r < size; r++ {
+				sum += int(ws[pos+r*vp8WS-1])
+			}
+			count += size
+		}
+		v := uint8(128)
+		if count > 0 {
+			v = uint8((sum + count/2) / count)
+		}
+		for r := 0; r < size; r++ {
+			for c := 0; c < size; c++ {
+				ws[pos+r*vp8WS+c] = v
+			}
+		}
+	case vp8V:
+		for r := 0; r < size; r++ {
+			copy(ws[pos+r*vp8WS:pos+r*vp8WS+size], ws[pos-vp8WS:pos-vp8WS+size])
+		}
+	case vp8H:
+		for r := 0; r < size; r++ {
+			for c := 0; c < size; c++ {
+				ws[pos+r*vp8WS+c] = ws[pos+r*vp8WS-1]
+			}
+		}
+	default:
+		vp8PredictTM(ws, pos, size)
+	}
+}
+
+// vp8PredictTM predicts the size by size block at pos of the work area ws
+// with the TrueMotion mode
+func vp8PredictTM(ws []uint8, pos, size int) {
+	corner := int(ws[pos-vp8WS-1])
+	for r := 0; r < size; r++ {
+		left := int(ws[pos+r*vp8WS-1]) - corner
+		for c := 0; c < size; c++ {
+			ws[pos+r*vp8WS+c] = vp8Clamp255(left + int(ws[pos-vp8WS+c]))
+		}
+	}
+}
+
+// vp8Predict4 predicts the 4x4 block at pos of the work area ws with mode
+func vp8Predict4(ws []uint8, pos int, mode uint8) {
+	top := pos - vp8WS
+	// e holds the left column from bottom to top, the corner and the top
+	// row with the pixels above right
+	var e [13]int
+	for j := 0; j < 4; j++ {
+		e[j] = int(ws[pos+(3-j)*vp8WS-1])
+	}
+	for j := 0; j < 9; j++ {
+		e[4+j] = int(ws[top-1+j])
+	}
+	avg2 := func(x, y int) uint8 { return uint8((x + y + 1) >> 1) }
+	avg3 := func(x, y, z int) uint8 { return uint8((x + 2*y + z + 2) >> 2) }
+	var b [4][4]uint8
+	switch mode {
+	case vp8BDC:
+		sum := 4
+		for j := 0; j < 4; j++ {
+			sum += e[j] + e[5+j]
+		}
+		for r := range b {
+			for c := range b[r] {
+				b[r][c] = uint8(sum >> 3)
+			}
+		}
+	case vp8BTM:
+		vp8PredictTM(ws, pos, 4)
+		return
+	case vp8BVE:
+		for c := 0; c < 4; c++ {
+			v := avg3(e[4+c], e[5+c], e[6+c])
+			for r := 0; r < 4; r++ {
+				b[r][c] = v
+			}
+		}
+	case vp8BHE:
+		for r := 0; r < 4; r++ {
+			// The pixel below the left column repeats the last one
+			below := 2 - r
+			if below < 0 {
+				below = 0
+			}
+			v := avg3(e[4-r], e[3-r], e[below])
+			for c := 0; c < 4; c++ {
+				b[r][c] = v
+			}
+		}
+	case vp8BLD:
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				i := r + c
+				if i < 6 {
+					b[r][c] = avg3(e[5+i], e[6+i], e[7+i])
+				} else {
+					b[r][c] = avg3(e[11], e[12], e[12])
+				}
+			}
+		}
+	case vp8BRD:
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				i := 4 - r + c
+				b[r][c] = avg3(e[i-1], e[i], e[i+1])
+			}
+		}
+	case vp8BVR:
+		b[3][0] = avg3(e[1], e[2], e[3])
+		b[2][0] = avg3(e[2], e[3], e[4])
+		b[3][1], b[1][0] = avg3(e[3], e[4], e[5]), avg3(e[3], e[4], e[5])
+		b[2][1], b[0][0] = avg2(e[4], e[5]), avg2(e[4], e[5])
+		b[3][2], b[1][1] = avg3(e[4], e[5], e[6]), avg3(e[4], e[5], e[6])
+		b[2][2], b[0][1] = avg2(e[5], e[6]), avg2(e[5], e[6])
+		b[3][3], b[1][2] = avg3(e[5], e[6], e[7]), avg3(e[5], e[6], e[7])
+		b[2][3], b[0][2] = avg2(e[6], e[7]), avg2(e[6], e[7])
+		b[1][3] = avg3(e[6], e[7], e[8])
+		b[0][3] = avg2(e[7], e[8])
+	case vp8BVL:
+		a := e[5:]
+		b[0][0] = avg2(a[0], a[1])
+		b[1][0] = avg3(a[0], a[1], a[2])
+		b[2][0], b[0][1] = avg2(a[1], a[2]), avg2(a[1], a[2])
+		b[1][1], b[3][0] = avg3(a[1], a[2], a[3]), avg3(a[1], a[2], a[3])
+		b[2][1], b[0][2] = avg2(a[2], a[3]), avg2(a[2], a[3])
+		b[3][1], b[1][2] = avg3(a[2], a[3], a[4]), avg3(a[2], a[3], a[4])
+		b[2][2], b[0][3] = avg2(a[3], a[4]), avg2(a[3], a[4])
+		b[3][2], b[1][3] = avg3(a[3], a[4], a[5]), avg3(a[3], a[4], a[5])
+		b[2][3] = avg3(a[4], a[5], a[6])
+		b[3][3] = avg3(a[5], a[6], a[7])
+	case vp8BHD:
+		b[3][0] = avg2(e[0], e[1])
+		b[3][1] = avg3(e[0], e[1], e[2])
+		b[2][0], b[3][2] = avg2(e[1], e[2]), avg2(e[1], e[2])
+		b[2][1], b[3][3] = avg3(e[1], e[2], e[3]), avg3(e[1], e[2], e[3])
+		b[2][2], b[1][0] = avg2(e[2], e[3]), avg2(e[2], e[3])
+		b[2][3], b[1][1] = avg3(e[2], e[3], e[4]), avg3(e[2], e[3], e[4])
+		b[1][2], b[0][0] = avg2(e[3], e[4]), avg2(e[3], e[4])
+		b[1][3], b[0][1] = avg3(e[3], e[4], e[5]), avg3(e[3], e[4], e[5])
+		b[0][2] = avg3(e[4], e[5], e[6])
+		b[0][3] = avg3(e[5], e[6], e[7])
+	case vp8BHU:
+		l := [4]int{e[3], e[2], e[1], e[0]}
+		b[0][0] = avg2(l[0], l[1])
+		b[0][1] = avg3(l[0], l[1], l[2])
+		b[0][2], b[1][0] = avg2(l[1], l[2]), avg2(l[1], l[2])
+		b[0][3], b[1][1] = avg3(l[1], l[2], l[3]), avg3(l[1], l[2], l[3])
+		b[1][2], b[2][0] = avg2(l[2], l[3]), avg2(l[2], l[3])
+		b[1][3], b[2][1] = avg3(l[2], l[3], l[3]), avg3(l[2], l[3], l[3])
+		b[2][2], b[2][3] = uint8(l[3]), uint8(l[3])
+		for c := 0; c < 4; c++ {
+			b[3][c] = uint8(l[3])
+		}
+	}
+	for r := 0; r < 4; r++ {
+		copy(ws[pos+r*vp8WS:pos+r*vp8WS+4], b[r][:])
+	}
+}
+
+// filterParams sets the loop filter parameters of the macroblock
+func (d *vp8Decoder) filterParams(mbx, mby, seg int, bpred, coded bool) {
+	clamp := func(v int) int {
+		if v < 0 {
+			return 0
+		}
+		if v > 63 {
+			return 63
+		}
+		return v
+	}
+	level := d.level
+	if d.segEnabled {
+		level = d.segFilter[seg]
+		if !d.segAbsolute {
+			level += d.level
+		}
+		level = clamp(level)
+	}
+	if d.lfDeltas {
+		level += d.refDelta
+		if bpred {
+			level += d.modeDelta
+		}
+		level = clamp(level)
+	}
+	mf := &d.filters[mby*d.mbW+mbx]
+	if level == 0 {
+		return
+	}
+	ilevel := level
+	if d.sharpness > 0 {
+		if d.sharpness > 4 {
+			ilevel >>= 2
+		} else {
+			ilevel >>= 1
+		}
+		if ilevel > 9-d.sharpness {
+			ilevel = 9 - d.sharpness
+		}
+	}
+	if ilevel < 1 {
+		ilevel = 1
+	}
+	mf.limit = 2*level + ilevel
+	mf.ilevel = ilevel
+	switch {
+	case level >= 40:
+		mf.hevThresh = 2
+	case level >= 15:
+		mf.hevThresh = 1
+	}
+	mf.inner = bpred || coded
+}
+
+// loopFilter applies the loop filter to the edges of every macroblock
+func (d *vp8Decoder) loopFilter() {
+	for mby := 0; mby < d.mbH; mby++ {
+		for mbx := 0; mbx < d.mbW; mbx++ {
+			mf := &d.filters[mby*d.mbW+mbx]
+			if mf.limit == 0 {
+				continue
+			}
+			yPos := 16*mby*d.yStride + 16*mbx
+			cPos := 8*mby*d.cStride + 8*mbx
+			if d.simple {
+				if mbx > 0 {
+					vp8SimpleEdge(d.y, yPos, 1, d.yStride, 16, mf.limit+4)
+				}
+				if mf.inner {
+					for x := 4; x < 16; x += 4 {
+						vp8SimpleEdge(d.y, yPos+x, 1, d.yStride, 16, mf.limit)
+					}
+				}
+				if mby > 0 {
+					vp8SimpleEdge(d.y, yPos, d.yStride, 1, 16, mf.limit+4)
+				}
+				if mf.inner {
+					for y := 4; y < 16; y += 4 {
+						vp8SimpleEdge(d.y, yPos+y*d.yStride, d.yStride, 1, 16, mf.limit)
+					}
+				}
+				continue
+			}
+			if mbx > 0 {
+				mf.edge(d.y, yPos, 1, d.yStride, 16, true)
+				mf.edge(d.cb, cPos, 1, d.cStride, 8, true)
+				mf.edge(d.cr, cPos, 1, d.cStride, 8, true)
+			}
+			if mf.inner {
+				for x := 4; x < 16; x += 4 {
+					mf.edge(d.y, yPos+x, 1, d.yStride, 16, false)
+				}
+				mf.edge(d.cb, cPos+4, 1, d.cStride, 8, false)
+				mf.edge(d.cr, cPos+4, 1, d.cStride, 8, false)
+			}
+			if mby > 0 {
+				mf.edge(d.y, yPos, d.yStride, 1, 16, true)
+				mf.edge(d.cb, cPos, d.cStride, 1, 8, true)
+				mf.edge(d.cr, cPos, d.cStride, 1, 8, true)
+			}
+			if mf.inner {
+				for y := 4; y < 16; y += 4 {
+					mf.edge(d.y, yPos+y*d.yStride, d.yStride, 1, 16, false)
+				}
+				mf.edge(d.cb, cPos+4*d.cStride, d.cStride, 1, 8, false)
+				mf.edge(d.cr, cPos+4*d.cStride, d.cStride, 1, 8, false)
+			}
+		}
+	}
+}
+
+func vp8Abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vp8Clamp127 clamps v to the range of a signed byte
+func vp8Clamp127(v int) int {
+	if v < -128 {
+		return -128
+	}
+	if v > 127 {
+		return 127
+	}
+	return v
+}
+
+// vp8Adjust moves the two pixels on each side of the edge before pix[pos],
+// step being the distance between the pixels across the edge, and returns
+// the adjustment of the first pixel after the edge
+func vp8Adjust(pix []byte, pos, step int, outer bool) int {
+	p1, p0 := int(pix[pos-2*step])-128, int(pix[pos-step])-128
+	q0, q1 := int(pix[pos])-128, int(pix[pos+step])-128
+	a := 3 * (q0 - p0)
+	if outer {
+		a += vp8Clamp127(p1 - q1)
+	}
+	a = vp8Clamp127(a)
+	b := vp8Clamp127(a+3) >> 3
+	a = vp8Clamp127(a+4) >> 3
+	pix[pos] = uint8(vp8Clamp127(q0-a) + 128)
+	pix[pos-step] = uint8(vp8Clamp127(p0+b) + 128)
+	return a
+}
+
+// vp8SimpleEdge applies the simple loop filter to the count pixels of the
+// edge starting at pos, next being the distance between them
+func vp8SimpleEdge(pix []byte, pos, step, next, count, limit int) {
+	for j := 0; j < count; j, pos = j+1, pos+next {
+		if vp8Abs(int(pix[pos-step])-int(pix[pos]))*2+vp8Abs(int(pix[pos-2*step])-int(pix[pos+step]))>>1 <= limit {
+			vp8Adjust(pix, pos, step, true)
+		}
+	}
+}
+
+// edge applies the normal loop filter to the count pixels of the edge
+// starting at pos, next being the distance between them, with the filter of
+// the macroblock edges if mbEdge is true
+func (mf *vp8MBFilter) edge(pix []byte, pos, step, next, count int, mbEdge bool) {
+	limit := mf.limit
+	if mbEdge {
+		limit += 4
+	}
+	for j := 0; j < count; j, pos = j+1, pos+next {
+		p3, p2 := int(pix[pos-4*step]), int(pix[pos-3*step])
+		p1, p0 := int(pix[pos-2*step]), int(pix[pos-step])
+		q0, q1 := int(pix[pos]), int(pix[pos+step])
+		q2, q3 := int(pix[pos+2*step]), int(pix[pos+3*step])
+		if vp8Abs(p0-q0)*2+vp8Abs(p1-q1)>>1 > limit ||
+			vp8Abs(p3-p2) > mf.ilevel || vp8Abs(p2-p1) > mf.ilevel || vp8Abs(p1-p0) > mf.ilevel ||
+			vp8Abs(q3-q2) > mf.ilevel || vp8Abs(q2-q1) > mf.ilevel || vp8Abs(q1-q0) > mf.ilevel {
+			continue
+		}
+		hev := vp8Abs(p1-p0) > mf.hevThresh || vp8Abs(q1-q0) > mf.hevThresh
+		switch {
+		case hev:
+			vp8Adjust(pix, pos, step, true)
+		case mbEdge:
+			w := vp8Clamp127(vp8Clamp127(p1-q1) + 3*(q0-p0))
+			for k, m := range [3]int{27, 18, 9} {
+				a := vp8Clamp127((m*w + 63) >> 7)
+				pix[pos+k*step] = uint8(vp8Clamp127(int(pix[pos+k*step])-128-a) + 128)
+				pix[pos-(k+1)*step] = uint8(vp8Clamp127(int(pix[pos-(k+1)*step])-128+a) + 128)
+			}
+		default:
+			a := (vp8Adjust(pix, pos, step, false) + 1) >> 1
+			pix[pos+step] = uint8(vp8Clamp127(q1-128-a) + 128)
+			pix[pos-2*step] = uint8(vp8Clamp127(p1-128+a) + 128)
+		}
+	}
+}
+
+var (
+	vp8Bands    = [17]int{0, 1, 2, 3, 6, 4, 5, 6, 6, 6, 6, 6, 6, 6, 6, 7, 0}
+	vp8Zigzag   = [16]int{0, 1, 4, 8, 5, 2, 3, 6, 9, 12, 13, 10, 7, 11, 14, 15}
+	vp8CatProbs = [4][]uint8{
+		{173, 148, 140},
+		{176, 155, 140, 135},
+		{180, 157, 141, 134, 130},
+		{254, 254, 243, 230, 196, 177, 153, 140, 133, 130, 129},
+	}
+)
+
+// vp8BModeProbs are the probabilities of the 4x4 luma prediction modes of
+// key frames, given the modes of the subblocks above and left (section 11.5)
+var vp8BModeProbs = [vp8NumBModes][vp8NumBModes][9]uint8{
+	{
+		{231, 120, 48, 89, 115, 113, 120, 152, 112},
+		{152, 179, 64, 126, 170, 118, 46, 70, 95},
+		{175, 69, 143, 80, 85, 82, 72, 155, 103},
+		{56, 58, 10, 171, 218, 189, 17, 13, 152},
+		{114, 26, 17, 163, 44, 195, 21, 10, 173},
+		{121, 24, 80, 195, 26, 62, 44, 64, 85},
+		{144, 71, 10, 38, 171, 213, 144, 34, 26},
+		{170, 46, 55, 19, 136, 160, 33, 206, 71},
+		{63, 20, 8, 114, 114, 208, 12, 9, 226},
+		{81, 40, 11, 96, 182, 84, 29, 16, 36},
+	},
+	{
+		{134, 183, 89, 137, 98, 101, 106, 165, 148},
+		{72, 187, 100, 130, 157, 111, 32, 75, 80},
+		{66, 102, 167, 99, 74, 62, 40, 234, 128},
+		{41, 53, 9, 178, 241, 141, 26, 8, 107},
+		{74, 43, 26, 146, 73, 166, 49, 23, 157},
+		{65, 38, 105, 160, 51, 52, 31, 115, 128},
+		{104, 79, 12, 27, 217, 255, 87, 17, 7},
+		{87, 68, 71, 44, 114, 51, 15, 186, 23},
+		{47, 41, 14, 110, 182, 183, 21, 17, 194},
+		{66, 45, 25, 102, 197, 189, 23, 18, 22},
+	},
+	{
+		{88, 88, 147, 150, 42, 46, 45, 196, 205},
+		{43, 97, 183, 117, 85, 38, 35, 179, 61},
+		{39, 53, 200, 87, 26, 21, 43, 232, 171},
+		{56, 34, 51, 104, 114, 102, 29, 93, 77},
+		{39, 28, 85, 171, 58, 165, 90, 98, 64},
+		{34, 22, 116, 206, 23, 34, 43, 166, 73},
+		{107, 54, 32, 26, 51, 1, 81, 43, 31},
+		{68, 25, 106, 22, 64, 171, 36, 225, 114},
+		{34, 19, 21, 102, 132, 188, 16, 76, 124},
+		{62, 18, 78, 95, 85, 57, 50, 48, 51},
+	},
+	{
+		{193, 101, 35, 159, 215, 111, 89, 46, 111},
+		{60, 148, 31, 172, 219, 228, 21, 18, 111},
+		{112, 113, 77, 85, 179, 255, 38, 120, 114},
+		{40, 42, 1, 196, 245, 209, 10, 25, 109},
+		{88, 43, 29, 140, 166, 213, 37, 43, 154},
+		{61, 63, 30, 155, 67, 45, 68, 1, 209},
+		{100, 80, 8, 43, 154, 1, 51, 26, 71},
+		{142, 78, 78, 16, 255, 128, 34, 197, 171},
+		{41, 40, 5, 102, 211, 183, 4, 1, 221},
+		{51, 50, 17, 168, 209, 192, 23, 25, 82},
+	},
+	{
+		{138, 31, 36, 171, 27, 166, 38, 44, 229},
+		{67, 87, 58, 169, 82, 115, 26, 59, 179},
+		{63, 59, 90, 180, 59, 166, 93, 73, 154},
+		{40, 40, 21, 116, 143, 209, 34, 39, 175},
+		{47, 15, 16, 183, 34, 223, 49, 45, 183},
+		{46, 17, 33, 183, 6, 98, 15, 32, 183},
+		{57, 46, 22, 24, 128, 1, 54, 17, 37},
+		{65, 32, 73, 115, 28, 128, 23, 128, 205},
+		{40, 3, 9, 115, 51, 192, 18, 6, 223},
+		{87, 37, 9, 115, 59, 77, 64, 21, 47},
+	},
+	{
+		{104, 55, 44, 218, 9, 54, 53, 130, 226},
+		{64, 90, 70, 205, 40, 41, 23, 26, 57},
+		{54, 57, 112, 184, 5, 41, 38, 166, 213},
+		{30, 34, 26, 133, 152, 116, 10, 32, 134},
+		{39, 19, 53, 221, 26, 114, 32, 73, 255},
+		{31, 9, 65, 234, 2, 15, 1, 118, 73},
+		{75, 32, 12, 51, 192, 255, 160, 43, 51},
+		{88, 31, 35, 67, 102, 85, 55, 186, 85},
+		{56, 21, 23, 111, 59, 205, 45, 37, 192},
+		{55, 38, 70, 124, 73, 102, 1, 34, 98},
+	},
+	{
+		{125, 98, 42, 88, 104, 85, 117, 175, 82},
+		{95, 84, 53, 89, 128, 100, 113, 101, 45},
+		{75, 79, 123, 47, 51, 128, 81, 171, 1},
+		{57, 17, 5, 71, 102, 57, 53, 41, 49},
+		{38, 33, 13, 121, 57, 73, 26, 1, 85},
+		{41, 10, 67, 138, 77, 110, 90, 47, 114},
+		{115, 21, 2, 10, 102, 255, 166, 23, 6},
+		{101, 29, 16, 10, 85, 128, 101, 196, 26},
+		{57, 18, 10, 102, 102, 213, 34, 20, 43},
+		{117, 20, 15, 36, 163, 128, 68, 1, 26},
+	},
+	{
+		{102, 61, 71, 37, 34, 53, 31, 243, 192},
+		{69, 60, 71, 38, 73, 119, 28, 222, 37},
+		{68, 45, 128, 34, 1, 47, 11, 245, 171},
+		{62, 17, 19, 70, 146, 85, 55, 62, 70},
+		{37, 43, 37, 154, 100, 163, 85, 160, 1},
+		{63, 9, 92, 136, 28, 64, 32, 201, 85},
+		{75, 15, 9, 9, 64, 255, 184, 119, 16},
+		{86, 6, 28, 5, 64, 255, 25, 248, 1},
+		{56, 8, 17, 132, 137, 255, 55, 116, 128},
+		{58, 15, 20, 82, 135, 57, 26, 121, 40},
+	},
+	{
+		{164, 50, 31, 137, 154, 133, 25, 35, 218},
+		{51, 103, 44, 131, 131, 123, 31, 6, 158},
+		{86, 40, 64, 135, 148, 224, 45, 183, 128},
+		{22, 26, 17, 131, 240, 154, 14, 1, 209},
+		{45, 16, 21, 91, 64, 222, 7, 1, 197},
+		{56, 21, 39, 155, 60, 138, 23, 102, 213},
+		{83, 12, 13, 54, 192, 255, 68, 47, 28},
+		{85, 26, 85, 85, 128, 128, 32, 146, 171},
+		{18, 11, 7, 63, 144, 171, 4, 4, 246},
+		{35, 27, 10, 146, 174, 171, 12, 26, 128},
+	},
+	{
+		{190, 80, 35, 99, 180, 80, 126, 54, 45},
+		{85, 126, 47, 87, 176, 51, 41, 20, 32},
+		{101, 75, 128, 139, 118, 146, 116, 128, 85},
+		{56, 41, 15, 176, 236, 85, 37, 9, 62},
+		{71, 30, 17, 119, 118, 255, 17, 18, 138},
+		{101, 38, 60, 138, 55, 70, 43, 26, 142},
+		{146, 36, 19, 30, 171, 255, 97, 27, 20},
+		{138, 45, 61, 62, 219, 1, 81, 188, 64},
+		{32, 41, 20, 117, 151, 142, 20, 21, 163},
+		{112, 19, 12, 61, 195, 128, 48, 4, 24},
+	},
+}
+
+// vp8CoeffUpdateProbs are the probabilities of an update of each token
+// probability (section 13.4)
+var vp8CoeffUpdateProbs = [4][8][3][11]uint8{
+	{
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{176, 246, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{223, 241, 252, 255, 255, 255, 255, 255, 255, 255, 255},
+			{249, 253, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 244, 252, 255, 255, 255, 255, 255, 255, 255, 255},
+			{234, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{253, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 246, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{239, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 248, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{251, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{251, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 253, 255, 254, 255, 255, 255, 255, 255, 255},
+			{250, 255, 254, 255, 254, 255, 255, 255, 255, 255, 255},
+			{254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+	},
+	{
+		{
+			{217, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{225, 252, 241, 253, 255, 255, 254, 255, 255, 255, 255},
+			{234, 250, 241, 250, 253, 255, 253, 254, 255, 255, 255},
+		},
+		{
+			{255, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{223, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{238, 253, 254, 254, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 248, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{249, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 253, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{247, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{252, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{253, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{250, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+	},
+	{
+		{
+			{186, 251, 250, 255, 255, 255, 255, 255, 255, 255, 255},
+			{234, 251, 244, 254, 255, 255, 255, 255, 255, 255, 255},
+			{251, 251, 243, 253, 254, 255, 254, 255, 255, 255, 255},
+		},
+		{
+			{255, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{236, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{251, 253, 253, 254, 254, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 254, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+	},
+	{
+		{
+			{248, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{250, 254, 252, 254, 255, 255, 255, 255, 255, 255, 255},
+			{248, 254, 249, 253, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 253, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{246, 253, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{252, 254, 251, 254, 254, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 254, 252, 255, 255, 255, 255, 255, 255, 255, 255},
+			{248, 254, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{253, 255, 254, 254, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 251, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{245, 251, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{253, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 251, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{252, 253, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 252, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{249, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 253, 255, 255, 255, 255, 255, 255, 255, 255},
+			{250, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+			{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+	},
+}
+
+// vp8DefaultCoeffProbs are the token probabilities in use before the updates
+// of the frame header (section 13.5)
+var vp8DefaultCoeffProbs = [4][8][3][11]uint8{
+	{
+		{
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+		{
+			{253, 136, 254, 255, 228, 219, 128, 128, 128, 128, 128},
+			{189, 129, 242, 255, 227, 213, 255, 219, 128, 128, 128},
+			{106, 126, 227, 252, 214, 209, 255, 255, 128, 128, 128},
+		},
+		{
+			{1, 98, 248, 255, 236, 226, 255, 255, 128, 128, 128},
+			{181, 133, 238, 254, 221, 234, 255, 154, 128, 128, 128},
+			{78, 134, 202, 247, 198, 180, 255, 219, 128, 128, 128},
+		},
+		{
+			{1, 185, 249, 255, 243, 255, 128, 128, 128, 128, 128},
+			{184, 150, 247, 255, 236, 224, 128, 128, 128, 128, 128},
+			{77, 110, 216, 255, 236, 230, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 101, 251, 255, 241, 255, 128, 128, 128, 128, 128},
+			{170, 139, 241, 252, 236, 209, 255, 255, 128, 128, 128},
+			{37, 116, 196, 243, 228, 255, 255, 255, 128, 128, 128},
+		},
+		{
+			{1, 204, 254, 255, 245, 255, 128, 128, 128, 128, 128},
+			{207, 160, 250, 255, 238, 128, 128, 128, 128, 128, 128},
+			{102, 103, 231, 255, 211, 171, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 152, 252, 255, 240, 255, 128, 128, 128, 128, 128},
+			{177, 135, 243, 255, 234, 225, 128, 128, 128, 128, 128},
+			{80, 129, 211, 255, 194, 224, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{246, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{255, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+	},
+	{
+		{
+			{198, 35, 237, 223, 193, 187, 162, 160, 145, 155, 62},
+			{131, 45, 198, 221, 172, 176, 220, 157, 252, 221, 1},
+			{68, 47, 146, 208, 149, 167, 221, 162, 255, 223, 128},
+		},
+		{
+			{1, 149, 241, 255, 221, 224, 255, 255, 128, 128, 128},
+			{184, 141, 234, 253, 222, 220, 255, 199, 128, 128, 128},
+			{81, 99, 181, 242, 176, 190, 249, 202, 255, 255, 128},
+		},
+		{
+			{1, 129, 232, 253, 214, 197, 242, 196, 255, 255, 128},
+			{99, 121, 210, 250, 201, 198, 255, 202, 128, 128, 128},
+			{23, 91, 163, 242, 170, 187, 247, 210, 255, 255, 128},
+		},
+		{
+			{1, 200, 246, 255, 234, 255, 128, 128, 128, 128, 128},
+			{109, 178, 241, 255, 231, 245, 255, 255, 128, 128, 128},
+			{44, 130, 201, 253, 205, 192, 255, 255, 128, 128, 128},
+		},
+		{
+			{1, 132, 239, 251, 219, 209, 255, 165, 128, 128, 128},
+			{94, 136, 225, 251, 218, 190, 255, 255, 128, 128, 128},
+			{22, 100, 174, 245, 186, 161, 255, 199, 128, 128, 128},
+		},
+		{
+			{1, 182, 249, 255, 232, 235, 128, 128, 128, 128, 128},
+			{124, 143, 241, 255, 227, 234, 128, 128, 128, 128, 128},
+			{35, 77, 181, 251, 193, 211, 255, 205, 128, 128, 128},
+		},
+		{
+			{1, 157, 247, 255, 236, 231, 255, 255, 128, 128, 128},
+			{121, 141, 235, 255, 225, 227, 255, 255, 128, 128, 128},
+			{45, 99, 188, 251, 195, 217, 255, 224, 128, 128, 128},
+		},
+		{
+			{1, 1, 251, 255, 213, 255, 128, 128, 128, 128, 128},
+			{203, 1, 248, 255, 255, 128, 128, 128, 128, 128, 128},
+			{137, 1, 177, 255, 224, 255, 128, 128, 128, 128, 128},
+		},
+	},
+	{
+		{
+			{253, 9, 248, 251, 207, 208, 255, 192, 128, 128, 128},
+			{175, 13, 224, 243, 193, 185, 249, 198, 255, 255, 128},
+			{73, 17, 171, 221, 161, 179, 236, 167, 255, 234, 128},
+		},
+		{
+			{1, 95, 247, 253, 212, 183, 255, 255, 128, 128, 128},
+			{239, 90, 244, 250, 211, 209, 255, 255, 128, 128, 128},
+			{155, 77, 195, 248, 188, 195, 255, 255, 128, 128, 128},
+		},
+		{
+			{1, 24, 239, 251, 218, 219, 255, 205, 128, 128, 128},
+			{201, 51, 219, 255, 196, 186, 128, 128, 128, 128, 128},
+			{69, 46, 190, 239, 201, 218, 255, 228, 128, 128, 128},
+		},
+		{
+			{1, 191, 251, 255, 255, 128, 128, 128, 128, 128, 128},
+			{223, 165, 249, 255, 213, 255, 128, 128, 128, 128, 128},
+			{141, 124, 248, 255, 255, 128, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 16, 248, 255, 255, 128, 128, 128, 128, 128, 128},
+			{190, 36, 230, 255, 236, 255, 128, 128, 128, 128, 128},
+			{149, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 226, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{247, 192, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{240, 128, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+		{
+			{1, 134, 252, 255, 255, 128, 128, 128, 128, 128, 128},
+			{213, 62, 250, 255, 255, 128, 128, 128, 128, 128, 128},
+			{55, 93, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+		{
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+			{128, 128, 128, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+	},
+	{
+		{
+			{202, 24, 213, 235, 186, 191, 220, 160, 240, 175, 255},
+			{126, 38, 182, 232, 169, 184, 228, 174, 255, 187, 128},
+			{61, 46, 138, 219, 151, 178, 240, 170, 255, 216, 128},
+		},
+		{
+			{1, 112, 230, 250, 199, 191, 247, 159, 255, 255, 128},
+			{166, 109, 228, 252, 211, 215, 255, 174, 128, 128, 128},
+			{39, 77, 162, 232, 172, 180, 245, 178, 255, 255, 128},
+		},
+		{
+			{1, 52, 220, 246, 198, 199, 249, 220, 255, 255, 128},
+			{124, 74, 191, 243, 183, 193, 250, 221, 255, 255, 128},
+			{24, 71, 130, 219, 154, 170, 243, 182, 255, 255, 128},
+		},
+		{
+			{1, 182, 225, 249, 219, 240, 255, 224, 128, 128, 128},
+			{149, 150, 226, 252, 216, 205, 255, 171, 128, 128, 128},
+			{28, 108, 170, 242, 183, 194, 254, 223, 255, 255, 128},
+		},
+		{
+			{1, 81, 230, 252, 204, 203, 255, 192, 128, 128, 128},
+			{123, 102, 209, 247, 188, 196, 255, 233, 128, 128, 128},
+			{20, 95, 153, 243, 164, 173, 255, 203, 128, 128, 128},
+		},
+		{
+			{1, 222, 248, 255, 216, 213, 128, 128, 128, 128, 128},
+			{168, 175, 246, 252, 235, 205, 255, 255, 128, 128, 128},
+			{47, 116, 215, 255, 211, 212, 255, 255, 128, 128, 128},
+		},
+		{
+			{1, 121, 236, 253, 212, 214, 255, 255, 128, 128, 128},
+			{141, 84, 213, 252, 201, 202, 255, 219, 128, 128, 128},
+			{42, 80, 160, 240, 162, 185, 255, 205, 128, 128, 128},
+		},
+		{
+			{1, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{244, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+			{238, 1, 255, 128, 128, 128, 128, 128, 128, 128, 128},
+		},
+	},
+}
+
+// vp8DCQuant and vp8ACQuant map the quantizer indices to the DC and AC
+// dequantization factors (section 14.1)
+var (
+	vp8DCQuant = [128]int{
+		4, 5, 6, 7, 8, 9, 10, 10, 11, 12, 13, 14, 15, 16, 17, 17,
+		18, 19, 20, 20, 21, 21, 22, 22, 23, 23, 24, 25, 25, 26, 27, 28,
+		29, 30, 31, 32, 33, 34, 35, 36, 37, 37, 38, 39, 40, 41, 42, 43,
+		44, 45, 46, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58,
+		59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
+		75, 76, 76, 77, 78, 79, 80, 81, 82, 83, 84, 85, 86, 87, 88, 89,
+		91, 93, 95, 96, 98, 100, 101, 102, 104, 106, 108, 110, 112, 114, 116, 118,
+		122, 124, 126, 128, 130, 132, 134, 136, 138, 140, 143, 145, 148, 151, 154, 157,
+	}
+	vp8ACQuant = [128]int{
+		4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19,
+		20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35,
+		36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+		52, 53, 54, 55, 56, 57, 58, 60, 62, 64, 66, 68, 70, 72, 74, 76,
+		78, 80, 82, 84, 86, 88, 90, 92, 94, 96, 98, 100, 102, 104, 106, 108,
+		110, 112, 114, 116, 119, 122, 125, 128, 131, 134, 137, 140, 143, 146, 149, 152,
+		155, 158, 161, 164, 167, 170, 173, 177, 181, 185, 189, 193, 197, 201, 205, 209,
+		213, 217, 221, 225, 229, 234, 239, 245, 249, 254, 259, 264, 269, 274, 279, 284,
+	}
+)
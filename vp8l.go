@@ -0,0 +1,597 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// WebP lossless (VP8L) decoding, as specified in RFC 9649
+
+const (
+	vp8lPredictor     = 0
+	vp8lColor         = 1
+	vp8lSubtractGreen = 2
+	vp8lColorIndexing = 3
+)
+
+var errVP8LData = errors.New("invalid or truncated WebP lossless data")
+
+// vp8lReader reads the bits of a VP8L stream, least significant first
+type vp8lReader struct {
+	data []byte
+	pos  int    // next byte to buffer
+	val  uint64 // buffered bits, the next one in the lowest bit
+	n    uint   // number of buffered bits
+	pad  uint   // number of buffered zero bits past the end of data
+	err  error
+}
+
+func (r *vp8lReader) fill() {
+	for r.n <= 56 {
+		if r.pos < len(r.data) {
+			r.val |= uint64(r.data[r.pos]) << r.n
+			r.pos++
+		} else {
+			r.pad += 8
+		}
+		r.n += 8
+	}
+}
+
+func (r *vp8lReader) skip(n uint) {
+	r.val >>= n
+	r.n -= n
+	if r.n < r.pad && r.err == nil {
+		r.err = errVP8LData
+	}
+}
+
+func (r *vp8lReader) readBits(n uint) uint32 {
+	if r.n < n {
+		r.fill()
+	}
+	v := uint32(r.val & (1<<n - 1))
+	r.skip(n)
+	return v
+}
+
+// vp8lCode is a canonical prefix code
+type vp8lCode struct {
+	single  int      // the only symbol of a code without bits, or -1
+	counts  [16]int  // number of codes of each length
+	symbols []int    // symbols in the order of their codes
+	table   []uint32 // symbol<<4 | length, by the next 8 bits, for short codes
+}
+
+// newVP8LCode builds the canonical code given the code length of each symbol
+func newVP8LCode(lengths []int) (c *vp8lCode, err error) {
+	c = &vp8lCode{single: -1}
+	used := 0
+	for sym, l := range lengths {
+		if l > 0 {
+			c.counts[l]++
+			c.single = sym
+			used++
+		}
+	}
+	switch used {
+	case 0:
+		return nil, errVP8LData
+	case 1:
+		return
+	}
+	c.single = -1
+	left := 1
+	for l := 1; l < 16; l++ {
+		left = left<<1 - c.counts[l]
+		if left < 0 {
+			return nil, errVP8LData
+		}
+	}
+	var offs [16]int
+	for l := 1; l < 15; l++ {
+		offs[l+1] = offs[l] + c.counts[l]
+	}
+	c.symbols = make([]int, used)
+	for sym, l := range lengths {
+		if l > 0 {
+			c.symbols[offs[l]] = sym
+			offs[l]++
+		}
+	}
+	c.table = make([]uint32, 256)
+	code, pos := 0, 0
+	for l := 1; l <= 8; l++ {
+		for j := 0; j < c.counts[l]; j++ {
+			rev := 0
+			for b := 0; b < l; b++ {
+				rev |= (code >> uint(b) & 1) << uint(l-1-b)
+			}
+			for idx := rev; idx < 256; idx += 1 << uint(l) {
+				c.table[idx] = uint32(c.symbols[pos])<<4 | uint32(l)
+			}
+			code++
+			pos++
+		}
+		code <<= 1
+	}
+	return
+}
+
+// decode reads the next symbol of code c
+func (r *vp8lReader) decode(c *vp8lCode) int {
+	if c.single >= 0 {
+		return c.single
+	}
+	if r.n < 15 {
+		r.fill()
+	}
+	if e := c.table[r.val&0xff]; e != 0 {
+		r.skip(uint(e & 15))
+		return int(e >> 4)
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l < 16; l++ {
+		code |= int(r.val >> uint(l-1) & 1)
+		count := c.counts[l]
+		if code-count < first {
+			r.skip(uint(l))
+			return c.symbols[index+code-first]
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	r.err = errVP8LData
+	return 0
+}
+
+// vp8lCodeLengthOrder is the order of the lengths of the code length code
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// readCode reads a prefix code of alphabet size symbols
+func (r *vp8lReader) readCode(size int) (*vp8lCode, error) {
+	lengths := make([]int, size)
+	if r.readBits(1) == 1 {
+		// Simple code of one or two symbols
+		count := r.readBits(1) + 1
+		sym := int(r.readBits(1 + 7*uint(r.readBits(1))))
+		if sym >= size {
+			return nil, errVP8LData
+		}
+		lengths[sym] = 1
+		if count == 2 {
+			sym = int(r.readBits(8))
+			if sym >= size {
+				return nil, errVP8LData
+			}
+			lengths[sym] = 1
+		}
+		return newVP8LCode(lengths)
+	}
+	var clLengths [19]int
+	count := int(r.readBits(4)) + 4
+	for j := 0; j < count; j++ {
+		clLengths[vp8lCodeLengthOrder[j]] = int(r.readBits(3))
+	}
+	clCode, err := newVP8LCode(clLengths[:])
+	if err != nil {
+		return nil, err
+	}
+	maxSym := size
+	if r.readBits(1) == 1 {
+		maxSym = 2 + int(r.readBits(2+2*uint(r.readBits(3))))
+		if maxSym > size {
+			return nil, errVP8LData
+		}
+	}
+	prev := 8
+	for sym := 0; sym < size && maxSym > 0 && r.err == nil; maxSym-- {
+		l := r.decode(clCode)
+		if l < 16 {
+			lengths[sym] = l
+			sym++
+			if l != 0 {
+				prev = l
+			}
+			continue
+		}
+		repeat, val := 0, 0
+		switch l {
+		case 16:
+			repeat, val = 3+int(r.readBits(2)), prev
+		case 17:
+			repeat = 3 + int(r.readBits(3))
+		default:
+			repeat = 11 + int(r.readBits(7))
+		}
+		if sym+repeat > size {
+			return nil, errVP8LData
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[sym] = val
+			sym++
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return newVP8LCode(lengths)
+}
+
+// vp8lTransform is a transform to undo once the image is decoded
+type vp8lTransform struct {
+	kind  uint32
+	width int      // width of the image the transform applies to
+	bits  uint     // size bits of the blocks or of the packed pixels
+	data  []uint32 // transform image or color table
+}
+
+// vp8lSubsample returns size divided by 1<<bits, rounded up
+func vp8lSubsample(size int, bits uint) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+// decodeVP8L decodes the WebP lossless image data
+func decodeVP8L(data []byte) (img *image.NRGBA, err error) {
+	if len(data) < 5 || data[0] != 0x2f {
+		return nil, errVP8LData
+	}
+	r := &vp8lReader{data: data[1:]}
+	w := int(r.readBits(14)) + 1
+	h := int(r.readBits(14)) + 1
+	r.readBits(1) // alpha hint
+	if r.readBits(3) != 0 {
+		return nil, fmt.Errorf("unsupported WebP lossless version")
+	}
+	pix, err := r.imageStream(w, h)
+	if err != nil {
+		return
+	}
+	img = image.NewNRGBA(image.Rect(0, 0, w, h))
+	for j, p := range pix {
+		img.Pix[4*j] = uint8(p >> 16)
+		img.Pix[4*j+1] = uint8(p >> 8)
+		img.Pix[4*j+2] = uint8(p)
+		img.Pix[4*j+3] = uint8(p >> 24)
+	}
+	return
+}
+
+// imageStream decodes the transforms and the main image of a VP8L stream of
+// w by h pixels and returns its ARGB pixels
+func (r *vp8lReader) imageStream(w, h int) (pix []uint32, err error) {
+	var transforms []vp8lTransform
+	xsize, seen := w, 0
+	for r.readBits(1) == 1 {
+		t := vp8lTransform{kind: r.readBits(2), width: xsize}
+		if seen&(1<<t.kind) != 0 {
+			return nil, errVP8LData
+		}
+		seen |= 1 << t.kind
+		switch t.kind {
+		case vp8lPredictor, vp8lColor:
+			t.bits = uint(r.readBits(3)) + 2
+			t.data, err = r.entropyImage(vp8lSubsample(xsize, t.bits), vp8lSubsample(h, t.bits), false)
+		case vp8lColorIndexing:
+			size := int(r.readBits(8)) + 1
+			t.data, err = r.entropyImage(size, 1, false)
+			if err == nil {
+				for j := 1; j < size; j++ {
+					t.data[j] = vp8lAdd(t.data[j], t.data[j-1])
+				}
+			}
+			switch {
+			case size <= 2:
+				t.bits = 3
+			case size <= 4:
+				t.bits = 2
+			case size <= 16:
+				t.bits = 1
+			}
+			xsize = vp8lSubsample(xsize, t.bits)
+		}
+		if err != nil {
+			return
+		}
+		transforms = append(transforms, t)
+	}
+	pix, err = r.entropyImage(xsize, h, true)
+	for j := len(transforms) - 1; j >= 0 && err == nil; j-- {
+		pix = transforms[j].inverse(pix, h)
+	}
+	return
+}
+
+// entropyImage decodes an entropy coded image of w by h pixels, with its own
+// meta prefix codes if it is the main image
+func (r *vp8lReader) entropyImage(w, h int, main bool) (pix []uint32, err error) {
+	var cache []uint32
+	cacheBits := uint(0)
+	if r.readBits(1) == 1 {
+		cacheBits = uint(r.readBits(4))
+		if cacheBits < 1 || cacheBits > 11 {
+			return nil, errVP8LData
+		}
+		cache = make([]uint32, 1<<cacheBits)
+	}
+	var meta []uint32
+	metaBits, metaW, groupCount := uint(0), 0, 1
+	if main && r.readBits(1) == 1 {
+		metaBits = uint(r.readBits(3)) + 2
+		metaW = vp8lSubsample(w, metaBits)
+		meta, err = r.entropyImage(metaW, vp8lSubsample(h, metaBits), false)
+		if err != nil {
+			return
+		}
+		for j, p := range meta {
+			meta[j] = p >> 8 & 0xffff
+			if int(meta[j]) >= groupCount {
+				groupCount = int(meta[j]) + 1
+			}
+		}
+	}
+	sizes := [5]int{256 + 24 + len(cache), 256, 256, 256, 40}
+	groups := make([][5]*vp8lCode, groupCount)
+	for g := range groups {
+		for k, size := range sizes {
+			if groups[g][k], err = r.readCode(size); err != nil {
+				return
+			}
+		}
+	}
+	pix = make([]uint32, w*h)
+	group := &groups[0]
+	for pos := 0; pos < len(pix) && r.err == nil; {
+		x, y := pos%w, pos/w
+		if meta != nil {
+			group = &groups[meta[(y>>metaBits)*metaW+x>>metaBits]]
+		}
+		sym := r.decode(group[0])
+		switch {
+		case sym < 256:
+			red := uint32(r.decode(group[1]))
+			blue := uint32(r.decode(group[2]))
+			alpha := uint32(r.decode(group[3]))
+			pix[pos] = alpha<<24 | red<<16 | uint32(sym)<<8 | blue
+			pos++
+		case sym < 256+24:
+			length := r.lz77Value(sym - 256)
+			dist := r.lz77Value(r.decode(group[4]))
+			if dist > 120 {
+				dist -= 120
+			} else {
+				d := vp8lDistances[dist-1]
+				dist = d[0] + d[1]*w
+				if dist < 1 {
+					dist = 1
+				}
+			}
+			if dist > pos || pos+length > len(pix) {
+				return nil, errVP8LData
+			}
+			for j := 0; j < length; j++ {
+				pix[pos] = pix[pos-dist]
+				if cache != nil {
+					cache[(0x1e35a7bd*pix[pos])>>(32-cacheBits)] = pix[pos]
+				}
+				pos++
+			}
+			continue
+		default:
+			if sym-280 >= len(cache) {
+				return nil, errVP8LData
+			}
+			pix[pos] = cache[sym-280]
+			pos++
+		}
+		if cache != nil {
+			cache[(0x1e35a7bd*pix[pos-1])>>(32-cacheBits)] = pix[pos-1]
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return
+}
+
+// lz77Value returns the length or distance of prefix code sym
+func (r *vp8lReader) lz77Value(sym int) int {
+	if sym < 4 {
+		return sym + 1
+	}
+	extra := uint(sym-2) >> 1
+	offset := (2 + sym&1) << extra
+	return offset + int(r.readBits(extra)) + 1
+}
+
+// vp8lAdd adds the channels of ARGB pixels a and b modulo 256
+func vp8lAdd(a, b uint32) uint32 {
+	return (a&0xff00ff00+b&0xff00ff00)&0xff00ff00 | (a&0x00ff00ff+b&0x00ff00ff)&0x00ff00ff
+}
+
+// vp8lAverage returns the average of the channels of ARGB pixels a and b
+func vp8lAverage(a, b uint32) uint32 {
+	return ((a^b)&0xfefefefe)>>1 + a&b
+}
+
+// vp8lSelect returns the one of l and t closest to the gradient l + t - tl
+func vp8lSelect(l, t, tl uint32) uint32 {
+	dl, dt := 0, 0
+	for s := uint(0); s < 32; s += 8 {
+		cl, ct, ctl := int(l>>s&0xff), int(t>>s&0xff), int(tl>>s&0xff)
+		dl += vp8lAbs(ct - ctl)
+		dt += vp8lAbs(cl - ctl)
+	}
+	if dl < dt {
+		return l
+	}
+	return t
+}
+
+func vp8lAbs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func vp8lClamp(v int) uint32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint32(v)
+}
+
+// vp8lClampFull returns the clamped gradient a + b - c of each channel
+func vp8lClampFull(a, b, c uint32) (p uint32) {
+	for s := uint(0); s < 32; s += 8 {
+		p |= vp8lClamp(int(a>>s&0xff)+int(b>>s&0xff)-int(c>>s&0xff)) << s
+	}
+	return
+}
+
+// vp8lClampHalf returns the clamped a + (a - b) / 2 of each channel
+func vp8lClampHalf(a, b uint32) (p uint32) {
+	for s := uint(0); s < 32; s += 8 {
+		ca, cb := int(a>>s&0xff), int(b>>s&0xff)
+		p |= vp8lClamp(ca+(ca-cb)/2) << s
+	}
+	return
+}
+
+// vp8lColorDelta returns the color transform delta of t applied to c
+func vp8lColorDelta(t, c uint32) uint32 {
+	return uint32(int(int8(t)) * int(int8(c)) >> 5)
+}
+
+// inverse undoes transform t on the pixels pix of h rows
+func (t *vp8lTransform) inverse(pix []uint32, h int) []uint32 {
+	w := t.width
+	switch t.kind {
+	case vp8lPredictor:
+		blocksW := vp8lSubsample(w, t.bits)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				pos := y*w + x
+				var pred uint32
+				switch {
+				case y == 0 && x == 0:
+					pred = 0xff000000
+				case y == 0:
+					pred = pix[pos-1]
+				case x == 0:
+					pred = pix[pos-w]
+				default:
+					// The top right pixel of the last column is the first one
+					// of the current row
+					l, t0, tl, tr := pix[pos-1], pix[pos-w], pix[pos-w-1], pix[pos-w+1]
+					switch t.data[(y>>t.bits)*blocksW+x>>t.bits] >> 8 & 0xf {
+					case 1:
+						pred = l
+					case 2:
+						pred = t0
+					case 3:
+						pred = tr
+					case 4:
+						pred = tl
+					case 5:
+						pred = vp8lAverage(vp8lAverage(l, tr), t0)
+					case 6:
+						pred = vp8lAverage(l, tl)
+					case 7:
+						pred = vp8lAverage(l, t0)
+					case 8:
+						pred = vp8lAverage(tl, t0)
+					case 9:
+						pred = vp8lAverage(t0, tr)
+					case 10:
+						pred = vp8lAverage(vp8lAverage(l, tl), vp8lAverage(t0, tr))
+					case 11:
+						pred = vp8lSelect(l, t0, tl)
+					case 12:
+						pred = vp8lClampFull(l, t0, tl)
+					case 13:
+						pred = vp8lClampHalf(vp8lAverage(l, t0), tl)
+					default:
+						pred = 0xff000000
+					}
+				}
+				pix[pos] = vp8lAdd(pix[pos], pred)
+			}
+		}
+	case vp8lColor:
+		blocksW := vp8lSubsample(w, t.bits)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				pos := y*w + x
+				e := t.data[(y>>t.bits)*blocksW+x>>t.bits]
+				p := pix[pos]
+				green := p >> 8
+				red := p>>16 + vp8lColorDelta(e, green)
+				blue := p + vp8lColorDelta(e>>8, green) + vp8lColorDelta(e>>16, red)
+				pix[pos] = p&0xff00ff00 | red&0xff<<16 | blue&0xff
+			}
+		}
+	case vp8lSubtractGreen:
+		for pos, p := range pix {
+			green := p >> 8 & 0xff
+			pix[pos] = vp8lAdd(p, green<<16|green)
+		}
+	case vp8lColorIndexing:
+		perPixel := 1 << t.bits
+		bitsPerIndex := uint(8) >> t.bits
+		packedW := vp8lSubsample(w, t.bits)
+		out := make([]uint32, w*h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				p := pix[y*packedW+x>>t.bits] >> 8 & 0xff
+				idx := int(p>>(uint(x%perPixel)*bitsPerIndex)) & (1<<bitsPerIndex - 1)
+				if idx < len(t.data) {
+					out[y*w+x] = t.data[idx]
+				}
+			}
+		}
+		return out
+	}
+	return pix
+}
+
+// vp8lDistances are the (x, y) offsets of the 120 short distance codes
+var vp8lDistances = [120][2]int{
+	{0, 1}, {1, 0}, {1, 1}, {-1, 1}, {0, 2}, {2, 0}, {1, 2}, {-1, 2},
+	{2, 1}, {-2, 1}, {2, 2}, {-2, 2}, {0, 3}, {3, 0}, {1, 3}, {-1, 3},
+	{3, 1}, {-3, 1}, {2, 3}, {-2, 3}, {3, 2}, {-3, 2}, {0, 4}, {4, 0},
+	{1, 4}, {-1, 4}, {4, 1}, {-4, 1}, {3, 3}, {-3, 3}, {2, 4}, {-2, 4},
+	{4, 2}, {-4, 2}, {0, 5}, {3, 4}, {-3, 4}, {4, 3}, {-4, 3}, {5, 0},
+	{1, 5}, {-1, 5}, {5, 1}, {-5, 1}, {2, 5}, {-2, 5}, {5, 2}, {-5, 2},
+	{4, 4}, {-4, 4}, {3, 5}, {-3, 5}, {5, 3}, {-5, 3}, {0, 6}, {6, 0},
+	{1, 6}, {-1, 6}, {6, 1}, {-6, 1}, {2, 6}, {-2, 6}, {6, 2}, {-6, 2},
+	{4, 5}, {-4, 5}, {5, 4}, {-5, 4}, {3, 6}, {-3, 6}, {6, 3}, {-6, 3},
+	{0, 7}, {7, 0}, {1, 7}, {-1, 7}, {5, 5}, {-5, 5}, {7, 1}, {-7, 1},
+	{4, 6}, {-4, 6}, {6, 4}, {-6, 4}, {2, 7}, {-2, 7}, {7, 2}, {-7, 2},
+	{3, 7}, {-3, 7}, {7, 3}, {-7, 3}, {5, 6}, {-5, 6}, {6, 5}, {-6, 5},
+	{8, 0}, {4, 7}, {-4, 7}, {7, 4}, {-7, 4}, {8, 1}, {8, 2}, {6, 6},
+	{-6, 6}, {8, 3}, {5, 7}, {-5, 7}, {7, 5}, {-7, 5}, {8, 4}, {6, 7},
+	{-6, 7}, {7, 6}, {-7, 6}, {8, 5}, {7, 7}, {-7, 7}, {8, 6}, {8, 7},
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Watermark defines a stamp drawn on the pages of the document by
+// AddWatermark(), such as "DRAFT" across the page or a logo in a corner. The
+// stamp is scaled to the size of each page.
+type Watermark struct {
+	Text       string  // Text of the stamp, drawn over the image if both are set
+	Image      string  // Name of an image file, or of a registered image
+	FontFamily string  // Font family of the text, "Helvetica" if empty
+	FontStyle  string  // Font style of the text
+	Color      *Color  // Color of the text, gray if nil
+	Opacity    float64 // Opacity of the stamp from 0 to 1, 0.25 if zero
+	Angle      float64 // Rotation of the stamp in degrees counterclockwise
+	Scale      float64 // Share of the width and height within the margins of the page that the stamp fills at most, 0.8 if zero
+	Position   string  // Place of the stamp within the margins: "T", "B", "L", "R", a corner such as "TR", or "C" for the center if empty
+	Under      bool    // Draw the stamp under the content of the pages rather than over it
+	Layer      string  // Name of the layer of the stamp, that viewers can hide; none if empty
+	PrintOnly  bool    // Show the stamp on printed copies only, in Layer or in a "Watermark" layer
+	Pages      []int   // One-based numbers of the stamped pages, all if empty
+}
+
+// watermarkType is a watermark of the document
+type watermarkType struct {
+	Watermark
+	layer int // layer of the stamp, -1 if none
+}
+
+// AddWatermark stamps the pages of the document with wm: the pages already
+// written right away, the page being written and the pages added afterwards
+// as they are finished. Watermarks are drawn in the order they are added.
+//
+// The stamp is drawn with the opacity of wm using SetAlpha(). When wm names a
+// layer, or is meant for print only, it is drawn in an optional content
+// group that viewers list with the layers of AddLayer(), so that it can be
+// hidden. Watermarks that share a layer name share the layer.
+func (f *Fpdf) AddWatermark(wm Watermark) {
+	if f.err != nil || (wm.Text == "" && wm.Image == "") {
+		return
+	}
+	mark := watermarkType{Watermark: wm, layer: -1}
+	if wm.Layer != "" || wm.PrintOnly {
+		name := wm.Layer
+		if name == "" {
+			name = "Watermark"
+		}
+		mark.layer = f.watermarkLayer(name, wm.PrintOnly)
+	}
+	f.watermarks = append(f.watermarks, mark)
+	for n := 1; n < len(f.pages) && f.err == nil; n++ {
+		if n != f.openPage {
+			f.stampPage(n, mark)
+		}
+	}
+}
+
+// watermarkLayer returns the layer named name, added if needed
+func (f *Fpdf) watermarkLayer(name string, printOnly bool) int {
+	for j, l := range f.layer.list {
+		if l.name == name {
+			f.layer.list[j].printOnly = f.layer.list[j].printOnly || printOnly
+			return j
+		}
+	}
+	id := f.AddLayer(name, true)
+	f.layer.list[id].printOnly = printOnly
+	return id
+}
+
+// stamps returns true if wm applies to the one-based page pageNum
+func (wm *Watermark) stamps(pageNum int) bool {
+	if len(wm.Pages) == 0 {
+		return true
+	}
+	for _, n := range wm.Pages {
+		if n == pageNum {
+			return true
+		}
+	}
+	return false
+}
+
+// stampPage draws the watermarks of marks that apply to the one-based page
+// pageNum, under or over its current content. The current page and the
+// graphic state of the document are left unchanged.
+func (f *Fpdf) stampPage(pageNum int, marks ...watermarkType) {
+	if len(marks) == 0 {
+		return
+	}
+	page, g, state := f.page, f.pageGeometry(), f.state
+	x, y := f.x, f.y
+	family, style, sizePt, size := f.fontFamily, f.fontStyle, f.fontSizePt, f.fontSize
+	font, underline, strikeout := f.currentFont, f.underline, f.strikeout
+	color, colorFlag := f.color, f.colorFlag
+	alpha, blendMode := f.alpha, f.blendMode
+	content := f.pages[pageNum]
+	f.selectPage(pageNum)
+	f.state = 2
+	for _, mark := range marks {
+		if !mark.stamps(pageNum) || f.err != nil {
+			continue
+		}
+		stamp := new(bytes.Buffer)
+		f.pages[pageNum] = stamp
+		f.SetError(f.putWatermark(&mark))
+		if mark.Under {
+			stamp.Write(content.Bytes())
+			content = stamp
+		} else {
+			content.Write(stamp.Bytes())
+		}
+	}
+	f.pages[pageNum] = content
+	f.page, f.state = page, state
+	f.setPageGeometry(g)
+	f.x, f.y = x, y
+	f.fontFamily, f.fontStyle, f.fontSizePt, f.fontSize = family, style, sizePt, size
+	f.currentFont, f.underline, f.strikeout = font, underline, strikeout
+	f.color, f.colorFlag = color, colorFlag
+	f.alpha, f.blendMode = alpha, blendMode
+}
+
+// putWatermark draws mark on the current page
+func (f *Fpdf) putWatermark(mark *watermarkType) (err error) {
+	familyStr := mark.FontFamily
+	if familyStr == "" {
+		familyStr = "Helvetica"
+	}
+	opacity := mark.Opacity
+	if opacity == 0 {
+		opacity = 0.25
+	}
+	if opacity < 0 || opacity > 1 {
+		return fmt.Errorf("watermark opacity (0.0 - 1.0) is out of range: %.3f", opacity)
+	}
+	// Size of the stamp, before scaling and rotation. What can fail is done
+	// before the first operator is written, so that the marked content and
+	// the graphics state are always balanced.
+	var info *ImageInfoType
+	var wd, ht, textWd float64
+	if mark.Text != "" {
+		if err = f.SetFont(familyStr, mark.FontStyle, 100); err != nil {
+			return
+		}
+		textWd = f.GetStringWidth(mark.Text)
+		wd, ht = textWd, 0.7*f.fontSize
+	}
+	if mark.Image != "" {
+		if info, err = f.RegisterImageOptions(mark.Image, ImageOptions{}); err != nil {
+			return
+		}
+		wd, ht = info.Width(), info.Height()
+	}
+	if wd == 0 || ht == 0 {
+		wd, ht = 1, 1
+	}
+	scale := mark.Scale
+	if scale == 0 {
+		scale = 0.8
+	}
+	areaWd, areaHt := f.w-f.lMargin-f.rMargin, f.h-f.tMargin-f.bMargin
+	sin, cos := math.Sincos(mark.Angle * math.Pi / 180)
+	sin, cos = math.Abs(sin), math.Abs(cos)
+	z := math.Min(scale*areaWd/(wd*cos+ht*sin), scale*areaHt/(wd*sin+ht*cos))
+	wd, ht = wd*z, ht*z
+	boxWd, boxHt := wd*cos+ht*sin, wd*sin+ht*cos
+	// Center of the stamp
+	cx, cy := f.lMargin+areaWd/2, f.tMargin+areaHt/2
+	switch {
+	case strings.Contains(mark.Position, "L"):
+		cx = f.lMargin + boxWd/2
+	case strings.Contains(mark.Position, "R"):
+		cx = f.w - f.rMargin - boxWd/2
+	}
+	switch {
+	case strings.Contains(mark.Position, "T"):
+		cy = f.tMargin + boxHt/2
+	case strings.Contains(mark.Position, "B"):
+		cy = f.h - f.bMargin - boxHt/2
+	}
+	if mark.layer >= 0 {
+		f.outf("/OC /OC%d BDC", mark.layer)
+	}
+	f.TransformBegin()
+	f.SetAlpha(opacity, "Normal")
+	if mark.Angle != 0 {
+		f.TransformRotate(mark.Angle, cx, cy)
+	}
+	if info != nil {
+		f.ImageOptions(mark.Image, cx-wd/2, cy-ht/2, wd, ht, false, ImageOptions{}, 0, "")
+	}
+	if mark.Text != "" {
+		// Over an image, the text spans most of its width
+		sizePt := 100 * z
+		if info != nil {
+			sizePt = math.Min(100*0.9*wd/textWd, ht*f.k)
+		}
+		// The font was set above, only its size changes
+		f.SetFontSize(sizePt)
+		if mark.Color != nil {
+			mark.Color.ToTextColor(f)
+		} else {
+			f.SetTextColor(128, 128, 128)
+		}
+		f.Text(cx-f.GetStringWidth(mark.Text)/2, cy+0.35*f.fontSize, mark.Text)
+	}
+	f.TransformEnd()
+	if mark.layer >= 0 {
+		f.out("EMC")
+	}
+	return
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2023-2025 Olivier Ruelle (github.com/oruelle)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package gofpdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// parsewebp extracts info from WebP data, decoding its lossy or lossless
+// image
+func (f *Fpdf) parsewebp(r io.Reader) (info *ImageInfoType) {
+	info = f.newImageInfo()
+	buf, err := bufferFromReader(r)
+	if err != nil {
+		f.err = err
+		return
+	}
+	img, err := decodeWebP(buf.Bytes())
+	if err != nil {
+		f.err = err
+		return
+	}
+	f.err = f.goimage(info, img, GoImageOptions{})
+	return
+}
+
+// decodeWebP decodes the still image of the RIFF container data
+func decodeWebP(data []byte) (img image.Image, err error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("invalid WebP image")
+	}
+	var alpha []byte
+	extended := false
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		pos += 8
+		if size > len(data)-pos {
+			return nil, errors.New("truncated WebP image")
+		}
+		chunk := data[pos : pos+size]
+		pos += size + size&1
+		switch id {
+		case "VP8X":
+			if len(chunk) < 10 {
+				return nil, errors.New("invalid WebP extended header")
+			}
+			if chunk[0]&0x02 != 0 {
+				return nil, errors.New("animated WebP images are not supported")
+			}
+			extended = true
+		case "ALPH":
+			if extended {
+				alpha = chunk
+			}
+		case "VP8L":
+			return decodeVP8L(chunk)
+		case "VP8 ":
+			var yuv *image.YCbCr
+			if yuv, err = decodeVP8(chunk); err != nil || alpha == nil {
+				return yuv, err
+			}
+			b := yuv.Bounds()
+			nyuva := &image.NYCbCrA{YCbCr: *yuv, AStride: b.Dx()}
+			nyuva.A, err = decodeWebPAlpha(alpha, b.Dx(), b.Dy())
+			return nyuva, err
+		}
+	}
+	return nil, errors.New("WebP image has no image data")
+}
+
+// decodeWebPAlpha returns the alpha values of the w by h pixels of the ALPH
+// chunk data
+func decodeWebPAlpha(data []byte, w, h int) (alpha []byte, err error) {
+	if len(data) < 1 {
+		return nil, errors.New("invalid WebP alpha chunk")
+	}
+	switch data[0] & 3 {
+	case 0:
+		if len(data)-1 < w*h {
+			return nil, errors.New("truncated WebP alpha chunk")
+		}
+		alpha = append([]byte(nil), data[1:1+w*h]...)
+	case 1:
+		// Lossless stream without header, with the alpha in the green channel
+		r := &vp8lReader{data: data[1:]}
+		var pix []uint32
+		if pix, err = r.imageStream(w, h); err != nil {
+			return
+		}
+		alpha = make([]byte, w*h)
+		for j, p := range pix {
+			alpha[j] = uint8(p >> 8)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WebP alpha compression %d", data[0]&3)
+	}
+	// Unfiltering, the first row and column being predicted horizontally
+	// and vertically whatever the method
+	filter := data[0] >> 2 & 3
+	if filter == 0 {
+		return
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pos := y*w + x
+			var pred byte
+			switch {
+			case x == 0 && y == 0:
+			case y == 0:
+				pred = alpha[pos-1]
+			case x == 0:
+				pred = alpha[pos-w]
+			case filter == 1:
+				pred = alpha[pos-1]
+			case filter == 2:
+				pred = alpha[pos-w]
+			default:
+				g := int(alpha[pos-1]) + int(alpha[pos-w]) - int(alpha[pos-w-1])
+				if g < 0 {
+					g = 0
+				} else if g > 255 {
+					g = 255
+				}
+				pred = byte(g)
+			}
+			alpha[pos] += pred
+		}
+	}
+	return
+}